@@ -1,6 +1,7 @@
 package main
 
 import (
+	"consensus/common/procman"
 	"consensus/common/proto"
 	"context"
 	"encoding/json"
@@ -16,9 +17,14 @@ import (
 )
 
 var (
-	processes = make(map[int]*exec.Cmd)
-	mu        sync.Mutex
-	ports     = []string{"50050", "50051", "50052", "50053", "50054"}
+	// Chạy file exe nằm trong folder Raft, trong bối cảnh thư mục Raft để logs nằm đúng chỗ
+	processes = &procman.Manager{
+		Binary: "./raft_node.exe",
+		Dir:    "Raft",
+		Args:   func(id int) []string { return []string{"-id", strconv.Itoa(id)} },
+	}
+	mu    sync.Mutex
+	ports = []string{"50050", "50051", "50052", "50053", "50054"}
 )
 
 func main() {
@@ -66,16 +72,9 @@ func toggleNode(w http.ResponseWriter, r *http.Request) {
 	mu.Lock()
 	defer mu.Unlock()
 	if action == "off" {
-		if c, ok := processes[id]; ok {
-			c.Process.Kill()
-			delete(processes, id)
-		}
+		processes.Stop(id)
 	} else {
-		// Chạy file exe nằm trong folder Raft
-		cmd := exec.Command("./Raft/raft_node.exe", "-id", strconv.Itoa(id))
-		cmd.Dir = "Raft" // Chạy trong bối cảnh thư mục Raft để logs nằm đúng chỗ
-		cmd.Start()
-		processes[id] = cmd
+		processes.Start(id)
 	}
 }
 
@@ -83,12 +82,7 @@ func startAll(w http.ResponseWriter, r *http.Request) {
 	leader := r.URL.Query().Get("leader")
 	mu.Lock()
 	for i := 0; i < 5; i++ {
-		if _, running := processes[i]; !running {
-			cmd := exec.Command("./raft_node.exe", "-id", strconv.Itoa(i))
-			cmd.Dir = "Raft" // Đảm bảo Dir là Raft
-			cmd.Start()
-			processes[i] = cmd
-		}
+		processes.Start(i)
 	}
 	mu.Unlock()
 	if leader != "" {
@@ -105,10 +99,8 @@ func resetAll(w http.ResponseWriter, r *http.Request) {
 	mu.Lock()
 	defer mu.Unlock()
 	// Tắt sạch sẽ trên Windows
+	processes.StopAll()
 	exec.Command("taskkill", "/F", "/IM", "raft_node.exe", "/T").Run()
-	for k := range processes {
-		delete(processes, k)
-	}
 }
 func partition(w http.ResponseWriter, r *http.Request) {
 	action := r.URL.Query().Get("action")
@@ -0,0 +1,56 @@
+package kv
+
+import "sort"
+
+// Span là khoảng key [Start, End), End rỗng = tới cuối keyspace.
+type Span struct {
+	Start string
+	End   string
+}
+
+func (s Span) overlaps(start, end string) bool {
+	return (end == "" || s.Start < end) && (s.End == "" || start < s.End)
+}
+
+// removeSpan bỏ [sp.Start, sp.End) khỏi danh sách, giữ phần nằm ngoài khoảng này.
+func removeSpan(spans []Span, sp Span) []Span {
+	var kept []Span
+	for _, s := range spans {
+		if !s.overlaps(sp.Start, sp.End) {
+			kept = append(kept, s)
+			continue
+		}
+		if s.Start < sp.Start {
+			kept = append(kept, Span{s.Start, sp.Start})
+		}
+		if sp.End != "" && (s.End == "" || sp.End < s.End) {
+			kept = append(kept, Span{sp.End, s.End})
+		}
+	}
+	return kept
+}
+
+// addSpan thêm khoảng và gộp các khoảng liền kề; danh sách luôn sắp theo Start, không chồng lấn.
+func addSpan(spans []Span, sp Span) []Span {
+	spans = append(removeSpan(spans, sp), sp)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		if last := &merged[len(merged)-1]; last.End == s.Start && last.End != "" {
+			last.End = s.End
+		} else {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// covers cho biết các khoảng (đã gộp) phủ kín [start, end).
+func covers(spans []Span, start, end string) bool {
+	for _, s := range spans {
+		if s.Start <= start && (s.End == "" || (end != "" && end <= s.End)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Package kv là state machine key-value chạy trên log Raft.
+// Mỗi LogEntry.command là một Command dạng JSON; mọi node apply cùng
+// thứ tự entry đã commit nên có cùng trạng thái.
+package kv
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	OpPut    = "put"
+	OpDelete = "delete"
+	OpCAS    = "cas"
+	OpTxn    = "txn"
+
+	// Di chuyển range giữa các nhóm (range sharding)
+	OpFence  = "range_fence"  // Thôi sở hữu [Key, End) và trả về dữ liệu của range
+	OpIngest = "range_ingest" // Nhận dữ liệu [Key, End) từ nhóm khác (Writes) và sở hữu range
+	OpDrop   = "range_drop"   // Xoá dữ liệu [Key, End) đã chuyển đi
+)
+
+// ErrWrongGroup: key thuộc range nhóm này không sở hữu (đã chuyển đi hoặc
+// chưa nạp xong), router cần đọc lại shard map.
+const ErrWrongGroup = "wrong group"
+
+type Command struct {
+	Op              string `json:"op"`
+	Key             string `json:"key"`
+	Value           string `json:"value,omitempty"`
+	ExpectedVersion int64  `json:"expected_version,omitempty"` // CAS: 0 = key phải chưa tồn tại
+	End             string `json:"end,omitempty"`              // Lệnh range: [Key, End), rỗng = tới cuối
+
+	// Txn: read-set (Compares) và write-set (Writes)
+	Compares []Compare `json:"compares,omitempty"`
+	Writes   []Write   `json:"writes,omitempty"`
+}
+
+type Compare struct {
+	Key     string `json:"key"`
+	Version int64  `json:"version"` // 0 = key phải chưa tồn tại
+}
+
+type Write struct {
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Delete  bool   `json:"delete,omitempty"`
+	Version int64  `json:"version,omitempty"` // Ingest: version của key ở nhóm nguồn
+}
+
+// Encode trả về chuỗi đặt vào LogEntry.command.
+func (c Command) Encode() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+type Entry struct {
+	Key     string
+	Value   string
+	Version int64 // Index của LogEntry ghi key lần cuối (cộng độ lệch, xem Store.shift)
+}
+
+type Result struct {
+	OK    bool
+	Found bool  // Key tồn tại trước khi apply
+	Entry Entry // Giá trị sau khi apply (CAS thất bại: giá trị đang có)
+	Err   string
+	Keys  []KeyResult // Txn: compares rồi writes, theo thứ tự trong command
+}
+
+type KeyResult struct {
+	Key   string
+	OK    bool
+	Entry Entry
+	Err   string
+}
+
+// Store không tự khoá: RaftNode gọi Apply/Get/Scan khi đang giữ mu.
+type Store struct {
+	data  map[string]Entry
+	owned []Span // Range nhóm đang phục vụ; ngoài các range này ghi/đọc trả ErrWrongGroup
+
+	// Key nhận từ nhóm khác giữ version của nhóm nguồn, có thể lớn hơn index
+	// log của nhóm này. Version mới = index + shift, với shift đủ lớn để luôn
+	// vượt mọi version đã nhận: version của một key không bao giờ lùi sau khi
+	// range bị chuyển, CAS/Txn với version cũ không thể khớp nhầm.
+	shift int64
+}
+
+// NewStore tạo store sở hữu toàn bộ keyspace (nhóm metadata, chạy một nhóm).
+func NewStore() *Store {
+	return &Store{data: make(map[string]Entry), owned: []Span{{}}}
+}
+
+// NewEmptyStore tạo store chưa sở hữu range nào; range được nhận qua OpIngest.
+func NewEmptyStore() *Store {
+	return &Store{data: make(map[string]Entry)}
+}
+
+// Apply thực thi entry đã commit tại `index`. Command không phải JSON
+// (block của dashboard, lệnh của chaos runner, no-op) được bỏ qua.
+func (s *Store) Apply(index int64, command string) Result {
+	var c Command
+	if command == "" || json.Unmarshal([]byte(command), &c) != nil || c.Op == "" {
+		return Result{}
+	}
+	switch c.Op {
+	case OpFence:
+		s.owned = removeSpan(s.owned, Span{c.Key, c.End})
+		res := Result{OK: true}
+		for _, e := range s.Scan(c.Key, c.End, 0) {
+			res.Keys = append(res.Keys, KeyResult{Key: e.Key, OK: true, Entry: e})
+		}
+		return res
+	case OpIngest:
+		s.owned = addSpan(s.owned, Span{c.Key, c.End})
+		for _, w := range c.Writes {
+			v := w.Version
+			if v == 0 {
+				v = s.version(index) // Lệnh ingest ghi trước khi Write mang version
+			}
+			s.data[w.Key] = Entry{Key: w.Key, Value: w.Value, Version: v}
+			s.shift = max(s.shift, v-index)
+		}
+		return Result{OK: true}
+	case OpDrop:
+		for _, e := range s.Scan(c.Key, c.End, 0) {
+			delete(s.data, e.Key)
+		}
+		return Result{OK: true}
+	case OpTxn:
+		for _, cmp := range c.Compares {
+			if !s.OwnsKey(cmp.Key) {
+				return Result{Err: ErrWrongGroup}
+			}
+		}
+		for _, w := range c.Writes {
+			if !s.OwnsKey(w.Key) {
+				return Result{Err: ErrWrongGroup}
+			}
+		}
+		return s.txn(index, c)
+	}
+	if !s.OwnsKey(c.Key) {
+		return Result{Err: ErrWrongGroup}
+	}
+	cur, found := s.data[c.Key]
+	switch c.Op {
+	case OpPut:
+		e := Entry{Key: c.Key, Value: c.Value, Version: s.version(index)}
+		s.data[c.Key] = e
+		return Result{OK: true, Found: found, Entry: e}
+	case OpDelete:
+		delete(s.data, c.Key)
+		return Result{OK: true, Found: found, Entry: cur}
+	case OpCAS:
+		if cur.Version != c.ExpectedVersion {
+			return Result{Found: found, Entry: cur, Err: "version mismatch"}
+		}
+		e := Entry{Key: c.Key, Value: c.Value, Version: s.version(index)}
+		s.data[c.Key] = e
+		return Result{OK: true, Found: found, Entry: e}
+	}
+	return Result{Err: "unknown op " + c.Op}
+}
+
+// version trả về version cho key được ghi bởi entry tại `index`.
+func (s *Store) version(index int64) int64 {
+	return index + s.shift
+}
+
+// txn kiểm tra toàn bộ read-set trước; chỉ khi mọi compare khớp mới áp
+// write-set, nên giao dịch hoặc ghi hết hoặc không ghi gì.
+func (s *Store) txn(index int64, c Command) Result {
+	res := Result{OK: true}
+	for _, cmp := range c.Compares {
+		cur := s.data[cmp.Key]
+		kr := KeyResult{Key: cmp.Key, OK: cur.Version == cmp.Version, Entry: cur}
+		if !kr.OK {
+			kr.Err = "version mismatch"
+			res.OK = false
+		}
+		res.Keys = append(res.Keys, kr)
+	}
+	for _, w := range c.Writes {
+		kr := KeyResult{Key: w.Key, OK: res.OK}
+		switch {
+		case !res.OK:
+			kr.Entry, kr.Err = s.data[w.Key], "aborted"
+		case w.Delete:
+			delete(s.data, w.Key)
+		default:
+			kr.Entry = Entry{Key: w.Key, Value: w.Value, Version: s.version(index)}
+			s.data[w.Key] = kr.Entry
+		}
+		res.Keys = append(res.Keys, kr)
+	}
+	if !res.OK {
+		res.Err = "version mismatch"
+	}
+	return res
+}
+
+// Owns cho biết nhóm có sở hữu toàn bộ [start, end) không.
+func (s *Store) Owns(start, end string) bool {
+	return covers(s.owned, start, end)
+}
+
+// OwnsKey: Owns cho một key đơn lẻ.
+func (s *Store) OwnsKey(key string) bool {
+	return s.Owns(key, key+"\x00")
+}
+
+func (s *Store) Get(key string) (Entry, bool) {
+	e, ok := s.data[key]
+	return e, ok
+}
+
+// Scan trả về các key trong [start, end) theo thứ tự tăng dần; end rỗng = tới cuối.
+func (s *Store) Scan(start, end string, limit int) []Entry {
+	var keys []string
+	for k := range s.data {
+		if k >= start && (end == "" || k < end) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	entries := make([]Entry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, s.data[k])
+	}
+	return entries
+}
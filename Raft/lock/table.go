@@ -0,0 +1,159 @@
+// Package lock là state machine lock/lease chạy trên log Raft.
+//
+// Thời gian hết hạn được tính theo `now_ms` mà Leader ghi vào command lúc
+// propose, nên mọi node apply ra cùng kết quả. Fencing token là index của
+// entry Acquire: tăng nghiêm ngặt theo thời gian, tài nguyên phía sau chỉ
+// cần từ chối các thao tác mang token nhỏ hơn token lớn nhất đã thấy.
+package lock
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	OpAcquire = "lock_acquire"
+	OpRelease = "lock_release"
+	OpRenew   = "lock_renew"
+	OpExpire  = "lock_expire" // Leader propose khi phát hiện lease quá hạn
+
+	EventReleased = "released"
+	EventExpired  = "expired"
+)
+
+type Command struct {
+	Op    string `json:"op"`
+	Name  string `json:"name"`
+	Owner string `json:"owner,omitempty"`
+	TTLMs int64  `json:"ttl_ms,omitempty"`
+	Token int64  `json:"token,omitempty"`
+	Now   int64  `json:"now_ms"`
+}
+
+// Handles cho biết op có thuộc lock service không.
+func Handles(op string) bool {
+	return strings.HasPrefix(op, "lock_")
+}
+
+func (c Command) Encode() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+type Lease struct {
+	Name      string
+	Owner     string
+	Token     int64
+	ExpiresAt int64 // Unix ms
+}
+
+type Event struct {
+	Index int64
+	Name  string
+	Type  string
+	Owner string
+	Token int64
+}
+
+type Result struct {
+	OK    bool
+	Lease Lease // Lease hiện tại sau khi apply (thất bại: lease đang giữ lock)
+	Err   string
+}
+
+// Table không tự khoá: RaftNode gọi khi đang giữ mu.
+type Table struct {
+	leases map[string]Lease
+	last   map[string]Event // Sự kiện nhả lock gần nhất của mỗi lock
+}
+
+func NewTable() *Table {
+	return &Table{leases: make(map[string]Lease), last: make(map[string]Event)}
+}
+
+func (t *Table) Apply(index int64, command string) Result {
+	var c Command
+	if json.Unmarshal([]byte(command), &c) != nil || !Handles(c.Op) {
+		return Result{Err: "not a lock command"}
+	}
+	cur, held := t.leases[c.Name]
+	if held && cur.ExpiresAt <= c.Now && c.Op != OpRelease {
+		t.free(index, cur, EventExpired)
+		cur, held = Lease{}, false
+	}
+
+	switch c.Op {
+	case OpAcquire:
+		if held && cur.Owner != c.Owner {
+			return Result{Lease: cur, Err: "held"}
+		}
+		if held {
+			// Client đã giữ lock gọi lại Acquire: gia hạn, giữ nguyên token
+			cur.ExpiresAt = c.Now + c.TTLMs
+			t.leases[c.Name] = cur
+			return Result{OK: true, Lease: cur}
+		}
+		l := Lease{Name: c.Name, Owner: c.Owner, Token: index, ExpiresAt: c.Now + c.TTLMs}
+		t.leases[c.Name] = l
+		return Result{OK: true, Lease: l}
+	case OpRenew:
+		if !held || cur.Owner != c.Owner || cur.Token != c.Token {
+			if !held {
+				return Result{Err: "lease expired"}
+			}
+			return Result{Lease: cur, Err: "not holder"}
+		}
+		cur.ExpiresAt = c.Now + c.TTLMs
+		t.leases[c.Name] = cur
+		return Result{OK: true, Lease: cur}
+	case OpRelease:
+		if !held || cur.Owner != c.Owner || cur.Token != c.Token {
+			return Result{Lease: cur, Err: "not holder"}
+		}
+		t.free(index, cur, EventReleased)
+		return Result{OK: true}
+	case OpExpire:
+		// Lease đã hết hạn được giải phóng ở trên; nếu vẫn còn thì Leader đã đánh giá sai
+		return Result{OK: !held, Lease: cur}
+	}
+	return Result{Err: "unknown op " + c.Op}
+}
+
+func (t *Table) free(index int64, l Lease, typ string) {
+	delete(t.leases, l.Name)
+	t.last[l.Name] = Event{Index: index, Name: l.Name, Type: typ, Owner: l.Owner, Token: l.Token}
+}
+
+func (t *Table) Get(name string) (Lease, bool) {
+	l, ok := t.leases[name]
+	return l, ok
+}
+
+// LastEvent trả về lần nhả lock gần nhất (release hoặc hết hạn).
+func (t *Table) LastEvent(name string) (Event, bool) {
+	e, ok := t.last[name]
+	return e, ok
+}
+
+// Expired liệt kê các lease đã quá hạn theo đồng hồ `now` (Unix ms), sắp theo tên.
+func (t *Table) Expired(now int64) []Lease {
+	var out []Lease
+	for _, l := range t.leases {
+		if l.ExpiresAt <= now {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Leases liệt kê các lease đang giữ, sắp theo tên (dùng cho snapshot).
+func (t *Table) Leases() []Lease {
+	out := make([]Lease, 0, len(t.leases))
+	for _, l := range t.leases {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ClusterConfig mô tả các node của cluster: địa chỉ, độ ưu tiên bầu Leader và zone.
+//
+//	{
+//	  "preferred_zone": "us-east",
+//	  "max_clock_offset_ms": 50,
+//	  "nodes": [{"id": 0, "addr": "localhost:50050", "priority": 3, "zone": "us-east"}, ...]
+//	}
+//
+// Node có điểm cao hơn thắng bầu cử khi khoẻ: node điểm thấp trì hoãn ứng
+// cử, và Leader tự chuyển quyền về node điểm cao hơn khi node đó hồi phục.
+//
+// max_clock_offset_ms là độ lệch đồng hồ tối đa giả định giữa hai node
+// (mặc định 50ms), dùng khi Follower đổi thời điểm của Leader sang safe time.
+type ClusterConfig struct {
+	PreferredZone    string       `json:"preferred_zone"`
+	MaxClockOffsetMs int64        `json:"max_clock_offset_ms"`
+	Nodes            []NodeConfig `json:"nodes"`
+}
+
+type NodeConfig struct {
+	ID       int32  `json:"id"`
+	Addr     string `json:"addr"`
+	Priority int    `json:"priority"` // Mặc định 1
+	Zone     string `json:"zone"`
+}
+
+// electionStep là thời gian trì hoãn ứng cử cho mỗi bậc điểm (trong các điểm
+// khác nhau của cluster) mà node đứng sau node cao điểm nhất. Tổng trì hoãn
+// không vượt maxElectionDelay, nhỏ hơn minElectionTimeout: khi mọi node điểm
+// cao đều chết, node điểm thấp nhất vẫn ứng cử trong chưa tới hai lần timeout.
+const (
+	electionStep     = 200 * time.Millisecond
+	maxElectionDelay = 300 * time.Millisecond
+)
+
+// defaultCluster là 5 node trên localhost:50050-50054, cùng độ ưu tiên.
+func defaultCluster() *ClusterConfig {
+	c := &ClusterConfig{}
+	for i := int32(0); i < 5; i++ {
+		c.Nodes = append(c.Nodes, NodeConfig{ID: i, Addr: fmt.Sprintf("localhost:%d", 50050+i)})
+	}
+	return c
+}
+
+// LoadClusterConfig đọc file cấu hình của node `me`.
+func LoadClusterConfig(path string, me int32) (*ClusterConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c ClusterConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cluster: parse %s: %w", path, err)
+	}
+	if err := c.validate(me); err != nil {
+		return nil, fmt.Errorf("cluster: %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// validate kiểm tra id và địa chỉ không trùng, không rỗng, và `me` có trong
+// cluster: peers() dựng map theo id nên mục trùng sẽ âm thầm ghi đè nhau.
+func (c *ClusterConfig) validate(me int32) error {
+	if len(c.Nodes) == 0 {
+		return fmt.Errorf("no nodes")
+	}
+	ids := make(map[int32]bool)
+	addrs := make(map[string]int32)
+	for _, n := range c.Nodes {
+		if ids[n.ID] {
+			return fmt.Errorf("duplicate node id %d", n.ID)
+		}
+		ids[n.ID] = true
+		if n.Addr == "" {
+			return fmt.Errorf("node %d has no addr", n.ID)
+		}
+		if other, ok := addrs[n.Addr]; ok {
+			return fmt.Errorf("nodes %d and %d share addr %s", other, n.ID, n.Addr)
+		}
+		addrs[n.Addr] = n.ID
+	}
+	if !ids[me] {
+		return fmt.Errorf("node %d is not in the cluster", me)
+	}
+	return nil
+}
+
+func (c *ClusterConfig) peers() map[int32]string {
+	peers := make(map[int32]string)
+	for _, n := range c.Nodes {
+		peers[n.ID] = n.Addr
+	}
+	return peers
+}
+
+const defaultMaxClockOffset = 50 * time.Millisecond
+
+func (c *ClusterConfig) maxClockOffset() time.Duration {
+	if c.MaxClockOffsetMs <= 0 {
+		return defaultMaxClockOffset
+	}
+	return time.Duration(c.MaxClockOffsetMs) * time.Millisecond
+}
+
+// score: priority là bậc chính, nằm trong preferred_zone thắng khi cùng priority.
+func (c *ClusterConfig) score(id int32) int {
+	for _, n := range c.Nodes {
+		if n.ID == id {
+			p := n.Priority
+			if p == 0 {
+				p = 1
+			}
+			s := 2 * p
+			if c.PreferredZone != "" && n.Zone == c.PreferredZone {
+				s++
+			}
+			return s
+		}
+	}
+	return 0
+}
+
+// electionDelay là thời gian node `id` chờ thêm trước khi ứng cử, theo thứ
+// hạng điểm chứ không theo khoảng cách điểm: priority 100 và 1 chỉ cách một bậc.
+func (c *ClusterConfig) electionDelay(id int32) time.Duration {
+	mine := c.score(id)
+	higher := make(map[int]bool)
+	for _, n := range c.Nodes {
+		if s := c.score(n.ID); s > mine {
+			higher[s] = true
+		}
+	}
+	return min(time.Duration(len(higher))*electionStep, maxElectionDelay)
+}
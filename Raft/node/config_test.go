@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadClusterConfigRejectsBadNodes(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		json, err string
+	}{
+		"ok":             {`{"nodes":[{"id":0,"addr":"a:1"},{"id":1,"addr":"a:2"}]}`, ""},
+		"no nodes":       {`{"nodes":[]}`, "no nodes"},
+		"duplicate id":   {`{"nodes":[{"id":0,"addr":"a:1"},{"id":0,"addr":"a:2"}]}`, "duplicate node id 0"},
+		"empty addr":     {`{"nodes":[{"id":0,"addr":"a:1"},{"id":1}]}`, "node 1 has no addr"},
+		"duplicate addr": {`{"nodes":[{"id":0,"addr":"a:1"},{"id":1,"addr":"a:1"}]}`, "share addr a:1"},
+		"me missing":     {`{"nodes":[{"id":1,"addr":"a:1"},{"id":2,"addr":"a:2"}]}`, "node 0 is not in the cluster"},
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".json")
+		if err := os.WriteFile(path, []byte(tc.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadClusterConfig(path, 0)
+		if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"consensus/common/netem"
+	"consensus/common/proto"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Host chạy nhiều nhóm Raft độc lập trong cùng một process (Multi-Raft).
+// Mỗi nhóm có log, term, storage riêng; các nhóm dùng chung một kết nối
+// gRPC tới mỗi peer, mọi message mang group_id, và heartbeat của mọi nhóm
+// mà node đang làm Leader được gộp thành một AppendEntriesBatch mỗi peer.
+// Partition và link fault áp dụng cho cả process.
+type Host struct {
+	proto.UnimplementedConsensusServiceServer
+	me      int32
+	cfg     *ClusterConfig
+	peers   map[int32]string
+	dataDir string
+	faults  *netem.Injector
+
+	mu        sync.Mutex
+	groups    map[int32]*RaftNode
+	conns     map[int32]*grpc.ClientConn
+	blacklist map[int32]bool
+	dead      bool
+
+	moveMu sync.Mutex // Tuần tự hoá split/merge khởi phát từ Host này
+}
+
+func NewHost(id int32, cfg *ClusterConfig, dataDir string, groups int) *Host {
+	h := &Host{
+		me:        id,
+		cfg:       cfg,
+		peers:     cfg.peers(),
+		dataDir:   dataDir,
+		faults:    netem.New(),
+		groups:    make(map[int32]*RaftNode),
+		conns:     make(map[int32]*grpc.ClientConn),
+		blacklist: make(map[int32]bool),
+	}
+	for g := int32(0); g < int32(groups); g++ {
+		h.groups[g] = NewRaftNode(h, g)
+	}
+	go h.heartbeatLoop()
+	return h
+}
+
+// Group trả về nhóm `id`, nil nếu process không chứa nhóm đó.
+func (h *Host) Group(id int32) *RaftNode {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.groups[id]
+}
+
+// client trả về client dùng chung tới `peer`.
+func (h *Host) client(peer int32) (proto.ConsensusServiceClient, error) {
+	conn, err := h.conn(peer)
+	if err != nil {
+		return nil, err
+	}
+	return proto.NewConsensusServiceClient(conn), nil
+}
+
+// conn trả về kết nối dùng chung tới `peer`; kết nối được tạo một lần và
+// tự nối lại, RPC thất bại ngay khi peer không kết nối được.
+func (h *Host) conn(peer int32) (*grpc.ClientConn, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.dead {
+		return nil, fmt.Errorf("host stopped")
+	}
+	conn, ok := h.conns[peer]
+	if !ok {
+		var err error
+		conn, err = grpc.NewClient(h.peers[peer],
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: 50 * time.Millisecond, Multiplier: 1.6, MaxDelay: 500 * time.Millisecond}}),
+			h.faults.DialOption(peer))
+		if err != nil {
+			return nil, err
+		}
+		h.conns[peer] = conn
+	}
+	return conn, nil
+}
+
+// quorum là số node (kể cả chính mình) tạo thành đa số của cluster.
+func (h *Host) quorum() int {
+	return len(h.peers)/2 + 1
+}
+
+// reachable trả về các peer không bị partition chặn.
+func (h *Host) reachable() []int32 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var ids []int32
+	for id := range h.peers {
+		if id != h.me && !h.blacklist[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (h *Host) blocked(id int32) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.blacklist[id]
+}
+
+// Kill giả lập crash cả process: dừng mọi nhóm, vòng heartbeat và đóng kết nối.
+func (h *Host) Kill() {
+	h.mu.Lock()
+	h.dead = true
+	groups := h.groups
+	for id, conn := range h.conns {
+		conn.Close()
+		delete(h.conns, id)
+	}
+	h.mu.Unlock()
+	for _, g := range groups {
+		g.Kill()
+	}
+}
+
+// heartbeatTimeout là deadline của một AppendEntriesBatch.
+const heartbeatTimeout = 80 * time.Millisecond
+
+// heartbeatLoop mỗi 150ms gửi một AppendEntriesBatch tới mỗi peer chứa
+// AppendEntries của mọi nhóm mà node đang làm Leader, rồi trả reply về
+// từng nhóm để cập nhật commit.
+func (h *Host) heartbeatLoop() {
+	for {
+		h.mu.Lock()
+		if h.dead {
+			h.mu.Unlock()
+			return
+		}
+		var groups []*RaftNode
+		for _, g := range h.groups {
+			groups = append(groups, g)
+		}
+		h.mu.Unlock()
+
+		rounds := make(map[int32]*heartbeat)
+		batches := make(map[int32]*proto.AppendEntriesBatchArgs)
+		for _, g := range groups {
+			hb := g.startRound()
+			if hb == nil {
+				continue
+			}
+			rounds[g.group] = hb
+			for _, peer := range hb.peers {
+				if batches[peer] == nil {
+					batches[peer] = &proto.AppendEntriesBatchArgs{}
+				}
+				batches[peer].Groups = append(batches[peer].Groups, hb.args[peer])
+			}
+		}
+
+		replies := make(map[int32]map[int32]*proto.AppendEntriesReply) // Nhóm -> peer -> reply
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for peer, batch := range batches {
+			wg.Add(1)
+			go func(peer int32, batch *proto.AppendEntriesBatchArgs) {
+				defer wg.Done()
+				cl, err := h.client(peer)
+				if err != nil {
+					return
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), heartbeatTimeout)
+				defer cancel()
+				resp, err := cl.AppendEntriesBatch(ctx, batch)
+				if err != nil || len(resp.Groups) != len(batch.Groups) {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for i, r := range resp.Groups {
+					g := batch.Groups[i].GroupId
+					if replies[g] == nil {
+						replies[g] = make(map[int32]*proto.AppendEntriesReply)
+					}
+					replies[g][peer] = r
+				}
+			}(peer, batch)
+		}
+		wg.Wait()
+		for _, g := range groups {
+			if hb, ok := rounds[g.group]; ok {
+				g.finishRound(hb, replies[g.group])
+			}
+		}
+		time.Sleep(150 * time.Millisecond)
+	}
+}
+
+func (h *Host) lookup(group int32) (*RaftNode, error) {
+	if g := h.Group(group); g != nil {
+		return g, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown group %d", group)
+}
+
+func (h *Host) RequestVote(ctx context.Context, args *proto.RequestVoteArgs) (*proto.RequestVoteReply, error) {
+	if h.blocked(args.CandidateId) {
+		return nil, fmt.Errorf("Partition")
+	}
+	g, err := h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return g.RequestVote(ctx, args)
+}
+
+func (h *Host) AppendEntries(ctx context.Context, args *proto.AppendEntriesArgs) (*proto.AppendEntriesReply, error) {
+	if h.blocked(args.LeaderId) {
+		return nil, fmt.Errorf("Partition")
+	}
+	g, err := h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return g.AppendEntries(ctx, args)
+}
+
+// AppendEntriesBatch xử lý lần lượt AppendEntries của từng nhóm; nhóm không
+// tồn tại trên process này trả Success=false.
+func (h *Host) AppendEntriesBatch(ctx context.Context, args *proto.AppendEntriesBatchArgs) (*proto.AppendEntriesBatchReply, error) {
+	reply := &proto.AppendEntriesBatchReply{}
+	for _, a := range args.Groups {
+		if h.blocked(a.LeaderId) {
+			return nil, fmt.Errorf("Partition")
+		}
+		r := &proto.AppendEntriesReply{}
+		if g := h.Group(a.GroupId); g != nil {
+			r, _ = g.AppendEntries(ctx, a)
+		}
+		reply.Groups = append(reply.Groups, r)
+	}
+	return reply, nil
+}
+
+func (h *Host) TimeoutNow(ctx context.Context, args *proto.TimeoutNowArgs) (*proto.Empty, error) {
+	if h.blocked(args.LeaderId) {
+		return nil, fmt.Errorf("Partition")
+	}
+	g, err := h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return g.TimeoutNow(ctx, args)
+}
+
+// sendTimeoutNow yêu cầu `peer` bầu cử ngay để nhận quyền Leader của nhóm.
+func (h *Host) sendTimeoutNow(peer int32, args *proto.TimeoutNowArgs) {
+	cl, err := h.client(peer)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), heartbeatTimeout)
+	defer cancel()
+	cl.TimeoutNow(ctx, args)
+}
+
+func (h *Host) Propose(ctx context.Context, args *proto.ProposeArgs) (*proto.ProposeReply, error) {
+	group := args.GroupId
+	if args.Key != "" {
+		group = h.route(args.Key).Group
+	}
+	g := h.Group(group)
+	if g == nil {
+		return &proto.ProposeReply{Success: false}, nil
+	}
+	return g.Propose(ctx, args)
+}
+
+// GetStatus và ForceLeader thuộc nhóm 0 (nhóm mặc định của dashboard/chaos runner).
+func (h *Host) GetStatus(ctx context.Context, args *proto.Empty) (*proto.StatusReply, error) {
+	return h.Group(0).GetStatus(ctx, args)
+}
+
+func (h *Host) ForceLeader(ctx context.Context, args *proto.Empty) (*proto.Empty, error) {
+	return h.Group(0).ForceLeader(ctx, args)
+}
+
+func (h *Host) GetLedger(ctx context.Context, args *proto.LedgerArgs) (*proto.LedgerReply, error) {
+	g, err := h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return g.GetLedger(ctx, args)
+}
+
+func (h *Host) WatchCommitted(args *proto.WatchArgs, stream grpc.ServerStreamingServer[proto.WatchEvent]) error {
+	g, err := h.lookup(args.GroupId)
+	if err != nil {
+		return err
+	}
+	return g.WatchCommitted(args, stream)
+}
+
+func (h *Host) SetNetworkPartition(ctx context.Context, args *proto.PartitionArgs) (*proto.PartitionReply, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blacklist = make(map[int32]bool)
+	for _, id := range args.IsolatedNodeIds {
+		h.blacklist[id] = true
+	}
+	return &proto.PartitionReply{Success: true}, nil
+}
+
+func (h *Host) SetLinkFaults(ctx context.Context, args *proto.LinkFaultArgs) (*proto.LinkFaultReply, error) {
+	h.faults.SetFaults(args.Links)
+	return &proto.LinkFaultReply{Success: true}, nil
+}
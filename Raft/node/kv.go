@@ -0,0 +1,143 @@
+package main
+
+import (
+	"consensus/Raft/kv"
+	"consensus/common/proto"
+	"context"
+	"time"
+)
+
+// kvServer phục vụ KVService: router chuyển lệnh tới Leader của nhóm sở hữu
+// key; ghi đi qua log Raft của nhóm, đọc qua ReadIndex trên Leader của nhóm.
+type kvServer struct {
+	proto.UnimplementedKVServiceServer
+	h *Host
+}
+
+func toKeyValue(e kv.Entry) *proto.KeyValue {
+	return &proto.KeyValue{Key: e.Key, Value: e.Value, Version: e.Version}
+}
+
+func (s *kvServer) write(ctx context.Context, cmd kv.Command) (*proto.KVReply, error) {
+	res, leader, err := s.h.write(ctx, cmd.Key, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.KVReply{Success: res.OK, Found: res.Found, Kv: toKeyValue(res.Entry), Error: res.Err, LeaderId: leader}, nil
+}
+
+func (s *kvServer) Put(ctx context.Context, args *proto.KVPutArgs) (*proto.KVReply, error) {
+	return s.write(ctx, kv.Command{Op: kv.OpPut, Key: args.Key, Value: args.Value})
+}
+
+func (s *kvServer) Delete(ctx context.Context, args *proto.KVDeleteArgs) (*proto.KVReply, error) {
+	return s.write(ctx, kv.Command{Op: kv.OpDelete, Key: args.Key})
+}
+
+func (s *kvServer) CompareAndSwap(ctx context.Context, args *proto.KVCasArgs) (*proto.KVReply, error) {
+	return s.write(ctx, kv.Command{Op: kv.OpCAS, Key: args.Key, Value: args.Value, ExpectedVersion: args.ExpectedVersion})
+}
+
+// Txn chỉ hỗ trợ các key nằm trong cùng một range.
+func (s *kvServer) Txn(ctx context.Context, args *proto.KVTxnArgs) (*proto.KVTxnReply, error) {
+	cmd := kv.Command{Op: kv.OpTxn}
+	var keys []string
+	for _, c := range args.Compares {
+		cmd.Compares = append(cmd.Compares, kv.Compare{Key: c.Key, Version: c.Version})
+		keys = append(keys, c.Key)
+	}
+	for _, w := range args.Writes {
+		cmd.Writes = append(cmd.Writes, kv.Write{Key: w.Key, Value: w.Value, Delete: w.Delete})
+		keys = append(keys, w.Key)
+	}
+	if len(keys) > 0 {
+		r := s.h.route(keys[0])
+		for _, k := range keys {
+			if !r.Contains(k) {
+				return &proto.KVTxnReply{Error: "cross-shard txn"}, nil
+			}
+		}
+		cmd.Key = keys[0] // Chỉ dùng để route
+	}
+	res, leader, err := s.h.write(ctx, cmd.Key, cmd)
+	if err != nil {
+		return nil, err
+	}
+	reply := &proto.KVTxnReply{Success: res.OK, Error: res.Err, LeaderId: leader}
+	for _, k := range res.Keys {
+		reply.Results = append(reply.Results, &proto.KVKeyResult{Key: k.Key, Success: k.OK, Kv: toKeyValue(k.Entry), Error: k.Err})
+	}
+	return reply, nil
+}
+
+func bound(maxStalenessMs, maxLag int64) readBound {
+	return readBound{maxStaleness: time.Duration(maxStalenessMs) * time.Millisecond, maxLag: maxLag}
+}
+
+// ceilMs làm tròn lên để staleness báo cho client không nhỏ hơn thực tế.
+func ceilMs(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+func (s *kvServer) Get(ctx context.Context, args *proto.KVGetArgs) (*proto.KVReply, error) {
+	res, err := s.h.scan(ctx, args.Key, args.Key+"\x00", 1, bound(args.MaxStalenessMs, args.MaxLagEntries))
+	if err != nil {
+		return nil, err
+	}
+	reply := &proto.KVReply{Success: true, LeaderId: res.leader, StalenessMs: ceilMs(res.staleness), LagEntries: res.lag}
+	if len(res.kvs) == 1 {
+		reply.Found, reply.Kv = true, res.kvs[0]
+	}
+	return reply, nil
+}
+
+func (s *kvServer) Scan(ctx context.Context, args *proto.KVScanArgs) (*proto.KVScanReply, error) {
+	res, err := s.h.scan(ctx, args.Start, args.End, args.Limit, bound(args.MaxStalenessMs, args.MaxLagEntries))
+	if err != nil {
+		return nil, err
+	}
+	return &proto.KVScanReply{Success: true, Kvs: res.kvs, LeaderId: res.leader, StalenessMs: ceilMs(res.staleness), LagEntries: res.lag}, nil
+}
+
+// read đọc linearizable [start, end) khi node là Leader của nhóm; range đã
+// chuyển sang nhóm khác trả ErrWrongGroup.
+func (rn *RaftNode) read(ctx context.Context, start, end string, limit int) (*proto.KVScanReply, error) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if err := rn.readIndex(ctx); err == errNotLeader {
+		return &proto.KVScanReply{Error: err.Error(), LeaderId: rn.leaderId}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !rn.store.Owns(start, end) {
+		return &proto.KVScanReply{Error: kv.ErrWrongGroup, LeaderId: rn.me}, nil
+	}
+	reply := &proto.KVScanReply{Success: true, LeaderId: rn.me}
+	for _, e := range rn.store.Scan(start, end, limit) {
+		reply.Kvs = append(reply.Kvs, toKeyValue(e))
+	}
+	return reply, nil
+}
+
+// staleRead đọc [start, end) từ bản sao cục bộ khi độ trễ nằm trong bound:
+// staleness tính từ safeTime (mọi entry Leader commit trước lúc đó đã có ở
+// đây), lag là số entry tới leaderCommit nhận được lần cuối mà chưa apply.
+func (rn *RaftNode) staleRead(start, end string, limit int, b readBound) (readResult, error) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if rn.safeTime.IsZero() {
+		return readResult{}, errTooStale // Chưa từng nhận heartbeat từ Leader
+	}
+	staleness, lag := time.Since(rn.safeTime), max(rn.leaderCommit-rn.lastApplied, 0)
+	if (b.maxStaleness > 0 && staleness > b.maxStaleness) || (b.maxLag > 0 && lag > b.maxLag) {
+		return readResult{}, errTooStale
+	}
+	if !rn.store.Owns(start, end) {
+		return readResult{}, errWrongGroup
+	}
+	res := readResult{leader: rn.leaderId, staleness: staleness, lag: lag}
+	for _, e := range rn.store.Scan(start, end, limit) {
+		res.kvs = append(res.kvs, toKeyValue(e))
+	}
+	return res, nil
+}
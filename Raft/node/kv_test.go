@@ -0,0 +1,188 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func (c *testCluster) kv(id int32) *kvServer {
+	return &kvServer{h: c.hosts[id]}
+}
+
+func kvCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestKVPutGetDeleteCAS(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.kv(c.waitLeader(3 * time.Second))
+	ctx := kvCtx(t)
+
+	put, err := leader.Put(ctx, &proto.KVPutArgs{Key: "a", Value: "1"})
+	if err != nil || !put.Success || put.Found {
+		t.Fatalf("put a: %v %v", put, err)
+	}
+	get, _ := leader.Get(ctx, &proto.KVGetArgs{Key: "a"})
+	if !get.Found || get.Kv.Value != "1" || get.Kv.Version != put.Kv.Version {
+		t.Fatalf("get a = %v", get)
+	}
+
+	// CAS với version cũ thất bại và trả về giá trị hiện tại
+	stale := put.Kv.Version - 1
+	cas, _ := leader.CompareAndSwap(ctx, &proto.KVCasArgs{Key: "a", ExpectedVersion: stale, Value: "x"})
+	if cas.Success || cas.Error != "version mismatch" || cas.Kv.Value != "1" {
+		t.Fatalf("stale cas = %v", cas)
+	}
+	cas, _ = leader.CompareAndSwap(ctx, &proto.KVCasArgs{Key: "a", ExpectedVersion: put.Kv.Version, Value: "2"})
+	if !cas.Success || cas.Kv.Value != "2" || cas.Kv.Version <= put.Kv.Version {
+		t.Fatalf("cas = %v", cas)
+	}
+	// ExpectedVersion 0: chỉ tạo khi key chưa tồn tại
+	if cas, _ = leader.CompareAndSwap(ctx, &proto.KVCasArgs{Key: "a", Value: "3"}); cas.Success {
+		t.Fatalf("create-if-absent on existing key succeeded: %v", cas)
+	}
+	if cas, _ = leader.CompareAndSwap(ctx, &proto.KVCasArgs{Key: "b", Value: "3"}); !cas.Success {
+		t.Fatalf("create-if-absent = %v", cas)
+	}
+
+	del, _ := leader.Delete(ctx, &proto.KVDeleteArgs{Key: "a"})
+	if !del.Success || !del.Found {
+		t.Fatalf("delete a = %v", del)
+	}
+	if get, _ = leader.Get(ctx, &proto.KVGetArgs{Key: "a"}); !get.Success || get.Found {
+		t.Fatalf("get deleted a = %v", get)
+	}
+}
+
+func TestKVScan(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.kv(c.waitLeader(3 * time.Second))
+	ctx := kvCtx(t)
+	for _, k := range []string{"user/3", "user/1", "order/1", "user/2", "zone"} {
+		if r, err := leader.Put(ctx, &proto.KVPutArgs{Key: k, Value: k}); err != nil || !r.Success {
+			t.Fatalf("put %s: %v %v", k, r, err)
+		}
+	}
+	scan, _ := leader.Scan(ctx, &proto.KVScanArgs{Start: "user/", End: "user0"})
+	var keys []string
+	for _, kv := range scan.Kvs {
+		keys = append(keys, kv.Key)
+	}
+	if got, want := len(keys), 3; got != want || keys[0] != "user/1" || keys[2] != "user/3" {
+		t.Fatalf("scan user/ = %v", keys)
+	}
+	if scan, _ = leader.Scan(ctx, &proto.KVScanArgs{Start: "", Limit: 2}); len(scan.Kvs) != 2 || scan.Kvs[0].Key != "order/1" {
+		t.Fatalf("scan limit 2 = %v", scan.Kvs)
+	}
+}
+
+func TestKVFollowerRoutesToLeaderAndSurvivesLeaderCrash(t *testing.T) {
+	c := newTestCluster(t)
+	id := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	if r, _ := c.kv(id).Put(ctx, &proto.KVPutArgs{Key: "k", Value: "v"}); !r.Success {
+		t.Fatalf("put = %v", r)
+	}
+
+	// Follower chuyển yêu cầu tới Leader của nhóm và trả về id Leader đã xử lý
+	follower := (id + 1) % 5
+	if r, err := c.kv(follower).Get(ctx, &proto.KVGetArgs{Key: "k"}); err != nil || !r.Found || r.Kv.Value != "v" || r.LeaderId != id {
+		t.Fatalf("follower get = %v %v, want value served by %d", r, err, id)
+	}
+
+	// Leader mới rebuild state machine từ log đã commit
+	c.kill(id)
+	next := c.waitLeaderExcept(id, 3*time.Second)
+	ctx = kvCtx(t)
+	if r, _ := c.kv(next).Get(ctx, &proto.KVGetArgs{Key: "k"}); !r.Found || r.Kv.Value != "v" {
+		t.Fatalf("get after failover = %v", r)
+	}
+}
+
+// transfer chuyển `amount` từ `from` sang `to` bằng một Txn dựa trên
+// version vừa đọc; trả về reply để test kiểm tra kết quả từng key.
+func transfer(ctx context.Context, s *kvServer, from, to string, amount int) (*proto.KVTxnReply, error) {
+	a, err := s.Get(ctx, &proto.KVGetArgs{Key: from})
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.Get(ctx, &proto.KVGetArgs{Key: to})
+	if err != nil {
+		return nil, err
+	}
+	x, _ := strconv.Atoi(a.Kv.GetValue())
+	y, _ := strconv.Atoi(b.Kv.GetValue())
+	return s.Txn(ctx, &proto.KVTxnArgs{
+		Compares: []*proto.KVCompare{{Key: from, Version: a.Kv.GetVersion()}, {Key: to, Version: b.Kv.GetVersion()}},
+		Writes:   []*proto.KVWrite{{Key: from, Value: strconv.Itoa(x - amount)}, {Key: to, Value: strconv.Itoa(y + amount)}},
+	})
+}
+
+func TestKVTxnAllOrNothing(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.kv(c.waitLeader(3 * time.Second))
+	ctx := kvCtx(t)
+	alice, _ := leader.Put(ctx, &proto.KVPutArgs{Key: "alice", Value: "100"})
+	leader.Put(ctx, &proto.KVPutArgs{Key: "bob", Value: "0"})
+
+	// Read-set cũ (alice đã bị ghi sau khi đọc) -> không key nào bị ghi
+	leader.Put(ctx, &proto.KVPutArgs{Key: "alice", Value: "90"})
+	r, _ := leader.Txn(ctx, &proto.KVTxnArgs{
+		Compares: []*proto.KVCompare{{Key: "alice", Version: alice.Kv.Version}, {Key: "carol", Version: 0}},
+		Writes:   []*proto.KVWrite{{Key: "alice", Value: "0"}, {Key: "carol", Value: "100"}},
+	})
+	if r.Success || len(r.Results) != 4 {
+		t.Fatalf("stale txn = %v", r)
+	}
+	if r.Results[0].Success || r.Results[0].Kv.Value != "90" || !r.Results[1].Success || r.Results[2].Error != "aborted" {
+		t.Fatalf("per-key results = %v", r.Results)
+	}
+	if g, _ := leader.Get(ctx, &proto.KVGetArgs{Key: "carol"}); g.Found {
+		t.Fatalf("aborted txn wrote carol: %v", g)
+	}
+
+	r, _ = transfer(ctx, leader, "alice", "bob", 40)
+	if !r.Success || r.Results[2].Kv.Value != "50" || r.Results[3].Kv.Value != "40" || r.Results[2].Kv.Version != r.Results[3].Kv.Version {
+		t.Fatalf("transfer = %v", r)
+	}
+	// Txn có thể xoá key
+	r, _ = leader.Txn(ctx, &proto.KVTxnArgs{Writes: []*proto.KVWrite{{Key: "bob", Delete: true}}})
+	if g, _ := leader.Get(ctx, &proto.KVGetArgs{Key: "bob"}); !r.Success || g.Found {
+		t.Fatalf("txn delete = %v, get = %v", r, g)
+	}
+}
+
+func TestKVTxnConcurrentTransfersConserveTotal(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.kv(c.waitLeader(3 * time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	leader.Put(ctx, &proto.KVPutArgs{Key: "alice", Value: "100"})
+	leader.Put(ctx, &proto.KVPutArgs{Key: "bob", Value: "0"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Xung đột version thì đọc lại và thử lại
+			for ctx.Err() == nil {
+				if r, err := transfer(ctx, leader, "alice", "bob", 10); err == nil && r.Success {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	a, _ := leader.Get(ctx, &proto.KVGetArgs{Key: "alice"})
+	b, _ := leader.Get(ctx, &proto.KVGetArgs{Key: "bob"})
+	if a.Kv.Value != "50" || b.Kv.Value != "50" {
+		t.Fatalf("alice=%s bob=%s, want 50/50", a.Kv.Value, b.Kv.Value)
+	}
+}
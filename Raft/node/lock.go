@@ -0,0 +1,108 @@
+package main
+
+import (
+	"consensus/Raft/lock"
+	"consensus/common/proto"
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	lockExpireInterval = 100 * time.Millisecond
+	defaultLockTTL     = 10 * time.Second
+)
+
+// lockServer phục vụ LockService trên cùng đường commit với KVService.
+type lockServer struct {
+	proto.UnimplementedLockServiceServer
+	rn *RaftNode
+}
+
+// newLockServer khởi động vòng quét lease hết hạn: Leader propose lock_expire
+// để Watch nhận được sự kiện ngay cả khi không có client nào chạm tới lock.
+func newLockServer(rn *RaftNode) *lockServer {
+	s := &lockServer{rn: rn}
+	go s.expireLoop()
+	return s
+}
+
+func (s *lockServer) expireLoop() {
+	for range time.Tick(lockExpireInterval) {
+		rn := s.rn
+		rn.mu.Lock()
+		if rn.dead {
+			rn.mu.Unlock()
+			return
+		}
+		var expired []lock.Lease
+		if rn.state == Leader {
+			expired = rn.locks.Expired(time.Now().UnixMilli())
+		}
+		rn.mu.Unlock()
+		for _, l := range expired {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			rn.submit(ctx, lock.Command{Op: lock.OpExpire, Name: l.Name, Now: time.Now().UnixMilli()}.Encode())
+			cancel()
+		}
+	}
+}
+
+func (s *lockServer) propose(ctx context.Context, c lock.Command) (*proto.LockReply, error) {
+	if c.TTLMs <= 0 {
+		c.TTLMs = defaultLockTTL.Milliseconds()
+	}
+	c.Now = time.Now().UnixMilli()
+	out, err := s.rn.submit(ctx, c.Encode())
+	if err == errNotLeader {
+		return &proto.LockReply{Error: err.Error(), LeaderId: s.rn.leaderHint()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := out.(lock.Result)
+	return &proto.LockReply{
+		Success: res.OK, Error: res.Err, Owner: res.Lease.Owner, Token: res.Lease.Token,
+		ExpiresAtMs: res.Lease.ExpiresAt, LeaderId: s.rn.me,
+	}, nil
+}
+
+func (s *lockServer) Acquire(ctx context.Context, args *proto.LockArgs) (*proto.LockReply, error) {
+	return s.propose(ctx, lock.Command{Op: lock.OpAcquire, Name: args.Name, Owner: args.Owner, TTLMs: args.TtlMs})
+}
+
+func (s *lockServer) Renew(ctx context.Context, args *proto.LockArgs) (*proto.LockReply, error) {
+	return s.propose(ctx, lock.Command{Op: lock.OpRenew, Name: args.Name, Owner: args.Owner, TTLMs: args.TtlMs, Token: args.Token})
+}
+
+func (s *lockServer) Release(ctx context.Context, args *proto.LockArgs) (*proto.LockReply, error) {
+	return s.propose(ctx, lock.Command{Op: lock.OpRelease, Name: args.Name, Owner: args.Owner, Token: args.Token})
+}
+
+// Watch gửi một LockEvent mỗi khi lock được nhả (kể cả hết hạn) sau thời điểm
+// bắt đầu watch. Node nào cũng phục vụ được vì sự kiện đến từ log đã commit;
+// nếu lock bị nhả nhiều lần giữa hai lần gửi, chỉ sự kiện mới nhất được gửi.
+func (s *lockServer) Watch(args *proto.LockWatchArgs, stream grpc.ServerStreamingServer[proto.LockEvent]) error {
+	rn := s.rn
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	last, _ := rn.locks.LastEvent(args.Name)
+	for !rn.dead {
+		if err := rn.wait(stream.Context()); err != nil {
+			return nil
+		}
+		ev, ok := rn.locks.LastEvent(args.Name)
+		if !ok || ev.Index <= last.Index {
+			continue
+		}
+		last = ev
+		rn.mu.Unlock()
+		err := stream.Send(&proto.LockEvent{Name: ev.Name, Type: ev.Type, Owner: ev.Owner, Token: ev.Token, Index: ev.Index})
+		rn.mu.Lock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
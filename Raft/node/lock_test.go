@@ -0,0 +1,108 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func (c *testCluster) lockClient(id int32) proto.LockServiceClient {
+	c.t.Helper()
+	conn, err := grpc.NewClient(c.peers[id], grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { conn.Close() })
+	return proto.NewLockServiceClient(conn)
+}
+
+func TestLockAcquireReleaseFencing(t *testing.T) {
+	c := newTestCluster(t)
+	cl := c.lockClient(c.waitLeader(3 * time.Second))
+	ctx := kvCtx(t)
+
+	a, err := cl.Acquire(ctx, &proto.LockArgs{Name: "job", Owner: "worker-a", TtlMs: 5000})
+	if err != nil || !a.Success || a.Token <= 0 {
+		t.Fatalf("acquire a: %v %v", a, err)
+	}
+	b, _ := cl.Acquire(ctx, &proto.LockArgs{Name: "job", Owner: "worker-b", TtlMs: 5000})
+	if b.Success || b.Error != "held" || b.Owner != "worker-a" || b.Token != a.Token {
+		t.Fatalf("contended acquire = %v", b)
+	}
+	// Acquire lại bởi chính chủ: gia hạn, giữ nguyên token
+	if again, _ := cl.Acquire(ctx, &proto.LockArgs{Name: "job", Owner: "worker-a", TtlMs: 5000}); !again.Success || again.Token != a.Token {
+		t.Fatalf("re-acquire = %v", again)
+	}
+	if r, _ := cl.Release(ctx, &proto.LockArgs{Name: "job", Owner: "worker-b", Token: a.Token}); r.Success {
+		t.Fatalf("release by non-holder succeeded: %v", r)
+	}
+	renew, _ := cl.Renew(ctx, &proto.LockArgs{Name: "job", Owner: "worker-a", Token: a.Token, TtlMs: 5000})
+	if !renew.Success || renew.ExpiresAtMs <= a.ExpiresAtMs {
+		t.Fatalf("renew = %v (acquired with expiry %d)", renew, a.ExpiresAtMs)
+	}
+	if r, _ := cl.Release(ctx, &proto.LockArgs{Name: "job", Owner: "worker-a", Token: a.Token}); !r.Success {
+		t.Fatalf("release = %v", r)
+	}
+
+	b, _ = cl.Acquire(ctx, &proto.LockArgs{Name: "job", Owner: "worker-b", TtlMs: 5000})
+	if !b.Success || b.Token <= a.Token {
+		t.Fatalf("fencing token did not increase: %d then %v", a.Token, b)
+	}
+}
+
+func TestLockLeaseExpiresAndWatchNotifies(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	cl := c.lockClient(leader)
+	ctx := kvCtx(t)
+
+	a, _ := cl.Acquire(ctx, &proto.LockArgs{Name: "cron", Owner: "a", TtlMs: 300})
+	if !a.Success {
+		t.Fatalf("acquire = %v", a)
+	}
+	// Watch trên Follower: sự kiện đến từ log đã commit
+	watch, err := c.lockClient((leader+1)%5).Watch(ctx, &proto.LockWatchArgs{Name: "cron"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := watch.Recv()
+	if err != nil || ev.Type != "expired" || ev.Owner != "a" || ev.Token != a.Token {
+		t.Fatalf("watch event = %v %v", ev, err)
+	}
+	if r, _ := cl.Renew(ctx, &proto.LockArgs{Name: "cron", Owner: "a", Token: a.Token, TtlMs: 300}); r.Success {
+		t.Fatalf("renewed an expired lease: %v", r)
+	}
+
+	b, _ := cl.Acquire(ctx, &proto.LockArgs{Name: "cron", Owner: "b", TtlMs: 5000})
+	if !b.Success || b.Token <= a.Token {
+		t.Fatalf("acquire after expiry = %v", b)
+	}
+	if r, _ := cl.Release(ctx, &proto.LockArgs{Name: "cron", Owner: "b", Token: b.Token}); !r.Success {
+		t.Fatalf("release = %v", r)
+	}
+	if ev, err = watch.Recv(); err != nil || ev.Type != "released" || ev.Owner != "b" {
+		t.Fatalf("watch event = %v %v", ev, err)
+	}
+}
+
+func TestLockSurvivesLeaderCrash(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	a, _ := c.lockClient(leader).Acquire(ctx, &proto.LockArgs{Name: "db", Owner: "a", TtlMs: 10000})
+	if !a.Success {
+		t.Fatalf("acquire = %v", a)
+	}
+
+	c.kill(leader)
+	next := c.lockClient(c.waitLeaderExcept(leader, 3*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if b, _ := next.Acquire(ctx, &proto.LockArgs{Name: "db", Owner: "b", TtlMs: 1000}); b.Success || b.Owner != "a" || b.Token != a.Token {
+		t.Fatalf("lock lost across failover: %v", b)
+	}
+}
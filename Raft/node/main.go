@@ -1,20 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"consensus/Raft/kv"
+	"consensus/Raft/lock"
+	"consensus/Raft/shard"
+	"consensus/common/netem"
 	"consensus/common/proto"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type NodeState int
@@ -25,87 +32,440 @@ const (
 	Leader
 )
 
+// RaftNode là một nhóm Raft chạy trong Host; RPC tới peer đi qua kết nối
+// dùng chung của Host.
 type RaftNode struct {
-	proto.UnimplementedConsensusServiceServer
 	mu            sync.Mutex
 	me            int32
-	peers         map[int32]string
+	group         int32
+	host          *Host
 	state         NodeState
 	currentTerm   int64
 	votedFor      int32
 	logs          []*proto.LogEntry
-	blacklist     map[int32]bool
+	commitIndex   int64
 	electionTimer *time.Timer
+	dataDir       string
+	dead          bool
+
+	// State machine: apply các entry đã commit theo thứ tự
+	store       *kv.Store
+	locks       *lock.Table
+	shards      *shard.Map // Chỉ dùng ở nhóm metadata (nhóm 0)
+	lastApplied int64
+	leaderId    int32
+	waiters     map[int64]waiter // Index -> lệnh ghi đang chờ kết quả
+	notify      chan struct{}    // Đóng (rồi thay mới) mỗi khi apply hoặc xác nhận quyền Leader
+	round       int64            // Số vòng heartbeat Leader đã bắt đầu
+	ackedRound  int64            // Vòng heartbeat gần nhất được đa số chấp nhận
+
+	// Follower read: bản sao cục bộ chứa mọi entry Leader đã commit tới
+	// leaderCommit, tính tới thời điểm safeTime
+	leaderCommit int64
+	safeTime     time.Time
+
+	healthy map[int32]int // Leader: số vòng heartbeat liên tiếp mỗi peer theo kịp log
+
+	// Leader: lô lệnh chờ flush và tiến độ replicate tới từng peer
+	pending    []*proposal
+	flushing   bool // Đã hẹn flush sau batchWindow
+	nextIndex  map[int32]int64
+	matchIndex map[int32]int64
+	inflight   map[int32]int
+	quorumAt   time.Time // Lúc bắt đầu vòng heartbeat gần nhất được đa số xác nhận
+
+	saved   int  // Số entry đầu log đã có trong file
+	rewrite bool // File chứa entry đã bị cắt, lần save tới ghi lại cả file
+
+	savedTerm int64 // currentTerm/votedFor đã có trong file state
+	savedVote int32
 }
 
-func NewRaftNode(id int32, peers map[int32]string) *RaftNode {
+// persistentState là phần trạng thái phải bền vững ngoài log (Raft Figure 2).
+type persistentState struct {
+	Term     int64 `json:"term"`
+	VotedFor int32 `json:"votedFor"`
+}
+
+type waiter struct {
+	term int64
+	ch   chan interface{} // kv.Result hoặc lock.Result
+}
+
+var errNotLeader = fmt.Errorf("not leader")
+
+// heartbeat là một vòng AppendEntries không mang entry của nhóm đang làm
+// Leader, do Host gộp với các nhóm khác trước khi gửi.
+type heartbeat struct {
+	start time.Time
+	round int64
+	term  int64
+	last  int64 // Index cuối của log Leader lúc bắt đầu vòng
+	peers []int32
+	args  map[int32]*proto.AppendEntriesArgs
+}
+
+func NewRaftNode(h *Host, group int32) *RaftNode {
 	rn := &RaftNode{
-		me:        id,
-		peers:     peers,
-		state:     Follower,
-		votedFor:  -1,
-		blacklist: make(map[int32]bool),
+		me:           h.me,
+		group:        group,
+		host:         h,
+		dataDir:      h.dataDir,
+		state:        Follower,
+		votedFor:     -1,
+		savedVote:    -1,
+		commitIndex:  -1,
+		leaderCommit: -1,
+		store:        kv.NewEmptyStore(),
+		locks:        lock.NewTable(),
+		shards:       shard.NewMap(),
+		lastApplied:  -1,
+		leaderId:     -1,
+		waiters:      make(map[int64]waiter),
+		healthy:      make(map[int32]int),
+		nextIndex:    make(map[int32]int64),
+		matchIndex:   make(map[int32]int64),
+		inflight:     make(map[int32]int),
+		notify:       make(chan struct{}),
+	}
+	if group == metaGroup {
+		rn.store = kv.NewStore() // Ban đầu cả keyspace thuộc nhóm metadata
 	}
 	rn.load()
 	rn.resetElectionTimer()
 	return rn
 }
 
+// storagePath: nhóm 0 giữ tên file cũ để dữ liệu trước Multi-Raft vẫn đọc được.
+func (rn *RaftNode) storagePath() string {
+	if rn.group == 0 {
+		return filepath.Join(rn.dataDir, fmt.Sprintf("storage_%d.json", rn.me))
+	}
+	return filepath.Join(rn.dataDir, fmt.Sprintf("storage_%d_g%d.json", rn.me, rn.group))
+}
+
+func (rn *RaftNode) statePath() string {
+	return strings.TrimSuffix(rn.storagePath(), ".json") + "_state.json"
+}
+
+// persistState ghi currentTerm và votedFor nếu đã đổi. Phải gọi trước khi trả
+// lời RequestVote/AppendEntries hoặc gửi RequestVote: node khởi động lại mà
+// quên lá phiếu đã bầu có thể bầu lần thứ hai trong cùng term.
+func (rn *RaftNode) persistState() {
+	if rn.currentTerm == rn.savedTerm && rn.votedFor == rn.savedVote {
+		return
+	}
+	_ = os.MkdirAll(rn.dataDir, 0755)
+	data, _ := json.Marshal(persistentState{Term: rn.currentTerm, VotedFor: rn.votedFor})
+	// Ghi file tạm rồi rename để crash giữa chừng không để lại file hỏng
+	tmp := rn.statePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, rn.statePath()); err == nil {
+		rn.savedTerm, rn.savedVote = rn.currentTerm, rn.votedFor
+	}
+}
+
+// save ghi các entry chưa lưu vào cuối file, mỗi entry một dòng JSON; cả
+// file chỉ được ghi lại sau khi log bị cắt.
 func (rn *RaftNode) save() {
-	_ = os.MkdirAll("logs", 0755) // Lưu vào folder logs nội bộ của Raft
-	data, _ := json.Marshal(rn.logs)
-	filename := fmt.Sprintf("logs/storage_%d.json", rn.me)
-	_ = os.WriteFile(filename, data, 0644)
+	_ = os.MkdirAll(rn.dataDir, 0755) // Lưu vào folder logs nội bộ của Raft
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if rn.rewrite {
+		flags, rn.saved = os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0
+	}
+	f, err := os.OpenFile(rn.storagePath(), flags, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range rn.logs[rn.saved:] {
+		_ = enc.Encode(e)
+	}
+	if _, err := f.Write(buf.Bytes()); err == nil {
+		rn.saved, rn.rewrite = len(rn.logs), false
+	}
 }
 
+// load đọc file log; file dạng mảng JSON (trước khi ghi nối đuôi) được ghi
+// lại theo dạng mới ở lần save đầu tiên.
 func (rn *RaftNode) load() {
-	filename := fmt.Sprintf("logs/storage_%d.json", rn.me)
-	data, err := os.ReadFile(filename)
-	if err == nil {
+	if data, err := os.ReadFile(rn.statePath()); err == nil {
+		var st persistentState
+		if json.Unmarshal(data, &st) == nil {
+			rn.currentTerm, rn.votedFor = st.Term, st.VotedFor
+			rn.savedTerm, rn.savedVote = st.Term, st.VotedFor
+		}
+	}
+	data, err := os.ReadFile(rn.storagePath())
+	if err != nil {
+		return
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
 		_ = json.Unmarshal(data, &rn.logs)
+		rn.rewrite = true
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var e proto.LogEntry
+		if err := dec.Decode(&e); err != nil {
+			// Dòng cuối ghi dở khi crash: bỏ đi và ghi lại file
+			rn.rewrite = err != io.EOF
+			break
+		}
+		rn.logs = append(rn.logs, &e)
+	}
+	rn.saved = len(rn.logs)
+}
+
+// truncate bỏ các entry từ `idx` (entry lệch với Leader); log được chép sang
+// mảng mới vì request đang gửi có thể còn giữ phần đuôi cũ.
+func (rn *RaftNode) truncate(idx int64) {
+	rn.logs = rn.logs[:idx:idx]
+	rn.commitIndex = min(rn.commitIndex, idx-1)
+	if rn.saved > len(rn.logs) {
+		rn.rewrite = true
+	}
+}
+
+// Kill giả lập crash: dừng timer và vòng heartbeat, node không tham gia bầu cử nữa.
+func (rn *RaftNode) Kill() {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.dead = true
+	rn.state = Follower
+	if rn.electionTimer != nil {
+		rn.electionTimer.Stop()
+	}
+	rn.signal()
+}
+
+func (rn *RaftNode) lastLog() (int64, int64) {
+	if len(rn.logs) == 0 {
+		return -1, 0
+	}
+	last := rn.logs[len(rn.logs)-1]
+	return last.Index, last.Term
+}
+
+// signal đánh thức mọi goroutine đang chờ trong wait (gọi khi đang giữ mu).
+func (rn *RaftNode) signal() {
+	close(rn.notify)
+	rn.notify = make(chan struct{})
+}
+
+// wait nhả mu cho tới lần signal tiếp theo hoặc ctx hết hạn, rồi khoá lại.
+func (rn *RaftNode) wait(ctx context.Context) error {
+	ch := rn.notify
+	rn.mu.Unlock()
+	defer rn.mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// applyCommitted đưa các entry đã commit vào state machine và trả kết quả
+// cho lệnh ghi đang chờ. Entry đã bị ghi đè bởi term khác thì báo mất quyền Leader.
+func (rn *RaftNode) applyCommitted() {
+	if rn.lastApplied >= rn.commitIndex {
+		return
+	}
+	for rn.lastApplied < rn.commitIndex {
+		rn.lastApplied++
+		e := rn.logs[rn.lastApplied]
+		res := rn.apply(e)
+		if w, ok := rn.waiters[e.Index]; ok {
+			delete(rn.waiters, e.Index)
+			if w.term == e.Term {
+				w.ch <- res
+			}
+			close(w.ch)
+		}
+	}
+	rn.signal()
+}
+
+func (rn *RaftNode) leaderHint() int32 {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	return rn.leaderId
+}
+
+// apply chuyển entry tới state machine tương ứng theo `op` của command.
+func (rn *RaftNode) apply(e *proto.LogEntry) interface{} {
+	var c struct {
+		Op string `json:"op"`
+	}
+	if json.Unmarshal([]byte(e.Command), &c) == nil && lock.Handles(c.Op) {
+		return rn.locks.Apply(e.Index, e.Command)
+	}
+	if shard.Handles(c.Op) {
+		return rn.shards.Apply(e.Index, e.Command)
+	}
+	return rn.store.Apply(e.Index, e.Command)
+}
+
+// submit ghi lệnh vào log của Leader và chờ tới khi nó được apply,
+// trả về kết quả của state machine.
+func (rn *RaftNode) submit(ctx context.Context, command string) (interface{}, error) {
+	rn.mu.Lock()
+	if rn.state != Leader {
+		rn.mu.Unlock()
+		return nil, errNotLeader
+	}
+	p := &proposal{command: command, ch: make(chan interface{}, 1)}
+	rn.propose(p)
+	rn.mu.Unlock()
+
+	select {
+	case res, ok := <-p.ch:
+		if !ok {
+			return nil, errNotLeader
+		}
+		return res, nil
+	case <-ctx.Done():
+		rn.mu.Lock()
+		if p.index >= 0 {
+			delete(rn.waiters, p.index)
+		} else {
+			p.ch = nil // Chưa flush: lệnh vẫn vào log nhưng không ai chờ
+		}
+		rn.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// readIndex chờ tới khi đọc state machine là linearizable (Raft §8):
+// Leader đã commit entry của term mình, một vòng heartbeat bắt đầu sau
+// lúc nhận yêu cầu được đa số chấp nhận, và state machine đã apply tới
+// commitIndex tại thời điểm đó. Gọi khi đang giữ mu.
+func (rn *RaftNode) readIndex(ctx context.Context) error {
+	term := rn.currentTerm
+	for rn.commitIndex < 0 || rn.logs[rn.commitIndex].Term != term {
+		if rn.state != Leader || rn.currentTerm != term {
+			return errNotLeader
+		}
+		if err := rn.wait(ctx); err != nil {
+			return err
+		}
 	}
+	index, round := rn.commitIndex, rn.round
+	for rn.ackedRound <= round || rn.lastApplied < index {
+		if rn.state != Leader || rn.currentTerm != term {
+			return errNotLeader
+		}
+		if err := rn.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// minElectionTimeout: Follower không nghe Leader lâu hơn thế mới ứng cử, nên
+// Leader mất đa số lâu hơn thế cũng tự hạ cấp.
+const minElectionTimeout = 400 * time.Millisecond
+
 func (rn *RaftNode) resetElectionTimer() {
 	if rn.electionTimer != nil {
 		rn.electionTimer.Stop()
 	}
-	timeout := time.Duration(400+rand.Intn(400)) * time.Millisecond
+	if rn.dead {
+		return
+	}
+	// Node có độ ưu tiên thấp chờ thêm để node ưu tiên cao ứng cử trước
+	timeout := minElectionTimeout + time.Duration(rand.Intn(400))*time.Millisecond + rn.host.cfg.electionDelay(rn.me)
 	rn.electionTimer = time.AfterFunc(timeout, rn.startElection)
 }
 
 func (rn *RaftNode) RequestVote(ctx context.Context, args *proto.RequestVoteArgs) (*proto.RequestVoteReply, error) {
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
-	if rn.blacklist[args.CandidateId] {
-		return nil, fmt.Errorf("Partition")
-	}
 	if args.Term > rn.currentTerm {
-		rn.currentTerm, rn.state, rn.votedFor = args.Term, Follower, -1
+		// Leader bị hạ cấp không có timer bầu cử đang chạy -> bật lại
+		if rn.state == Leader {
+			rn.resetElectionTimer()
+			rn.signal()
+		}
+		rn.currentTerm, rn.state, rn.votedFor, rn.leaderId = args.Term, Follower, -1, -1
 	}
 	reply := &proto.RequestVoteReply{Term: rn.currentTerm, VoteGranted: false}
-	if (rn.votedFor == -1 || rn.votedFor == args.CandidateId) && args.Term >= rn.currentTerm {
+	// Chỉ bầu cho ứng viên có log ít nhất mới bằng log của mình (Raft §5.4.1)
+	lastIndex, lastTerm := rn.lastLog()
+	upToDate := args.LastLogTerm > lastTerm || (args.LastLogTerm == lastTerm && args.LastLogIndex >= lastIndex)
+	if (rn.votedFor == -1 || rn.votedFor == args.CandidateId) && args.Term >= rn.currentTerm && upToDate {
 		rn.votedFor = args.CandidateId
 		reply.VoteGranted = true
 		rn.resetElectionTimer()
 	}
+	rn.persistState()
 	return reply, nil
 }
 
 func (rn *RaftNode) AppendEntries(ctx context.Context, args *proto.AppendEntriesArgs) (*proto.AppendEntriesReply, error) {
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
-	if rn.blacklist[args.LeaderId] {
-		return nil, fmt.Errorf("Partition")
-	}
 	if args.Term >= rn.currentTerm {
-		rn.state, rn.currentTerm = Follower, args.Term
+		if args.Term > rn.currentTerm {
+			rn.votedFor = -1
+		}
+		rn.state, rn.currentTerm, rn.leaderId = Follower, args.Term, args.LeaderId
+		rn.persistState()
 		rn.resetElectionTimer()
-		if len(args.Entries) > len(rn.logs) {
-			rn.logs = args.Entries
+		// Kiểm tra log khớp tại prev (Raft §5.3); lệch thì gợi ý Leader lùi lại
+		last := int64(len(rn.logs)) - 1
+		prev := args.PrevLogIndex
+		if prev > last {
+			return &proto.AppendEntriesReply{Term: rn.currentTerm, MatchIndex: last}, nil
+		}
+		if prev >= 0 && rn.logs[prev].Term != args.PrevLogTerm {
+			// Bỏ qua cả đoạn entry của term lệch thay vì lùi từng entry
+			i := prev
+			for i > 0 && rn.logs[i-1].Term == rn.logs[prev].Term {
+				i--
+			}
+			return &proto.AppendEntriesReply{Term: rn.currentTerm, MatchIndex: i - 1}, nil
+		}
+		// Request pipeline có thể tới không theo thứ tự: chỉ cắt log khi
+		// entry khác term, entry đã khớp được giữ nguyên
+		for i, e := range args.Entries {
+			idx := prev + 1 + int64(i)
+			if idx < int64(len(rn.logs)) && rn.logs[idx].Term == e.Term {
+				continue
+			}
+			if idx < int64(len(rn.logs)) {
+				rn.truncate(idx)
+			}
+			rn.logs = append(rn.logs, args.Entries[i:]...)
 			rn.save()
+			break
 		}
-		return &proto.AppendEntriesReply{Term: rn.currentTerm, Success: true}, nil
+		match := prev + int64(len(args.Entries))
+		if c := min(args.LeaderCommit, match); c > rn.commitIndex {
+			rn.commitIndex = c
+			rn.applyCommitted()
+		}
+		if match >= args.LeaderCommit {
+			// Log ở đây đã chứa mọi entry Leader commit trước LeaderTimeMs; đổi
+			// sang đồng hồ cục bộ bằng cách trừ độ lệch đồng hồ tối đa
+			rn.leaderCommit = max(rn.leaderCommit, args.LeaderCommit)
+			if args.LeaderTimeMs > 0 {
+				t := time.UnixMilli(args.LeaderTimeMs).Add(-rn.host.cfg.maxClockOffset())
+				if now := time.Now(); t.After(now) {
+					t = now
+				}
+				if t.After(rn.safeTime) {
+					rn.safeTime = t
+				}
+			}
+		}
+		return &proto.AppendEntriesReply{Term: rn.currentTerm, Success: true, MatchIndex: match}, nil
 	}
 	return &proto.AppendEntriesReply{Term: rn.currentTerm, Success: false}, nil
 }
@@ -116,8 +476,7 @@ func (rn *RaftNode) Propose(ctx context.Context, args *proto.ProposeArgs) (*prot
 	if rn.state != Leader {
 		return &proto.ProposeReply{Success: false}, nil
 	}
-	rn.logs = append(rn.logs, &proto.LogEntry{Term: rn.currentTerm, Index: int64(len(rn.logs)), Command: args.Command})
-	rn.save()
+	rn.propose(&proposal{command: args.Command})
 	return &proto.ProposeReply{Success: true, LeaderId: rn.me}, nil
 }
 
@@ -125,127 +484,232 @@ func (rn *RaftNode) GetStatus(ctx context.Context, _ *proto.Empty) (*proto.Statu
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
 	states := []string{"Follower", "Candidate", "Leader"}
-	return &proto.StatusReply{Id: rn.me, State: states[rn.state], Term: rn.currentTerm}, nil
+	return &proto.StatusReply{Id: rn.me, State: states[rn.state], Term: rn.currentTerm, Committed: rn.commitIndex + 1}, nil
 }
 
-func (rn *RaftNode) ForceLeader(ctx context.Context, _ *proto.Empty) (*proto.Empty, error) {
+func (rn *RaftNode) GetLedger(ctx context.Context, args *proto.LedgerArgs) (*proto.LedgerReply, error) {
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
-	rn.currentTerm += 100
-	rn.state = Leader
-	go rn.becomeLeader()
-	return &proto.Empty{}, nil
+	reply := &proto.LedgerReply{}
+	for i := max(args.FromIndex, 0); i <= rn.commitIndex; i++ {
+		e := rn.logs[i]
+		reply.Entries = append(reply.Entries, &proto.LedgerEntry{Index: e.Index, Term: e.Term, Data: e.Command})
+	}
+	return reply, nil
 }
 
-func (rn *RaftNode) SetNetworkPartition(ctx context.Context, args *proto.PartitionArgs) (*proto.PartitionReply, error) {
+func (rn *RaftNode) ForceLeader(ctx context.Context, _ *proto.Empty) (*proto.Empty, error) {
 	rn.mu.Lock()
 	defer rn.mu.Unlock()
-	rn.blacklist = make(map[int32]bool)
-	for _, id := range args.IsolatedNodeIds {
-		rn.blacklist[id] = true
-	}
-	return &proto.PartitionReply{Success: true}, nil
+	rn.currentTerm, rn.votedFor = rn.currentTerm+100, rn.me
+	rn.persistState()
+	rn.becomeLeader()
+	return &proto.Empty{}, nil
 }
 
 func (rn *RaftNode) startElection() {
 	rn.mu.Lock()
-	if rn.state == Leader {
+	if rn.state == Leader || rn.dead {
 		rn.mu.Unlock()
 		return
 	}
 	rn.state, rn.currentTerm, rn.votedFor = Candidate, rn.currentTerm+1, rn.me
+	rn.persistState()
 	term := rn.currentTerm
+	lastIndex, lastTerm := rn.lastLog()
+	peers := rn.host.reachable()
+	rn.resetElectionTimer()
 	rn.mu.Unlock()
-	votes := 1
+	votes, quorum := 1, rn.host.quorum()
 	var once sync.Once
-	for id, addr := range rn.peers {
-		if id == rn.me || rn.blacklist[id] {
-			continue
-		}
-		go func(address string) {
+	for _, id := range peers {
+		go func(peer int32) {
 			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 			defer cancel()
-			conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+			cl, err := rn.host.client(peer)
+			if err != nil {
+				return
+			}
+			resp, err := cl.RequestVote(ctx, &proto.RequestVoteArgs{Term: term, CandidateId: rn.me, LastLogIndex: lastIndex, LastLogTerm: lastTerm, GroupId: rn.group})
 			if err != nil {
 				return
 			}
-			defer conn.Close()
-			resp, err := proto.NewConsensusServiceClient(conn).RequestVote(ctx, &proto.RequestVoteArgs{Term: term, CandidateId: rn.me})
-			if err == nil && resp.VoteGranted {
-				rn.mu.Lock()
+			rn.mu.Lock()
+			defer rn.mu.Unlock()
+			if resp.Term > rn.currentTerm {
+				rn.currentTerm, rn.state, rn.votedFor, rn.leaderId = resp.Term, Follower, -1, -1
+				rn.persistState()
+				return
+			}
+			if resp.VoteGranted {
 				votes++
-				if votes >= 3 && rn.state == Candidate {
+				if votes >= quorum && rn.state == Candidate && rn.currentTerm == term {
 					once.Do(func() { rn.becomeLeader() })
 				}
-				rn.mu.Unlock()
 			}
-		}(addr)
+		}(id)
 	}
-	rn.resetElectionTimer()
 }
 
 func (rn *RaftNode) becomeLeader() {
-	rn.state = Leader
-	go func() {
-		for {
-			rn.mu.Lock()
-			if rn.state != Leader {
-				rn.mu.Unlock()
-				return
-			}
-			term, logs := rn.currentTerm, rn.logs
-			rn.mu.Unlock()
-			successCount := 1
-			var wg sync.WaitGroup
-			var mu sync.Mutex
-			for id, addr := range rn.peers {
-				if id == rn.me || rn.blacklist[id] {
-					continue
-				}
-				wg.Add(1)
-				go func(ad string) {
-					defer wg.Done()
-					ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
-					defer cancel()
-					conn, err := grpc.DialContext(ctx, ad, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-					if err != nil {
-						return
-					}
-					defer conn.Close()
-					resp, err := proto.NewConsensusServiceClient(conn).AppendEntries(ctx, &proto.AppendEntriesArgs{Term: term, LeaderId: rn.me, Entries: logs})
-					if err == nil && resp.Success {
-						mu.Lock()
-						successCount++
-						mu.Unlock()
-					}
-				}(addr)
-			}
-			wg.Wait()
-			rn.mu.Lock()
-			if successCount < 3 {
-				rn.state, rn.votedFor = Follower, -1
-				rn.resetElectionTimer()
-				rn.mu.Unlock()
-				return
-			}
-			rn.mu.Unlock()
-			time.Sleep(150 * time.Millisecond)
+	rn.state, rn.leaderId = Leader, rn.me
+	// Entry rỗng (no-op) của term mới: entry của term cũ chỉ được commit
+	// gián tiếp khi có entry của term hiện tại được đa số nhận (Raft §8)
+	next := int64(len(rn.logs))
+	rn.logs = append(rn.logs, &proto.LogEntry{Term: rn.currentTerm, Index: next})
+	rn.save()
+	rn.resetProgress(next)
+	rn.quorumAt = time.Now()
+	rn.replicateAll()
+}
+
+// startRound bắt đầu một vòng heartbeat nếu nhóm đang làm Leader; Host gửi
+// args tới các peer rồi gọi finishRound với các reply nhận được.
+func (rn *RaftNode) startRound() *heartbeat {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if rn.state != Leader || rn.dead {
+		return nil
+	}
+	rn.round++
+	hb := &heartbeat{
+		start: time.Now(),
+		round: rn.round,
+		term:  rn.currentTerm,
+		last:  int64(len(rn.logs)) - 1,
+		peers: rn.host.reachable(),
+		args:  make(map[int32]*proto.AppendEntriesArgs),
+	}
+	// Nối sau matchIndex nên luôn khớp; phần log còn thiếu đi theo pipeline
+	for _, peer := range hb.peers {
+		hb.args[peer] = rn.appendArgs(rn.matchIndex[peer], nil)
+	}
+	return hb
+}
+
+// transferAfter là số vòng heartbeat liên tiếp node ưu tiên cao phải theo kịp
+// log trước khi Leader chuyển quyền cho nó (tránh chuyển qua lại khi node chập chờn).
+const transferAfter = 10
+
+func (rn *RaftNode) finishRound(hb *heartbeat, replies map[int32]*proto.AppendEntriesReply) {
+	acks, quorum := 1, rn.host.quorum() // Peer trả lời cùng term là đã công nhận Leader
+	var higherTerm int64
+	for _, r := range replies {
+		if r.Term == hb.term {
+			acks++
 		}
-	}()
+		higherTerm = max(higherTerm, r.Term)
+	}
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if higherTerm > rn.currentTerm {
+		rn.currentTerm, rn.votedFor = higherTerm, -1
+		rn.persistState()
+	}
+	if rn.state != Leader {
+		return // Đã bị hạ cấp trong lúc chờ reply
+	}
+	// Một vòng chậm (peer đang bận ghi log) chưa đủ để hạ cấp; không được đa
+	// số xác nhận trong cả minElectionTimeout thì Leader đã có thể bị thay
+	if rn.currentTerm != hb.term || (acks < quorum && time.Since(rn.quorumAt) > minElectionTimeout) {
+		// Cùng term thì giữ lá phiếu đã bầu cho chính mình
+		rn.state = Follower
+		rn.resetElectionTimer()
+		rn.signal()
+		return
+	}
+	for peer, r := range replies {
+		rn.handleAppendReply(peer, r)
+	}
+	for _, peer := range hb.peers {
+		rn.replicate(peer) // Gửi lại phần log bị mất cùng request lỗi
+	}
+	if acks < quorum {
+		return
+	}
+	rn.ackedRound, rn.quorumAt = hb.round, hb.start
+	// Tới lúc bắt đầu vòng, node vẫn là Leader duy nhất của term
+	rn.leaderCommit, rn.safeTime = rn.commitIndex, hb.start
+	rn.signal()
+
+	for _, peer := range hb.peers {
+		if r, ok := replies[peer]; ok && r.Success && rn.matchIndex[peer] >= hb.last {
+			rn.healthy[peer]++
+		} else {
+			rn.healthy[peer] = 0
+		}
+	}
+	// Peer đã có toàn bộ log hiện tại nên thắng bầu cử mà không mất entry nào
+	if target := rn.transferTarget(); target >= 0 && rn.matchIndex[target] == int64(len(rn.logs))-1 {
+		rn.healthy[target] = 0
+		go rn.host.sendTimeoutNow(target, &proto.TimeoutNowArgs{Term: rn.currentTerm, LeaderId: rn.me, GroupId: rn.group})
+	}
+}
+
+// transferTarget trả về peer có điểm ưu tiên cao nhất, cao hơn Leader hiện
+// tại và đã khoẻ đủ lâu; -1 nếu Leader đã là node được ưu tiên.
+func (rn *RaftNode) transferTarget() int32 {
+	cfg, best := rn.host.cfg, int32(-1)
+	for peer, n := range rn.healthy {
+		if n < transferAfter || cfg.score(peer) <= cfg.score(rn.me) {
+			continue
+		}
+		if best < 0 || cfg.score(peer) > cfg.score(best) || (cfg.score(peer) == cfg.score(best) && peer < best) {
+			best = peer
+		}
+	}
+	return best
+}
+
+// TimeoutNow: Leader chuyển quyền cho node này, bầu cử ngay không chờ timer.
+func (rn *RaftNode) TimeoutNow(ctx context.Context, args *proto.TimeoutNowArgs) (*proto.Empty, error) {
+	rn.mu.Lock()
+	ok := !rn.dead && rn.state == Follower && args.Term == rn.currentTerm
+	rn.mu.Unlock()
+	if ok {
+		go rn.startElection()
+	}
+	return &proto.Empty{}, nil
 }
 
 func main() {
 	id := flag.Int("id", 0, "node id")
+	topology := flag.String("netem", "", "WAN topology JSON (latency/jitter/bandwidth matrix)")
+	clusterFile := flag.String("cluster", "", "cluster config JSON (addresses, election priority, zones)")
+	groups := flag.Int("groups", 1, "number of Raft groups hosted by this process (Multi-Raft)")
+	splitKeys := flag.Int("split-keys", 0, "auto-split ranges holding more keys than this (0 = off)")
 	flag.Parse()
 	rand.Seed(time.Now().UnixNano() + int64(*id))
-	ports := []string{"50050", "50051", "50052", "50053", "50054"}
-	peers := make(map[int32]string)
-	for i, p := range ports {
-		peers[int32(i)] = "localhost:" + p
+	cfg := defaultCluster()
+	if *clusterFile != "" {
+		var err error
+		if cfg, err = LoadClusterConfig(*clusterFile, int32(*id)); err != nil {
+			log.Fatalf("%v", err)
+		}
+	} else if err := cfg.validate(int32(*id)); err != nil {
+		log.Fatalf("cluster: %v", err)
+	}
+	h := NewHost(int32(*id), cfg, "logs", *groups)
+	lis, err := net.Listen("tcp", h.peers[h.me])
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+	if *topology != "" {
+		t, err := netem.LoadTopology(*topology)
+		if err != nil {
+			log.Fatalf("netem: %v", err)
+		}
+		h.faults.SetProfiles(t.Profiles(h.me))
 	}
-	lis, _ := net.Listen("tcp", "localhost:"+ports[*id])
 	s := grpc.NewServer()
-	proto.RegisterConsensusServiceServer(s, NewRaftNode(int32(*id), peers))
+	proto.RegisterConsensusServiceServer(s, h)
+	// KV route theo shard map; Lock chạy trên nhóm 0
+	proto.RegisterKVServiceServer(s, &kvServer{h: h})
+	proto.RegisterShardServiceServer(s, &shardServer{h: h})
+	proto.RegisterLockServiceServer(s, newLockServer(h.Group(0)))
+	if *splitKeys > 0 {
+		h.autoSplit(*splitKeys)
+	}
 	log.Printf("Node %d starting...", *id)
 	s.Serve(lis)
 }
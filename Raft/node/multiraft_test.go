@@ -0,0 +1,124 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// groupLeader chờ nhóm `g` có Leader khác `old` được đa số Host đang chạy công nhận.
+func (c *testCluster) groupLeader(g, old int32, d time.Duration) int32 {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		terms := make(map[int64]int)
+		leaders := make(map[int64][]int32)
+		for id := range c.srvs {
+			st, _ := c.hosts[id].Group(g).GetStatus(context.Background(), &proto.Empty{})
+			terms[st.Term]++
+			if st.State == "Leader" {
+				leaders[st.Term] = append(leaders[st.Term], id)
+			}
+		}
+		for term, ids := range leaders {
+			if len(ids) == 1 && terms[term] >= 3 && ids[0] != old {
+				return ids[0]
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.t.Fatalf("group %d: no leader within %v", g, d)
+	return -1
+}
+
+// groupCommands trả về các lệnh đã commit của nhóm `g` trên Host `id`.
+func (c *testCluster) groupCommands(id, g int32) []string {
+	reply, _ := c.hosts[id].GetLedger(context.Background(), &proto.LedgerArgs{GroupId: g})
+	return commands(reply.Entries)
+}
+
+func (c *testCluster) waitGroupLedger(g int32, want []string, d time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for {
+		var diff string
+		for id := range c.srvs {
+			if got := c.groupCommands(id, g); fmt.Sprint(got) != fmt.Sprint(want) {
+				diff = fmt.Sprintf("host %d group %d committed %v, want %v", id, g, got, want)
+				break
+			}
+		}
+		if diff == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			c.t.Fatal(diff)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestMultiRaftGroupsAreIndependent(t *testing.T) {
+	c := newMultiCluster(t, 3)
+	ctx := context.Background()
+	for g := int32(0); g < 3; g++ {
+		leader := c.groupLeader(g, -1, 3*time.Second)
+		cmd := fmt.Sprintf("g%d-cmd", g)
+		if r, _ := c.hosts[leader].Propose(ctx, &proto.ProposeArgs{Command: cmd, GroupId: g}); !r.Success {
+			t.Fatalf("group %d: propose to leader %d rejected", g, leader)
+		}
+	}
+	for g := int32(0); g < 3; g++ {
+		c.waitGroupLedger(g, []string{fmt.Sprintf("g%d-cmd", g)}, 3*time.Second)
+	}
+	// Mỗi nhóm có file storage riêng; nhóm 0 giữ tên file cũ
+	for _, name := range []string{"storage_0.json", "storage_0_g1.json", "storage_0_g2.json"} {
+		if _, err := os.Stat(filepath.Join(c.dir, name)); err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+	}
+	if r, _ := c.hosts[0].Propose(ctx, &proto.ProposeArgs{Command: "x", GroupId: 7}); r.Success {
+		t.Fatal("propose to unknown group succeeded")
+	}
+}
+
+func TestMultiRaftBatchedHeartbeatsAndFailover(t *testing.T) {
+	c := newMultiCluster(t, 3)
+	for g := int32(0); g < 3; g++ {
+		c.groupLeader(g, -1, 3*time.Second)
+	}
+	// Host 0 làm Leader mọi nhóm: heartbeat của cả 3 nhóm đi chung một batch mỗi peer
+	for g := int32(0); g < 3; g++ {
+		c.hosts[0].Group(g).ForceLeader(context.Background(), &proto.Empty{})
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for g := int32(0); g < 3; g++ {
+		// Leader cũ vẫn được đa số công nhận cho tới khi nhận heartbeat term mới
+		for c.groupLeader(g, -1, 3*time.Second) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("group %d not led by host 0 after ForceLeader", g)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	time.Sleep(time.Second)
+	for g := int32(0); g < 3; g++ {
+		if id := c.groupLeader(g, -1, time.Second); id != 0 {
+			t.Fatalf("group %d lost leadership to %d without failures", g, id)
+		}
+	}
+
+	c.kill(0)
+	for g := int32(0); g < 3; g++ {
+		leader := c.groupLeader(g, 0, 3*time.Second)
+		cmd := fmt.Sprintf("after-%d", g)
+		if r, _ := c.hosts[leader].Propose(context.Background(), &proto.ProposeArgs{Command: cmd, GroupId: g}); !r.Success {
+			t.Fatalf("group %d: propose rejected", g)
+		}
+		c.waitGroupLedger(g, []string{cmd}, 3*time.Second)
+	}
+}
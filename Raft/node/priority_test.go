@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// waitLeaderIs chờ `want` được đa số công nhận là Leader.
+func (c *testCluster) waitLeaderIs(want int32, d time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		if id, ok := c.leader(); ok && id == want {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	id, _ := c.leader()
+	c.t.Fatalf("leader is %d, want %d", id, want)
+}
+
+func TestPriorityNodeWinsAndTakesLeadershipBack(t *testing.T) {
+	c := newConfiguredCluster(t, 1, &ClusterConfig{Nodes: []NodeConfig{
+		{ID: 0, Priority: 1}, {ID: 1, Priority: 1}, {ID: 2, Priority: 3}, {ID: 3, Priority: 1}, {ID: 4, Priority: 1},
+	}})
+	c.waitLeaderIs(2, 3*time.Second)
+
+	c.kill(2)
+	c.waitLeaderExcept(2, 3*time.Second)
+	c.propose("while-2-down")
+
+	// Node ưu tiên hồi phục, theo kịp log rồi được Leader chuyển quyền lại
+	c.restart(2)
+	c.waitLeaderIs(2, 6*time.Second)
+	c.waitLedger([]string{"while-2-down"}, 3*time.Second)
+}
+
+func TestPreferredZoneBreaksPriorityTie(t *testing.T) {
+	c := newConfiguredCluster(t, 1, &ClusterConfig{PreferredZone: "eu", Nodes: []NodeConfig{
+		{ID: 0, Zone: "us"}, {ID: 1, Zone: "us"}, {ID: 2, Zone: "us"}, {ID: 3, Zone: "ap"}, {ID: 4, Zone: "eu"},
+	}})
+	c.waitLeaderIs(4, 3*time.Second)
+
+	// Leader ngoài zone ưu tiên (do ép) trả quyền lại cho node trong zone
+	c.hosts[1].Group(0).ForceLeader(nil, nil)
+	c.waitLeaderIs(1, 3*time.Second)
+	c.waitLeaderIs(4, 5*time.Second)
+}
+
+func TestElectionDelayByRank(t *testing.T) {
+	cfg := &ClusterConfig{PreferredZone: "eu", Nodes: []NodeConfig{
+		{ID: 0, Priority: 100}, {ID: 1, Priority: 100}, {ID: 2, Priority: 1, Zone: "eu"}, {ID: 3, Priority: 1}, {ID: 4, Priority: 50},
+	}}
+	for id, want := range map[int32]time.Duration{
+		0: 0,
+		1: 0,                // Cùng điểm cao nhất
+		4: electionStep,     // Khoảng cách 50 priority vẫn chỉ là một bậc
+		2: maxElectionDelay, // Bậc thứ hai (400ms) bị chặn trần
+		3: maxElectionDelay, // Zone ưu tiên đặt node 2 trên node 3 cùng priority
+	} {
+		if got := cfg.electionDelay(id); got != want {
+			t.Errorf("node %d delay = %v, want %v", id, got, want)
+		}
+	}
+	if maxElectionDelay >= minElectionTimeout {
+		t.Fatalf("maxElectionDelay %v must stay below the election timeout %v", maxElectionDelay, minElectionTimeout)
+	}
+}
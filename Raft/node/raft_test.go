@@ -0,0 +1,447 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// testCluster chạy các Host (mặc định 5) trong cùng process, mỗi Host một gRPC server
+// thật trên 127.0.0.1 để đi qua đúng đường RPC như khi chạy thật.
+// nodes là nhóm 0 của mỗi Host.
+type testCluster struct {
+	t      *testing.T
+	dir    string
+	groups int
+	cfg    *ClusterConfig
+	peers  map[int32]string
+	hosts  map[int32]*Host
+	nodes  map[int32]*RaftNode
+	srvs   map[int32]*grpc.Server
+}
+
+func newTestCluster(t *testing.T) *testCluster {
+	return newMultiCluster(t, 1)
+}
+
+// newMultiCluster tạo cluster mà mỗi Host chứa `groups` nhóm Raft.
+func newMultiCluster(t *testing.T, groups int) *testCluster {
+	return newConfiguredCluster(t, groups, &ClusterConfig{})
+}
+
+// newSizedCluster tạo cluster n node, mỗi Host một nhóm Raft.
+func newSizedCluster(t *testing.T, n int32) *testCluster {
+	cfg := &ClusterConfig{}
+	for id := int32(0); id < n; id++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: id})
+	}
+	return newConfiguredCluster(t, 1, cfg)
+}
+
+// newConfiguredCluster dùng priority/zone trong cfg.Nodes (nếu có, quyết
+// định luôn số node); địa chỉ được điền theo listener thật.
+func newConfiguredCluster(t *testing.T, groups int, cfg *ClusterConfig) *testCluster {
+	t.Helper()
+	c := &testCluster{
+		t:      t,
+		dir:    t.TempDir(),
+		groups: groups,
+		cfg:    cfg,
+		peers:  make(map[int32]string),
+		hosts:  make(map[int32]*Host),
+		nodes:  make(map[int32]*RaftNode),
+		srvs:   make(map[int32]*grpc.Server),
+	}
+	listeners := make(map[int32]net.Listener)
+	n := int32(len(cfg.Nodes))
+	if n == 0 {
+		n = 5
+	}
+	for id := int32(0); id < n; id++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners[id] = lis
+		c.peers[id] = lis.Addr().String()
+		if int(id) == len(cfg.Nodes) {
+			cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: id})
+		}
+		cfg.Nodes[id].Addr = c.peers[id]
+	}
+	for id, lis := range listeners {
+		c.serve(id, lis)
+	}
+	t.Cleanup(func() {
+		for id := range c.srvs {
+			c.kill(id)
+		}
+	})
+	return c
+}
+
+func (c *testCluster) serve(id int32, lis net.Listener) {
+	h := NewHost(id, c.cfg, c.dir, c.groups)
+	s := grpc.NewServer()
+	proto.RegisterConsensusServiceServer(s, h)
+	proto.RegisterKVServiceServer(s, &kvServer{h: h})
+	proto.RegisterShardServiceServer(s, &shardServer{h: h})
+	proto.RegisterLockServiceServer(s, newLockServer(h.Group(0)))
+	go s.Serve(lis)
+	c.hosts[id], c.nodes[id], c.srvs[id] = h, h.Group(0), s
+}
+
+// restart tạo lại node từ cùng dataDir trên cùng địa chỉ (giả lập khởi động lại process).
+func (c *testCluster) restart(id int32) {
+	c.t.Helper()
+	var lis net.Listener
+	var err error
+	for i := 0; i < 50; i++ {
+		if lis, err = net.Listen("tcp", c.peers[id]); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		c.t.Fatalf("relisten node %d: %v", id, err)
+	}
+	c.serve(id, lis)
+}
+
+func (c *testCluster) kill(id int32) {
+	if s, ok := c.srvs[id]; ok {
+		c.hosts[id].Kill()
+		s.Stop()
+		delete(c.srvs, id)
+	}
+}
+
+// leader trả về Leader được đa số node đang chạy công nhận (cùng term).
+func (c *testCluster) leader() (int32, bool) {
+	terms := make(map[int64]int)
+	leaders := make(map[int64][]int32)
+	for id := range c.srvs {
+		st, _ := c.nodes[id].GetStatus(context.Background(), &proto.Empty{})
+		terms[st.Term]++
+		if st.State == "Leader" {
+			leaders[st.Term] = append(leaders[st.Term], id)
+		}
+	}
+	for term, ids := range leaders {
+		if len(ids) == 1 && terms[term] >= len(c.peers)/2+1 {
+			return ids[0], true
+		}
+	}
+	return -1, false
+}
+
+func (c *testCluster) waitLeader(d time.Duration) int32 {
+	c.t.Helper()
+	return c.waitLeaderExcept(-1, d)
+}
+
+// waitLeaderExcept chờ một Leader khác `old` (-1 = bất kỳ).
+func (c *testCluster) waitLeaderExcept(old int32, d time.Duration) int32 {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		if id, ok := c.leader(); ok && id != old {
+			return id
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.t.Fatalf("no leader elected within %v", d)
+	return -1
+}
+
+// propose gửi lệnh tới Leader hiện hành và chờ Leader commit.
+func (c *testCluster) propose(cmd string) {
+	c.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if id, ok := c.leader(); ok {
+			// Leader mới có thể đã nhận lệnh từ Leader cũ: chỉ chờ commit, không gửi lại
+			if !c.inLog(id, cmd) {
+				if reply, _ := c.nodes[id].Propose(context.Background(), &proto.ProposeArgs{Command: cmd}); !reply.Success {
+					continue
+				}
+			}
+			if c.waitCommitted(id, cmd, 2*time.Second) {
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.t.Fatalf("command %q not committed", cmd)
+}
+
+func (c *testCluster) inLog(id int32, cmd string) bool {
+	rn := c.nodes[id]
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	for _, e := range rn.logs {
+		if e.Command == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *testCluster) waitCommitted(id int32, cmd string, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		for _, e := range c.ledger(id) {
+			if e.Data == cmd {
+				return true
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func (c *testCluster) ledger(id int32) []*proto.LedgerEntry {
+	reply, _ := c.nodes[id].GetLedger(context.Background(), &proto.LedgerArgs{})
+	return reply.Entries
+}
+
+// waitLedger chờ mọi node đang chạy có đúng danh sách lệnh đã commit.
+func (c *testCluster) waitLedger(want []string, d time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for {
+		var diff string
+		for id := range c.srvs {
+			if got := commands(c.ledger(id)); fmt.Sprint(got) != fmt.Sprint(want) {
+				diff = fmt.Sprintf("node %d committed %v, want %v", id, got, want)
+				break
+			}
+		}
+		if diff == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			c.t.Fatal(diff)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (c *testCluster) partition(groups ...[]int32) {
+	group := make(map[int32]int)
+	for g, ids := range groups {
+		for _, id := range ids {
+			group[id] = g
+		}
+	}
+	for id := range c.nodes {
+		var isolated []int32
+		for other := range c.nodes {
+			if other != id && group[other] != group[id] {
+				isolated = append(isolated, other)
+			}
+		}
+		c.hosts[id].SetNetworkPartition(context.Background(), &proto.PartitionArgs{IsolatedNodeIds: isolated})
+	}
+}
+
+func commands(entries []*proto.LedgerEntry) []string {
+	var cmds []string
+	for _, e := range entries {
+		if e.Data != "" { // bỏ qua no-op của Leader mới
+			cmds = append(cmds, e.Data)
+		}
+	}
+	return cmds
+}
+
+func TestInitialElection(t *testing.T) {
+	c := newTestCluster(t)
+	first := c.waitLeader(3 * time.Second)
+
+	// Không có lỗi thì Leader phải giữ nguyên qua nhiều chu kỳ heartbeat
+	time.Sleep(time.Second)
+	if id := c.waitLeader(time.Second); id != first {
+		t.Fatalf("leader changed from %d to %d without failures", first, id)
+	}
+}
+
+func TestReElectionAfterLeaderCrash(t *testing.T) {
+	c := newTestCluster(t)
+	old := c.waitLeader(3 * time.Second)
+	c.propose("before-crash")
+
+	c.kill(old)
+	c.waitLeaderExcept(old, 3*time.Second)
+	c.propose("after-crash")
+
+	// Node cũ quay lại phải trở thành Follower và bắt kịp log
+	c.restart(old)
+	c.waitLedger([]string{"before-crash", "after-crash"}, 3*time.Second)
+	if st, _ := c.nodes[old].GetStatus(context.Background(), &proto.Empty{}); st.State == "Leader" {
+		t.Fatalf("restarted node %d took over leadership", old)
+	}
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	c := newTestCluster(t)
+	old := c.waitLeader(3 * time.Second)
+	c.propose("x1")
+
+	// Leader cũ rơi vào nhóm thiểu số
+	minority := []int32{old}
+	var majority []int32
+	for id := int32(0); id < 5; id++ {
+		if id == old {
+			continue
+		}
+		if len(minority) < 2 {
+			minority = append(minority, id)
+		} else {
+			majority = append(majority, id)
+		}
+	}
+	c.partition(minority, majority)
+
+	// Lệnh gửi tới Leader thiểu số không được commit
+	c.nodes[old].Propose(context.Background(), &proto.ProposeArgs{Command: "lost"})
+
+	c.waitLeaderExcept(old, 3*time.Second)
+	c.propose("x2")
+	if c.waitCommitted(old, "lost", 500*time.Millisecond) {
+		t.Fatal("minority leader committed a command")
+	}
+
+	c.partition(append(minority, majority...))
+	c.waitLeader(3 * time.Second)
+	c.propose("x3")
+	c.waitLedger([]string{"x1", "x2", "x3"}, 5*time.Second)
+}
+
+func TestLogPersistenceAcrossRestart(t *testing.T) {
+	c := newTestCluster(t)
+	c.waitLeader(3 * time.Second)
+	want := []string{"a", "b", "c"}
+	for _, cmd := range want {
+		c.propose(cmd)
+	}
+	c.waitLedger(want, 3*time.Second)
+
+	for id := int32(0); id < 5; id++ {
+		c.kill(id)
+	}
+	for id := int32(0); id < 5; id++ {
+		c.restart(id)
+		for _, cmd := range want {
+			if !c.inLog(id, cmd) {
+				t.Fatalf("node %d lost %q after restart", id, cmd)
+			}
+		}
+	}
+
+	// commitIndex không được lưu: entry cũ được commit lại khi Leader mới commit entry của term mình
+	c.waitLeader(3 * time.Second)
+	c.propose("d")
+	c.waitLedger(append(want, "d"), 3*time.Second)
+}
+
+// Lá phiếu đã bầu phải còn sau khi khởi động lại: node không được bầu cho
+// ứng viên thứ hai trong cùng term.
+func TestVotePersistsAcrossRestart(t *testing.T) {
+	cfg := &ClusterConfig{}
+	for i := int32(0); i < 5; i++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: i, Addr: "127.0.0.1:1"})
+	}
+	dir := t.TempDir()
+	h := NewHost(0, cfg, dir, 1)
+	vote := func(rn *RaftNode, candidate int32) *proto.RequestVoteReply {
+		r, _ := rn.RequestVote(context.Background(), &proto.RequestVoteArgs{Term: 7, CandidateId: candidate, LastLogIndex: -1})
+		return r
+	}
+	if r := vote(h.Group(0), 1); !r.VoteGranted {
+		t.Fatalf("first vote = %v", r)
+	}
+	h.Kill()
+
+	h = NewHost(0, cfg, dir, 1)
+	defer h.Kill()
+	rn := h.Group(0)
+	rn.mu.Lock()
+	term, votedFor := rn.currentTerm, rn.votedFor
+	rn.mu.Unlock()
+	if term != 7 || votedFor != 1 {
+		t.Fatalf("after restart term=%d votedFor=%d, want 7 and 1", term, votedFor)
+	}
+	if r := vote(rn, 2); r.VoteGranted {
+		t.Fatal("granted a second vote in term 7 after restart")
+	}
+	if r := vote(rn, 1); !r.VoteGranted {
+		t.Fatal("repeated request from the same candidate must still be granted")
+	}
+
+	// Term mới từ AppendEntries xoá lá phiếu của term cũ và cũng được lưu
+	rn.AppendEntries(context.Background(), &proto.AppendEntriesArgs{Term: 9, LeaderId: 3, PrevLogIndex: -1, LeaderCommit: -1})
+	h.Kill()
+	h = NewHost(0, cfg, dir, 1)
+	defer h.Kill()
+	rn = h.Group(0)
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if rn.currentTerm != 9 || rn.votedFor != -1 {
+		t.Fatalf("after second restart term=%d votedFor=%d, want 9 and -1", rn.currentTerm, rn.votedFor)
+	}
+}
+
+// Đa số tính theo số node của cluster: 3 node còn 2, 5 node còn 3 vẫn bầu
+// được Leader và commit được lệnh.
+func TestElectionQuorumByClusterSize(t *testing.T) {
+	for _, n := range []int32{3, 5} {
+		t.Run(fmt.Sprint(n, "-nodes"), func(t *testing.T) {
+			c := newSizedCluster(t, n)
+			old := c.waitLeader(3 * time.Second)
+			c.propose("a")
+
+			// Chỉ còn đúng đa số chạy, trong đó không có Leader cũ
+			c.kill(old)
+			for id := int32(0); len(c.srvs) > int(n/2+1); id++ {
+				c.kill(id)
+			}
+			c.waitLeaderExcept(old, 3*time.Second)
+			c.propose("b")
+			c.waitLedger([]string{"a", "b"}, 3*time.Second)
+		})
+	}
+}
+
+// Leader 3 node giữ quyền khi còn một Follower xác nhận, và tự hạ cấp khi
+// mất lease (không còn đa số nào trả lời).
+func TestLeaseLossStepsDown(t *testing.T) {
+	c := newSizedCluster(t, 3)
+	leader := c.waitLeader(3 * time.Second)
+	follower, isolated := (leader+1)%3, (leader+2)%3
+	state := func() *proto.StatusReply {
+		st, _ := c.nodes[leader].GetStatus(context.Background(), &proto.Empty{})
+		return st
+	}
+	term := state().Term
+
+	c.partition([]int32{leader, follower}, []int32{isolated})
+	time.Sleep(3 * minElectionTimeout)
+	if st := state(); st.State != "Leader" || st.Term != term {
+		t.Fatalf("leader with a 2-of-3 quorum lost leadership: %v", st)
+	}
+
+	c.partition([]int32{leader}, []int32{follower, isolated})
+	deadline := time.Now().Add(3 * minElectionTimeout)
+	for state().State == "Leader" {
+		if time.Now().After(deadline) {
+			t.Fatal("isolated leader kept leadership after its lease expired")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"slices"
+	"time"
+)
+
+// Leader ghi log theo lô và replicate ngay, không chờ vòng heartbeat:
+//   - Lệnh đề xuất trong cùng cửa sổ batchWindow được nối vào log và lưu
+//     xuống đĩa một lần (group commit).
+//   - Mỗi peer có nextIndex/matchIndex riêng; AppendEntries chỉ mang phần log
+//     peer còn thiếu, tối đa maxInflight request đang bay tới mỗi peer
+//     (pipelining), nextIndex tiến ngay khi gửi.
+//   - Commit khi đa số matchIndex đạt tới entry của term hiện tại.
+//
+// Vòng heartbeat vẫn xác nhận quyền Leader, lan truyền commit và gửi lại
+// phần log bị mất khi request lỗi.
+const (
+	batchWindow = 2 * time.Millisecond
+	maxBatch    = 512 // Flush ngay khi đủ số lệnh này
+	maxInflight = 4   // AppendEntries đang chờ reply tới mỗi peer
+	maxEntries  = 512 // Số entry tối đa trong một AppendEntries
+)
+
+// proposal là lệnh chờ flush vào log; ch nil nếu không ai chờ kết quả.
+type proposal struct {
+	command string
+	term    int64
+	ch      chan interface{}
+	index   int64 // -1 khi chưa flush
+}
+
+// propose xếp lệnh vào lô hiện tại (gọi khi đang giữ mu và là Leader).
+func (rn *RaftNode) propose(p *proposal) {
+	p.term, p.index = rn.currentTerm, -1
+	rn.pending = append(rn.pending, p)
+	if len(rn.pending) >= maxBatch {
+		rn.flush()
+		return
+	}
+	if !rn.flushing {
+		rn.flushing = true
+		time.AfterFunc(batchWindow, func() {
+			rn.mu.Lock()
+			defer rn.mu.Unlock()
+			rn.flushing = false
+			rn.flush()
+		})
+	}
+}
+
+// flush nối lô lệnh đang chờ vào log, lưu một lần rồi replicate tới mọi peer.
+// Lệnh đề xuất ở term cũ (node đã mất quyền Leader) bị huỷ.
+func (rn *RaftNode) flush() {
+	batch := rn.pending
+	rn.pending = nil
+	if len(batch) == 0 {
+		return
+	}
+	appended := false
+	for _, p := range batch {
+		if rn.state != Leader || rn.dead || p.term != rn.currentTerm {
+			if p.ch != nil {
+				close(p.ch)
+			}
+			continue
+		}
+		e := &proto.LogEntry{Term: rn.currentTerm, Index: int64(len(rn.logs)), Command: p.command}
+		rn.logs = append(rn.logs, e)
+		p.index = e.Index
+		if p.ch != nil {
+			rn.waiters[e.Index] = waiter{term: e.Term, ch: p.ch}
+		}
+		appended = true
+	}
+	if appended {
+		rn.save()
+		rn.replicateAll()
+		rn.advanceCommit() // Cluster một node
+	}
+}
+
+// resetProgress khởi tạo trạng thái replicate khi vừa thành Leader: mọi peer
+// được giả định có đủ log tới `next`-1 cho tới khi reply nói khác.
+func (rn *RaftNode) resetProgress(next int64) {
+	for peer := range rn.host.peers {
+		if peer != rn.me {
+			rn.nextIndex[peer], rn.matchIndex[peer], rn.inflight[peer] = next, -1, 0
+		}
+	}
+}
+
+func (rn *RaftNode) replicateAll() {
+	for _, peer := range rn.host.reachable() {
+		rn.replicate(peer)
+	}
+}
+
+// replicate gửi phần log `peer` còn thiếu, giữ tối đa maxInflight request
+// đang bay (gọi khi đang giữ mu).
+func (rn *RaftNode) replicate(peer int32) {
+	if rn.state != Leader || rn.dead || rn.host.blocked(peer) {
+		return
+	}
+	for rn.inflight[peer] < maxInflight && rn.nextIndex[peer] < int64(len(rn.logs)) {
+		next := rn.nextIndex[peer]
+		args := rn.appendArgs(next-1, rn.logs[next:min(int64(len(rn.logs)), next+maxEntries)])
+		rn.nextIndex[peer] = next + int64(len(args.Entries))
+		rn.inflight[peer]++
+		go rn.sendAppend(peer, args)
+	}
+}
+
+// appendArgs tạo AppendEntries mang `entries` nối sau entry `prev`. Entries
+// được chép ra slice mới vì log có thể bị cắt sau khi node mất quyền Leader.
+func (rn *RaftNode) appendArgs(prev int64, entries []*proto.LogEntry) *proto.AppendEntriesArgs {
+	args := &proto.AppendEntriesArgs{
+		Term:         rn.currentTerm,
+		LeaderId:     rn.me,
+		Entries:      append([]*proto.LogEntry(nil), entries...),
+		LeaderCommit: rn.commitIndex,
+		GroupId:      rn.group,
+		PrevLogIndex: prev,
+	}
+	if prev >= 0 {
+		args.PrevLogTerm = rn.logs[prev].Term
+	}
+	// Còn lease (đa số xác nhận trong minElectionTimeout) thì chưa Leader nào
+	// khác commit được: mọi entry commit trước lúc này nằm trong LeaderCommit
+	if time.Since(rn.quorumAt) < minElectionTimeout {
+		args.LeaderTimeMs = time.Now().UnixMilli()
+	}
+	return args
+}
+
+func (rn *RaftNode) sendAppend(peer int32, args *proto.AppendEntriesArgs) {
+	var reply *proto.AppendEntriesReply
+	cl, err := rn.host.client(peer)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), heartbeatTimeout)
+		reply, err = cl.AppendEntries(ctx, args)
+		cancel()
+	}
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if rn.state != Leader || rn.currentTerm != args.Term {
+		return // Reply của term cũ
+	}
+	rn.inflight[peer]--
+	if err != nil {
+		// Gửi lại từ entry đầu của request bị mất ở vòng heartbeat tới
+		rn.nextIndex[peer] = max(min(rn.nextIndex[peer], args.PrevLogIndex+1), rn.matchIndex[peer]+1)
+		return
+	}
+	rn.handleAppendReply(peer, reply)
+	rn.replicate(peer)
+}
+
+// handleAppendReply cập nhật tiến độ của `peer` theo reply của AppendEntries
+// (pipeline hoặc heartbeat) và commit nếu đa số đã có entry.
+func (rn *RaftNode) handleAppendReply(peer int32, r *proto.AppendEntriesReply) {
+	if r.Term > rn.currentTerm {
+		rn.currentTerm, rn.state, rn.votedFor, rn.leaderId = r.Term, Follower, -1, -1
+		rn.persistState()
+		rn.resetElectionTimer()
+		rn.signal()
+		return
+	}
+	if rn.state != Leader || r.Term != rn.currentTerm {
+		return
+	}
+	if r.Success {
+		rn.matchIndex[peer] = max(rn.matchIndex[peer], r.MatchIndex)
+		rn.nextIndex[peer] = max(rn.nextIndex[peer], rn.matchIndex[peer]+1)
+		rn.advanceCommit()
+		return
+	}
+	// Peer thiếu hoặc lệch log: lùi về chỗ peer gợi ý (request đang bay sau đó cũng sẽ bị từ chối)
+	rn.nextIndex[peer] = max(min(rn.nextIndex[peer], r.MatchIndex+1), rn.matchIndex[peer]+1)
+}
+
+// advanceCommit commit tới index lớn nhất mà đa số đã có, nếu entry đó thuộc
+// term hiện tại (Raft §5.4.2).
+func (rn *RaftNode) advanceCommit() {
+	matches := []int64{int64(len(rn.logs)) - 1}
+	for peer := range rn.host.peers {
+		if peer != rn.me {
+			matches = append(matches, rn.matchIndex[peer])
+		}
+	}
+	slices.Sort(matches)
+	n := matches[len(matches)-rn.host.quorum()] // Đa số có log tới n
+	if n > rn.commitIndex && rn.logs[n].Term == rn.currentTerm {
+		rn.commitIndex = n
+		rn.applyCommitted()
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequentialWritesDoNotWaitForHeartbeat(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		if r, err := c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: fmt.Sprint("k", i), Value: "v"}); err != nil || !r.Success {
+			t.Fatalf("put %d: %v %v", i, r, err)
+		}
+	}
+	// Chờ heartbeat 150ms cho mỗi lệnh sẽ mất hơn 7s
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("50 sequential puts took %v", d)
+	}
+}
+
+func TestConcurrentWritesAreBatched(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, 2000)
+	for w := 0; w < 50; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 40; i++ {
+				r, err := c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: fmt.Sprintf("w%d-%d", w, i), Value: "v"})
+				if err != nil || !r.Success {
+					errs <- fmt.Errorf("put w%d-%d: %v %v", w, i, r, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	// Mọi node có cùng 2000 lệnh (cộng no-op) theo cùng thứ tự
+	want := commands(c.ledger(leader))
+	if len(want) < 2000 {
+		t.Fatalf("leader committed %d entries", len(want))
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for id := int32(0); id < 5; id++ {
+		for fmt.Sprint(commands(c.ledger(id))) != fmt.Sprint(want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("node %d ledger diverges: %d entries", id, len(c.ledger(id)))
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// Request pipeline tới không theo thứ tự: request cũ không được cắt mất
+// entry mà request mới hơn đã ghi.
+func TestAppendEntriesOutOfOrder(t *testing.T) {
+	cfg := &ClusterConfig{}
+	for i := int32(0); i < 5; i++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: i, Addr: "127.0.0.1:1"})
+	}
+	h := NewHost(0, cfg, t.TempDir(), 1)
+	defer h.Kill()
+	rn := h.Group(0)
+	entry := func(i int64) *proto.LogEntry {
+		return &proto.LogEntry{Term: 5, Index: i, Command: fmt.Sprint("c", i)}
+	}
+	send := func(prev int64, entries ...*proto.LogEntry) *proto.AppendEntriesReply {
+		args := &proto.AppendEntriesArgs{Term: 5, LeaderId: 1, Entries: entries, LeaderCommit: -1, PrevLogIndex: prev}
+		if prev >= 0 {
+			args.PrevLogTerm = 5
+		}
+		r, _ := rn.AppendEntries(nil, args)
+		return r
+	}
+	if r := send(-1, entry(0), entry(1)); !r.Success || r.MatchIndex != 1 {
+		t.Fatalf("first append = %v", r)
+	}
+	// Request thứ ba tới trước request thứ hai: bị từ chối, gợi ý gửi lại từ 2
+	if r := send(3, entry(4)); r.Success || r.MatchIndex != 1 {
+		t.Fatalf("gap append = %v", r)
+	}
+	if r := send(1, entry(2), entry(3)); !r.Success || r.MatchIndex != 3 {
+		t.Fatalf("second append = %v", r)
+	}
+	// Bản lặp của request đầu tới muộn
+	if r := send(-1, entry(0)); !r.Success || r.MatchIndex != 0 {
+		t.Fatalf("duplicate append = %v", r)
+	}
+	rn.mu.Lock()
+	n := len(rn.logs)
+	rn.mu.Unlock()
+	if n != 4 {
+		t.Fatalf("log has %d entries after out-of-order appends, want 4", n)
+	}
+	// Entry khác term thay thế phần đuôi lệch
+	if r := send(1, &proto.LogEntry{Term: 6, Index: 2}); !r.Success {
+		t.Fatalf("conflicting append = %v", r)
+	}
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	if len(rn.logs) != 3 || rn.logs[2].Term != 6 {
+		t.Fatalf("log after conflict = %v", rn.logs)
+	}
+	// File được ghi lại sau khi cắt: đọc lại đúng log hiện tại
+	reloaded := &RaftNode{me: rn.me, group: rn.group, dataDir: rn.dataDir}
+	reloaded.load()
+	if len(reloaded.logs) != 3 || reloaded.logs[2].Term != 6 || reloaded.logs[1].Command != "c1" {
+		t.Fatalf("reloaded log = %v", reloaded.logs)
+	}
+}
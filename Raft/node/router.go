@@ -0,0 +1,426 @@
+package main
+
+import (
+	"consensus/Raft/kv"
+	"consensus/Raft/shard"
+	"consensus/common/proto"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Range sharding: shard map nằm trong log của nhóm metadata, router trên mọi
+// Host tra bản sao map cục bộ để gửi lệnh ghi / đọc tới Leader của nhóm sở
+// hữu key. Bản sao có thể cũ: nhóm không sở hữu range (đã chuyển đi hoặc
+// chưa nạp xong) trả ErrWrongGroup và router tra lại khi map bắt kịp.
+
+const metaGroup int32 = 0
+
+var (
+	errWrongGroup = errors.New(kv.ErrWrongGroup)
+	errTooStale   = errors.New("replica too stale")
+)
+
+// readBound là giới hạn trễ client chấp nhận cho follower read; 0 = không giới hạn chiều đó.
+type readBound struct {
+	maxStaleness time.Duration
+	maxLag       int64
+}
+
+// stale cho biết client cho phép đọc bản sao cục bộ.
+func (b readBound) stale() bool {
+	return b.maxStaleness > 0 || b.maxLag > 0
+}
+
+// readResult là kết quả đọc gộp qua các range; staleness/lag = 0 với đọc linearizable.
+type readResult struct {
+	kvs       []*proto.KeyValue
+	leader    int32
+	staleness time.Duration
+	lag       int64
+}
+
+// route trả về range chứa key theo shard map cục bộ.
+func (h *Host) route(key string) shard.Range {
+	meta := h.Group(metaGroup)
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	return meta.shards.Lookup(key)
+}
+
+func (h *Host) shardMap() ([]shard.Range, int64) {
+	meta := h.Group(metaGroup)
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	return meta.shards.Ranges(), meta.shards.Version()
+}
+
+func (h *Host) shardClient(peer int32) (proto.ShardServiceClient, error) {
+	conn, err := h.conn(peer)
+	if err != nil {
+		return nil, err
+	}
+	return proto.NewShardServiceClient(conn), nil
+}
+
+// pause chờ một nhịp trước khi thử lại, trả lỗi nếu ctx hết hạn.
+func pause(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(20 * time.Millisecond):
+		return nil
+	}
+}
+
+// replyErr chuyển lỗi dạng chuỗi trong reply của Host khác về lỗi của router.
+func replyErr(msg string) error {
+	switch msg {
+	case errNotLeader.Error():
+		return errNotLeader
+	case kv.ErrWrongGroup:
+		return errWrongGroup
+	}
+	return errors.New(msg)
+}
+
+// onLeader chạy `call` trên Host đang làm Leader của nhóm: thử Host được gợi
+// ý trước, gặp errNotLeader thì theo gợi ý trong reply hoặc thử Host kế tiếp.
+func (h *Host) onLeader(ctx context.Context, group int32, call func(peer int32) (hint int32, err error)) error {
+	rn, err := h.lookup(group)
+	if err != nil {
+		return err
+	}
+	next := rn.leaderHint()
+	if next < 0 {
+		next = h.me
+	}
+	for {
+		hint, err := call(next)
+		if err != errNotLeader {
+			return err
+		}
+		if hint >= 0 && hint != next {
+			next = hint
+		} else {
+			next = (next + 1) % int32(len(h.peers))
+		}
+		if err := pause(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// submitTo ghi command vào log của nhóm `group` qua Leader của nhóm và trả về
+// kết quả state machine dạng JSON cùng id của Leader đã xử lý.
+func (h *Host) submitTo(ctx context.Context, group int32, command string) (string, int32, error) {
+	var result string
+	var leader int32
+	err := h.onLeader(ctx, group, func(peer int32) (int32, error) {
+		if peer == h.me {
+			rn := h.Group(group)
+			out, err := rn.submit(ctx, command)
+			if err != nil {
+				return rn.leaderHint(), err
+			}
+			data, _ := json.Marshal(out)
+			result, leader = string(data), peer
+			return peer, nil
+		}
+		cl, err := h.shardClient(peer)
+		if err != nil {
+			return -1, err
+		}
+		reply, err := cl.Submit(ctx, &proto.ShardSubmitArgs{GroupId: group, Command: command})
+		if err != nil {
+			if ctx.Err() != nil {
+				return -1, ctx.Err()
+			}
+			return -1, errNotLeader // Host không kết nối được: thử Host khác
+		}
+		if !reply.Success {
+			return reply.LeaderId, replyErr(reply.Error)
+		}
+		result, leader = reply.Result, peer
+		return peer, nil
+	})
+	return result, leader, err
+}
+
+// readFrom đọc linearizable [start, end) của nhóm `group` trên Leader của nhóm.
+func (h *Host) readFrom(ctx context.Context, group int32, start, end string, limit int32) (readResult, error) {
+	var res readResult
+	err := h.onLeader(ctx, group, func(peer int32) (int32, error) {
+		var reply *proto.KVScanReply
+		var err error
+		if peer == h.me {
+			reply, err = h.Group(group).read(ctx, start, end, int(limit))
+		} else {
+			var cl proto.ShardServiceClient
+			if cl, err = h.shardClient(peer); err != nil {
+				return -1, err
+			}
+			if reply, err = cl.Read(ctx, &proto.ShardReadArgs{GroupId: group, Start: start, End: end, Limit: limit}); err != nil && ctx.Err() == nil {
+				return -1, errNotLeader
+			}
+		}
+		if err != nil {
+			return -1, err
+		}
+		if !reply.Success {
+			return reply.LeaderId, replyErr(reply.Error)
+		}
+		res = readResult{kvs: reply.Kvs, leader: peer}
+		return peer, nil
+	})
+	return res, err
+}
+
+// staleFrom trả về hàm đọc bản sao cục bộ của nhóm, errTooStale khi bản sao
+// trễ hơn bound.
+func (h *Host) staleFrom(b readBound) func(context.Context, int32, string, string, int32) (readResult, error) {
+	return func(ctx context.Context, group int32, start, end string, limit int32) (readResult, error) {
+		rn, err := h.lookup(group)
+		if err != nil {
+			return readResult{}, err
+		}
+		return rn.staleRead(start, end, int(limit), b)
+	}
+}
+
+// write gửi lệnh KV tới nhóm sở hữu `key`, tra lại shard map khi nhóm báo
+// range đã chuyển đi.
+func (h *Host) write(ctx context.Context, key string, cmd kv.Command) (kv.Result, int32, error) {
+	for {
+		out, leader, err := h.submitTo(ctx, h.route(key).Group, cmd.Encode())
+		if err != nil {
+			return kv.Result{}, -1, err
+		}
+		var res kv.Result
+		_ = json.Unmarshal([]byte(out), &res)
+		if res.Err != kv.ErrWrongGroup {
+			return res, leader, nil
+		}
+		if err := pause(ctx); err != nil {
+			return kv.Result{}, -1, err
+		}
+	}
+}
+
+// scan đọc [start, end) qua mọi range giao với khoảng này theo thứ tự key:
+// từ bản sao cục bộ nếu bound cho phép, ngược lại (hoặc bản sao quá trễ)
+// đọc linearizable trên Leader của từng nhóm.
+func (h *Host) scan(ctx context.Context, start, end string, limit int32, b readBound) (readResult, error) {
+	if b.stale() {
+		if res, err := h.scanRanges(ctx, start, end, limit, h.staleFrom(b)); err != errTooStale {
+			return res, err
+		}
+	}
+	return h.scanRanges(ctx, start, end, limit, h.readFrom)
+}
+
+func (h *Host) scanRanges(ctx context.Context, start, end string, limit int32, read func(context.Context, int32, string, string, int32) (readResult, error)) (readResult, error) {
+retry:
+	for {
+		out := readResult{leader: -1}
+		ranges, _ := h.shardMap()
+		for _, r := range ranges {
+			s, e := max(r.Start, start), r.End
+			if e == "" || (end != "" && end < e) {
+				e = end
+			}
+			if e != "" && s >= e {
+				continue
+			}
+			want := int32(0)
+			if limit > 0 {
+				want = limit - int32(len(out.kvs))
+			}
+			res, err := read(ctx, r.Group, s, e, want)
+			if err == errWrongGroup {
+				if err := pause(ctx); err != nil {
+					return readResult{}, err
+				}
+				continue retry
+			}
+			if err != nil {
+				return readResult{}, err
+			}
+			out.kvs, out.leader = append(out.kvs, res.kvs...), res.leader
+			out.staleness, out.lag = max(out.staleness, res.staleness), max(out.lag, res.lag)
+			if limit > 0 && int32(len(out.kvs)) >= limit {
+				break
+			}
+		}
+		return out, nil
+	}
+}
+
+// commitMap ghi thay đổi shard map vào nhóm metadata, trả về map sau thay đổi.
+func (h *Host) commitMap(ctx context.Context, change shard.Command) ([]shard.Range, error) {
+	out, _, err := h.submitTo(ctx, metaGroup, change.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var res shard.Result
+	_ = json.Unmarshal([]byte(out), &res)
+	if !res.OK {
+		return nil, errors.New(res.Err)
+	}
+	return res.Ranges, nil
+}
+
+// syncMap chờ shard map cục bộ apply mọi thay đổi đã commit; chỉ thành
+// công trên Leader của nhóm metadata.
+func (h *Host) syncMap(ctx context.Context) error {
+	meta := h.Group(metaGroup)
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	return meta.readIndex(ctx)
+}
+
+// split tách range chứa key tại key và chuyển [key, end) sang nhóm dst.
+// Chạy trên Leader của nhóm metadata.
+func (h *Host) split(ctx context.Context, key string, dst int32) ([]shard.Range, error) {
+	h.moveMu.Lock()
+	defer h.moveMu.Unlock()
+	if err := h.syncMap(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := h.lookup(dst); err != nil {
+		return nil, err
+	}
+	r := h.route(key)
+	if key == r.Start {
+		return nil, fmt.Errorf("split at range start")
+	}
+	change := shard.Command{Op: shard.OpSplit, Key: key, Start: r.Start, End: r.End, From: r.Group, Group: dst}
+	if r.Group == dst {
+		return h.commitMap(ctx, change)
+	}
+	return h.move(ctx, r.Group, dst, key, r.End, change)
+}
+
+// merge gộp range kết thúc tại key với range bắt đầu tại key vào nhóm của range bên trái.
+func (h *Host) merge(ctx context.Context, key string) ([]shard.Range, error) {
+	h.moveMu.Lock()
+	defer h.moveMu.Unlock()
+	if err := h.syncMap(ctx); err != nil {
+		return nil, err
+	}
+	ranges, _ := h.shardMap()
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start != key {
+			continue
+		}
+		left, right := ranges[i-1], ranges[i]
+		change := shard.Command{Op: shard.OpMerge, Key: key, Start: left.Start, End: right.End, From: right.Group, Group: left.Group}
+		if left.Group == right.Group {
+			return h.commitMap(ctx, change)
+		}
+		return h.move(ctx, right.Group, left.Group, key, right.End, change)
+	}
+	return nil, fmt.Errorf("no range boundary at key")
+}
+
+// move chuyển range [start, end) từ nhóm from sang nhóm to rồi đổi map:
+//  1. nhóm nguồn thôi sở hữu range, trả dữ liệu tại thời điểm đó;
+//  2. nạp dữ liệu vào nhóm đích (giữ nguyên version), nhóm đích sở hữu range;
+//  3. ghi map mới vào nhóm metadata, router bắt đầu gửi tới nhóm đích;
+//  4. xoá dữ liệu cũ ở nhóm nguồn.
+//
+// Lỗi giữa chừng để range không được phục vụ; gọi lại split/merge sẽ lấy
+// lại dữ liệu (vẫn còn ở nhóm nguồn) và đi tiếp.
+func (h *Host) move(ctx context.Context, from, to int32, start, end string, change shard.Command) ([]shard.Range, error) {
+	out, _, err := h.submitTo(ctx, from, kv.Command{Op: kv.OpFence, Key: start, End: end}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var fenced kv.Result
+	_ = json.Unmarshal([]byte(out), &fenced)
+	ingest := kv.Command{Op: kv.OpIngest, Key: start, End: end}
+	for _, k := range fenced.Keys {
+		ingest.Writes = append(ingest.Writes, kv.Write{Key: k.Key, Value: k.Entry.Value, Version: k.Entry.Version})
+	}
+	if _, _, err := h.submitTo(ctx, to, ingest.Encode()); err != nil {
+		return nil, err
+	}
+	ranges, err := h.commitMap(ctx, change)
+	if err != nil {
+		// Map đã bị đổi bởi thao tác khác: bỏ bản sao ở nhóm đích, trả range cho nhóm nguồn
+		h.submitTo(ctx, from, kv.Command{Op: kv.OpIngest, Key: start, End: end}.Encode())
+		h.submitTo(ctx, to, kv.Command{Op: kv.OpFence, Key: start, End: end}.Encode())
+		h.submitTo(ctx, to, kv.Command{Op: kv.OpDrop, Key: start, End: end}.Encode())
+		return nil, err
+	}
+	_, _, err = h.submitTo(ctx, from, kv.Command{Op: kv.OpDrop, Key: start, End: end}.Encode())
+	return ranges, err
+}
+
+// autoSplit mỗi giây tách range có hơn maxKeys key tại key giữa, chuyển nửa
+// sau sang nhóm dữ liệu đang giữ ít range nhất. Chỉ Host đang làm Leader
+// nhóm metadata ra quyết định; số key đếm trên bản sao cục bộ của nhóm.
+func (h *Host) autoSplit(maxKeys int) {
+	go func() {
+		for {
+			time.Sleep(time.Second)
+			h.mu.Lock()
+			dead, groups := h.dead, len(h.groups)
+			h.mu.Unlock()
+			if dead {
+				return
+			}
+			if groups < 2 || h.Group(metaGroup).leaderHint() != h.me {
+				continue
+			}
+			ranges, _ := h.shardMap()
+			h.splitOversized(ranges, maxKeys)
+		}
+	}()
+}
+
+// splitOversized tách range đầu tiên có hơn maxKeys key tại key giữa. Range
+// thuộc nhóm mà Host này không chạy (cấu hình -groups khác nhau) bị bỏ qua.
+func (h *Host) splitOversized(ranges []shard.Range, maxKeys int) {
+	for _, r := range ranges {
+		rn := h.Group(r.Group)
+		if rn == nil {
+			continue
+		}
+		rn.mu.Lock()
+		keys := rn.store.Scan(r.Start, r.End, 0)
+		rn.mu.Unlock()
+		if len(keys) <= maxKeys {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if _, err := h.split(ctx, keys[len(keys)/2].Key, h.emptiestGroup(ranges)); err != nil {
+			log.Printf("auto split %q: %v", keys[len(keys)/2].Key, err)
+		}
+		cancel()
+		return // Map đã đổi, tra lại ở vòng sau
+	}
+}
+
+// emptiestGroup trả về nhóm dữ liệu (khác nhóm metadata) giữ ít range nhất.
+func (h *Host) emptiestGroup(ranges []shard.Range) int32 {
+	count := make(map[int32]int)
+	for _, r := range ranges {
+		count[r.Group]++
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	best := int32(-1)
+	for g := range h.groups {
+		if g == metaGroup {
+			continue
+		}
+		if best < 0 || count[g] < count[best] || (count[g] == count[best] && g < best) {
+			best = g
+		}
+	}
+	return best
+}
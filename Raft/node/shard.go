@@ -0,0 +1,101 @@
+package main
+
+import (
+	"consensus/Raft/shard"
+	"consensus/common/proto"
+	"context"
+	"encoding/json"
+)
+
+// shardServer phục vụ ShardService: xem / đổi shard map, và nhận lệnh mà
+// router trên Host khác chuyển tới Leader của nhóm.
+type shardServer struct {
+	proto.UnimplementedShardServiceServer
+	h *Host
+}
+
+func toShardRanges(ranges []shard.Range) []*proto.ShardRange {
+	var out []*proto.ShardRange
+	for _, r := range ranges {
+		out = append(out, &proto.ShardRange{Start: r.Start, End: r.End, GroupId: r.Group})
+	}
+	return out
+}
+
+func (s *shardServer) GetShardMap(ctx context.Context, _ *proto.Empty) (*proto.ShardMapReply, error) {
+	ranges, version := s.h.shardMap()
+	return &proto.ShardMapReply{Ranges: toShardRanges(ranges), Version: version}, nil
+}
+
+// onMetaLeader chạy thay đổi map trên Leader của nhóm metadata: tại chỗ nếu
+// Host này là Leader, ngược lại chuyển tiếp qua `remote`.
+func (s *shardServer) onMetaLeader(ctx context.Context, local func() ([]shard.Range, error), remote func(proto.ShardServiceClient) (*proto.ShardReply, error)) (*proto.ShardReply, error) {
+	var reply *proto.ShardReply
+	err := s.h.onLeader(ctx, metaGroup, func(peer int32) (int32, error) {
+		if peer == s.h.me {
+			ranges, err := local()
+			if err == errNotLeader {
+				return s.h.Group(metaGroup).leaderHint(), err
+			}
+			reply = &proto.ShardReply{Success: err == nil, Ranges: toShardRanges(ranges), LeaderId: peer}
+			if err != nil {
+				reply.Error = err.Error()
+			}
+			return peer, nil
+		}
+		cl, err := s.h.shardClient(peer)
+		if err != nil {
+			return -1, err
+		}
+		r, err := remote(cl)
+		if err != nil {
+			if ctx.Err() != nil {
+				return -1, ctx.Err()
+			}
+			return -1, errNotLeader
+		}
+		if r.Error == errNotLeader.Error() {
+			return r.LeaderId, errNotLeader
+		}
+		reply = r
+		return peer, nil
+	})
+	return reply, err
+}
+
+func (s *shardServer) Split(ctx context.Context, args *proto.ShardSplitArgs) (*proto.ShardReply, error) {
+	return s.onMetaLeader(ctx,
+		func() ([]shard.Range, error) { return s.h.split(ctx, args.Key, args.GroupId) },
+		func(cl proto.ShardServiceClient) (*proto.ShardReply, error) { return cl.Split(ctx, args) })
+}
+
+func (s *shardServer) Merge(ctx context.Context, args *proto.ShardMergeArgs) (*proto.ShardReply, error) {
+	return s.onMetaLeader(ctx,
+		func() ([]shard.Range, error) { return s.h.merge(ctx, args.Key) },
+		func(cl proto.ShardServiceClient) (*proto.ShardReply, error) { return cl.Merge(ctx, args) })
+}
+
+// Submit ghi lệnh vào nhóm khi Host này là Leader của nhóm; không chuyển tiếp.
+func (s *shardServer) Submit(ctx context.Context, args *proto.ShardSubmitArgs) (*proto.ShardSubmitReply, error) {
+	rn, err := s.h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	out, err := rn.submit(ctx, args.Command)
+	if err == errNotLeader {
+		return &proto.ShardSubmitReply{Error: err.Error(), LeaderId: rn.leaderHint()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(out)
+	return &proto.ShardSubmitReply{Success: true, Result: string(data), LeaderId: rn.me}, nil
+}
+
+func (s *shardServer) Read(ctx context.Context, args *proto.ShardReadArgs) (*proto.KVScanReply, error) {
+	rn, err := s.h.lookup(args.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	return rn.read(ctx, args.Start, args.End, int(args.Limit))
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"consensus/Raft/kv"
+	"consensus/Raft/shard"
+	"consensus/common/proto"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func (c *testCluster) shards(id int32) *shardServer {
+	return &shardServer{h: c.hosts[id]}
+}
+
+// storeKeys trả về các key trong state machine của nhóm `g` trên Host `id`.
+func (c *testCluster) storeKeys(id, g int32) []string {
+	rn := c.hosts[id].Group(g)
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	var keys []string
+	for _, e := range rn.store.Scan("", "", 0) {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func (c *testCluster) putKeys(ctx context.Context, id int32, keys ...string) {
+	c.t.Helper()
+	for _, k := range keys {
+		if r, err := c.kv(id).Put(ctx, &proto.KVPutArgs{Key: k, Value: "v-" + k}); err != nil || !r.Success {
+			c.t.Fatalf("put %s: %v %v", k, r, err)
+		}
+	}
+}
+
+func TestShardSplitMovesRangeAndRoutes(t *testing.T) {
+	c := newMultiCluster(t, 3)
+	for g := int32(0); g < 3; g++ {
+		c.groupLeader(g, -1, 3*time.Second)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	c.putKeys(ctx, 1, "a", "f", "m", "t", "z")
+
+	split, _ := c.shards(2).Split(ctx, &proto.ShardSplitArgs{Key: "m", GroupId: 1})
+	if !split.Success || len(split.Ranges) != 2 || split.Ranges[1].Start != "m" || split.Ranges[1].GroupId != 1 {
+		t.Fatalf("split = %v", split)
+	}
+	// Mọi Host (kể cả Follower của mọi nhóm) route được tới nhóm mới
+	for id := int32(0); id < 5; id++ {
+		for _, k := range []string{"a", "m", "z"} {
+			if r, err := c.kv(id).Get(ctx, &proto.KVGetArgs{Key: k}); err != nil || !r.Found || r.Kv.Value != "v-"+k {
+				t.Fatalf("host %d get %s = %v %v", id, k, r, err)
+			}
+		}
+	}
+	leader0 := c.groupLeader(0, -1, time.Second)
+	leader1 := c.groupLeader(1, -1, time.Second)
+	if got := fmt.Sprint(c.storeKeys(leader0, 0)); got != "[a f]" {
+		t.Fatalf("group 0 keeps %s after split", got)
+	}
+	if got := fmt.Sprint(c.storeKeys(leader1, 1)); got != "[m t z]" {
+		t.Fatalf("group 1 holds %s after split", got)
+	}
+	// Ghi vào nhóm cũ bằng map cũ bị từ chối
+	out, _ := c.hosts[leader0].Group(0).submit(ctx, kv.Command{Op: kv.OpPut, Key: "x", Value: "stale"}.Encode())
+	if res := out.(kv.Result); res.Err != kv.ErrWrongGroup {
+		t.Fatalf("stale write to old group = %+v", res)
+	}
+
+	c.putKeys(ctx, 3, "n", "b")
+	scan, _ := c.kv(4).Scan(ctx, &proto.KVScanArgs{Start: "b", End: "u"})
+	var keys []string
+	for _, kv := range scan.Kvs {
+		keys = append(keys, kv.Key)
+	}
+	if fmt.Sprint(keys) != "[b f m n t]" {
+		t.Fatalf("scan across ranges = %v", keys)
+	}
+	if r, _ := c.kv(0).Txn(ctx, &proto.KVTxnArgs{Writes: []*proto.KVWrite{{Key: "a", Value: "1"}, {Key: "z", Value: "1"}}}); r.Success || r.Error != "cross-shard txn" {
+		t.Fatalf("cross-shard txn = %v", r)
+	}
+}
+
+func TestShardMergeAndProposeByKey(t *testing.T) {
+	c := newMultiCluster(t, 3)
+	for g := int32(0); g < 3; g++ {
+		c.groupLeader(g, -1, 3*time.Second)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	c.putKeys(ctx, 0, "a", "k", "q", "w")
+	for _, s := range []struct {
+		key   string
+		group int32
+	}{{"h", 1}, {"p", 2}} {
+		if r, _ := c.shards(0).Split(ctx, &proto.ShardSplitArgs{Key: s.key, GroupId: s.group}); !r.Success {
+			t.Fatalf("split %s: %v", s.key, r)
+		}
+	}
+	if r, _ := c.shards(1).Merge(ctx, &proto.ShardMergeArgs{Key: "p"}); !r.Success || len(r.Ranges) != 2 || r.Ranges[1].End != "" || r.Ranges[1].GroupId != 1 {
+		t.Fatalf("merge = %v", r)
+	}
+	if r, _ := c.shards(1).Merge(ctx, &proto.ShardMergeArgs{Key: "p"}); r.Success {
+		t.Fatal("merge at removed boundary succeeded")
+	}
+	scan, _ := c.kv(2).Scan(ctx, &proto.KVScanArgs{})
+	if len(scan.Kvs) != 4 || scan.Kvs[3].Key != "w" {
+		t.Fatalf("scan after merge = %v", scan.Kvs)
+	}
+	// Ghi vào range vừa trả về nhóm 1 sau khi nhóm 2 giữ nó
+	c.putKeys(ctx, 4, "r")
+	if got := fmt.Sprint(c.storeKeys(c.groupLeader(1, -1, time.Second), 1)); got != "[k q r w]" {
+		t.Fatalf("group 1 holds %s after merge", got)
+	}
+
+	// Propose theo key đi vào nhóm sở hữu key
+	leader1 := c.groupLeader(1, -1, time.Second)
+	if r, _ := c.hosts[leader1].Propose(context.Background(), &proto.ProposeArgs{Command: "routed", Key: "zz"}); !r.Success {
+		t.Fatalf("propose by key = %v", r)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !contains(c.groupCommands(leader1, 1), "routed") {
+		if time.Now().After(deadline) {
+			t.Fatalf("group 1 did not commit routed proposal: %v", c.groupCommands(leader1, 1))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if contains(c.groupCommands(leader1, 0), "routed") {
+		t.Fatal("routed proposal landed in group 0")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestShardAutoSplit(t *testing.T) {
+	c := newMultiCluster(t, 2)
+	for g := int32(0); g < 2; g++ {
+		c.groupLeader(g, -1, 3*time.Second)
+	}
+	for _, h := range c.hosts {
+		h.autoSplit(4)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.putKeys(ctx, 0, "a", "b", "c", "d", "e", "f")
+	for {
+		m, _ := c.shards(0).GetShardMap(ctx, &proto.Empty{})
+		if len(m.Ranges) == 2 && m.Ranges[1].GroupId == 1 && m.Version > 0 {
+			break
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("range not split: %v", m)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	scan, err := c.kv(3).Scan(ctx, &proto.KVScanArgs{})
+	if err != nil || len(scan.Kvs) != 6 {
+		t.Fatalf("scan after auto split = %v %v", scan, err)
+	}
+}
+
+// Range trỏ tới nhóm mà Host không chạy (cấu hình -groups lệch nhau) bị bỏ
+// qua thay vì làm vòng auto split panic.
+func TestShardAutoSplitSkipsUnknownGroup(t *testing.T) {
+	cfg := &ClusterConfig{}
+	for i := int32(0); i < 5; i++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: i, Addr: "127.0.0.1:1"})
+	}
+	h := NewHost(0, cfg, t.TempDir(), 2)
+	defer h.Kill()
+	h.splitOversized([]shard.Range{{Start: "", End: "m", Group: 7}, {Start: "m", Group: 1}}, 0)
+}
+
+// Version đi theo key khi range được chuyển: không lùi về index log của
+// nhóm đích, nên CAS bằng version đọc trước khi chuyển vẫn đúng nghĩa.
+func TestShardMoveKeepsVersions(t *testing.T) {
+	c := newMultiCluster(t, 2)
+	for g := int32(0); g < 2; g++ {
+		c.groupLeader(g, -1, 3*time.Second)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	// Nhóm 0 ghi nhiều hơn để index log của nó vượt xa nhóm 1
+	for i := 0; i < 20; i++ {
+		c.putKeys(ctx, 0, "a", "m")
+	}
+	before, _ := c.kv(0).Get(ctx, &proto.KVGetArgs{Key: "m"})
+
+	if r, _ := c.shards(0).Split(ctx, &proto.ShardSplitArgs{Key: "h", GroupId: 1}); !r.Success {
+		t.Fatalf("split = %v", r)
+	}
+	after, _ := c.kv(1).Get(ctx, &proto.KVGetArgs{Key: "m"})
+	if after.Kv.Version != before.Kv.Version {
+		t.Fatalf("version after move = %d, want %d", after.Kv.Version, before.Kv.Version)
+	}
+	cas, _ := c.kv(2).CompareAndSwap(ctx, &proto.KVCasArgs{Key: "m", Value: "moved", ExpectedVersion: before.Kv.Version})
+	if !cas.Success || cas.Kv.Version <= before.Kv.Version {
+		t.Fatalf("cas with pre-move version = %v", cas)
+	}
+	// Xoá rồi tạo lại ở nhóm đích: version mới vẫn lớn hơn mọi version cũ
+	c.kv(3).Delete(ctx, &proto.KVDeleteArgs{Key: "m"})
+	c.putKeys(ctx, 3, "m")
+	again, _ := c.kv(4).Get(ctx, &proto.KVGetArgs{Key: "m"})
+	if again.Kv.Version <= cas.Kv.Version {
+		t.Fatalf("recreated key version %d not above %d", again.Kv.Version, cas.Kv.Version)
+	}
+	if r, _ := c.kv(0).CompareAndSwap(ctx, &proto.KVCasArgs{Key: "m", Value: "stale", ExpectedVersion: before.Kv.Version}); r.Success {
+		t.Fatalf("stale cas succeeded: %v", r)
+	}
+}
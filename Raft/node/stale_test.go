@@ -0,0 +1,134 @@
+package main
+
+import (
+	"consensus/common/proto"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFollowerReadWithinBound(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "k", Value: "v1"})
+
+	follower := (leader + 1) % 5
+	// Follower đã nhận heartbeat chứa entry vừa commit
+	deadline := time.Now().Add(time.Second)
+	for {
+		r, err := c.kv(follower).Get(ctx, &proto.KVGetArgs{Key: "k", MaxStalenessMs: 1000, MaxLagEntries: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Found && r.Kv.Value == "v1" {
+			if r.StalenessMs <= 0 || r.StalenessMs > 1000 || r.LagEntries > 5 || r.LeaderId != leader {
+				t.Fatalf("follower read = %v", r)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("follower never served v1: %v", r)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// Đọc không đặt bound vẫn linearizable
+	if r, _ := c.kv(follower).Get(ctx, &proto.KVGetArgs{Key: "k"}); r.StalenessMs != 0 || r.Kv.GetValue() != "v1" {
+		t.Fatalf("linearizable read = %v", r)
+	}
+}
+
+func TestFollowerReadFallsBackWhenTooStale(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "k", Value: "old"})
+
+	// Cô lập một Follower (khác Leader) sau khi nó đã nhận "old"
+	follower := (leader + 1) % 5
+	for deadline := time.Now().Add(2 * time.Second); len(c.ledger(follower)) < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("follower did not commit the put")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	var rest []int32
+	for id := int32(0); id < 5; id++ {
+		if id != follower {
+			rest = append(rest, id)
+		}
+	}
+	c.partition([]int32{follower}, rest)
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "k", Value: "new"})
+	time.Sleep(500 * time.Millisecond)
+
+	// Bound rộng: bản sao cục bộ cũ được phục vụ kèm độ trễ
+	r, err := c.kv(follower).Get(ctx, &proto.KVGetArgs{Key: "k", MaxStalenessMs: 5000})
+	if err != nil || r.Kv.GetValue() != "old" || r.StalenessMs < 500 {
+		t.Fatalf("stale read = %v %v", r, err)
+	}
+	// Bound hẹp: bản sao quá trễ, đọc qua Leader
+	r, err = c.kv(follower).Get(ctx, &proto.KVGetArgs{Key: "k", MaxStalenessMs: 200})
+	if err != nil || r.Kv.GetValue() != "new" || r.StalenessMs != 0 {
+		t.Fatalf("bounded read = %v %v", r, err)
+	}
+}
+
+// Safe time của Follower lấy theo đồng hồ Leader trừ độ lệch đồng hồ tối
+// đa, không theo lúc nhận: trễ mạng hay đồng hồ Leader chạy nhanh không làm
+// Follower tưởng mình mới hơn thực tế.
+func TestFollowerSafeTimeFromLeaderClock(t *testing.T) {
+	cfg := &ClusterConfig{MaxClockOffsetMs: 200}
+	for i := int32(0); i < 5; i++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: i, Addr: "127.0.0.1:1"})
+	}
+	h := NewHost(0, cfg, t.TempDir(), 1)
+	defer h.Kill()
+	rn := h.Group(0)
+	safeAfter := func(leaderTime time.Time) time.Time {
+		args := &proto.AppendEntriesArgs{Term: 1, LeaderId: 1, LeaderCommit: -1, PrevLogIndex: -1}
+		if !leaderTime.IsZero() {
+			args.LeaderTimeMs = leaderTime.UnixMilli()
+		}
+		if r, _ := rn.AppendEntries(nil, args); !r.Success {
+			t.Fatalf("append = %v", r)
+		}
+		rn.mu.Lock()
+		defer rn.mu.Unlock()
+		return rn.safeTime
+	}
+
+	// Leader không còn lease: không có mốc thời gian, không đọc cục bộ được
+	if st := safeAfter(time.Time{}); !st.IsZero() {
+		t.Fatalf("safe time without leader time = %v", st)
+	}
+	sent := time.Now().Add(-time.Second) // Gói tin đi mất 1s
+	if st := safeAfter(sent); !st.Equal(time.UnixMilli(sent.UnixMilli()).Add(-200 * time.Millisecond)) {
+		t.Fatalf("safe time = %v, want leader time %v - 200ms", st, sent)
+	}
+	// Đồng hồ Leader chạy nhanh quá bound: safe time không vượt đồng hồ cục bộ
+	if st := safeAfter(time.Now().Add(time.Hour)); st.After(time.Now()) {
+		t.Fatalf("safe time %v is in the future", st)
+	}
+}
+
+func TestLeaderStampsTimeOnlyWithLease(t *testing.T) {
+	cfg := &ClusterConfig{}
+	for i := int32(0); i < 5; i++ {
+		cfg.Nodes = append(cfg.Nodes, NodeConfig{ID: i, Addr: "127.0.0.1:1"})
+	}
+	h := NewHost(0, cfg, t.TempDir(), 1)
+	defer h.Kill()
+	rn := h.Group(0)
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.quorumAt = time.Now()
+	if args := rn.appendArgs(-1, nil); args.LeaderTimeMs == 0 {
+		t.Fatal("leader with a fresh quorum did not stamp its clock")
+	}
+	rn.quorumAt = time.Now().Add(-minElectionTimeout)
+	if args := rn.appendArgs(-1, nil); args.LeaderTimeMs != 0 {
+		t.Fatalf("leader without lease stamped %d", args.LeaderTimeMs)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"consensus/common/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchCommitted stream các entry đã commit theo thứ tự index, bắt đầu từ
+// args.FromIndex. Chỉ entry đã commit mới được gửi nên client resume ở bất
+// kỳ node nào cũng thấy cùng một chuỗi.
+//
+// FromIndex < 0 hoặc nhỏ hơn entry đầu còn giữ trong log: gửi snapshot tại
+// lastApplied (KV và bảng lock) trước, rồi tiếp tục từ entry sau snapshot.
+// Entry được lấy theo offset so với entry đầu nên vẫn đúng khi log bị compact.
+func (rn *RaftNode) WatchCommitted(args *proto.WatchArgs, stream grpc.ServerStreamingServer[proto.WatchEvent]) error {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	next := args.FromIndex
+	if next < 0 || next < rn.firstIndex() {
+		snap := &proto.KVSnapshot{Index: rn.lastApplied}
+		for _, e := range rn.store.Scan("", "", 0) {
+			snap.Kvs = append(snap.Kvs, toKeyValue(e))
+		}
+		for _, l := range rn.locks.Leases() {
+			snap.Locks = append(snap.Locks, &proto.LockLease{Name: l.Name, Owner: l.Owner, Token: l.Token, ExpiresAtMs: l.ExpiresAt})
+		}
+		if err := rn.send(stream, &proto.WatchEvent{Snapshot: snap}); err != nil {
+			return err
+		}
+		next = snap.Index + 1
+	}
+
+	for {
+		if rn.dead {
+			return status.Error(codes.Unavailable, "node stopped")
+		}
+		for next <= rn.commitIndex {
+			if next < rn.firstIndex() {
+				// Log bị compact qua vị trí stream trong lúc gửi: client cần
+				// watch lại từ snapshot
+				return status.Errorf(codes.OutOfRange, "index %d compacted", next)
+			}
+			// Entry đã commit không bao giờ bị ghi đè nên có thể gửi ngoài khoá
+			if err := rn.send(stream, &proto.WatchEvent{Entry: rn.logs[next-rn.firstIndex()]}); err != nil {
+				return err
+			}
+			next++
+		}
+		if err := rn.wait(stream.Context()); err != nil {
+			return nil
+		}
+	}
+}
+
+// firstIndex là index của entry đầu còn giữ trong log.
+func (rn *RaftNode) firstIndex() int64 {
+	if len(rn.logs) == 0 {
+		return rn.commitIndex + 1
+	}
+	return rn.logs[0].Index
+}
+
+// send gửi event khi tạm nhả mu để stream chậm không chặn node.
+func (rn *RaftNode) send(stream grpc.ServerStreamingServer[proto.WatchEvent], ev *proto.WatchEvent) error {
+	rn.mu.Unlock()
+	defer rn.mu.Lock()
+	return stream.Send(ev)
+}
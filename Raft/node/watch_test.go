@@ -0,0 +1,144 @@
+package main
+
+import (
+	"consensus/Raft/kv"
+	"consensus/common/proto"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func (c *testCluster) watch(ctx context.Context, id int32, from int64) grpc.ServerStreamingClient[proto.WatchEvent] {
+	c.t.Helper()
+	conn, err := grpc.NewClient(c.peers[id], grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { conn.Close() })
+	stream, err := proto.NewConsensusServiceClient(conn).WatchCommitted(ctx, &proto.WatchArgs{FromIndex: from})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return stream
+}
+
+// recvPuts đọc tới khi gặp đủ n lệnh put, bỏ qua no-op và lệnh khác.
+func recvPuts(t *testing.T, stream grpc.ServerStreamingClient[proto.WatchEvent], n int) []*proto.LogEntry {
+	t.Helper()
+	var puts []*proto.LogEntry
+	for len(puts) < n {
+		ev, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if ev.Entry == nil {
+			t.Fatalf("unexpected event %v", ev)
+		}
+		if ev.Entry.Command != "" {
+			puts = append(puts, ev.Entry)
+		}
+	}
+	return puts
+}
+
+func TestWatchCommittedStreamsAndResumes(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	follower := (leader + 1) % 5
+
+	stream := c.watch(ctx, follower, 0)
+	for _, k := range []string{"a", "b", "c"} {
+		c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: k, Value: k})
+	}
+	got := recvPuts(t, stream, 3)
+	for i := 1; i < len(got); i++ {
+		if got[i].Index <= got[i-1].Index {
+			t.Fatalf("entries out of order: %v", got)
+		}
+	}
+
+	// Resume trên node khác từ sau entry đầu tiên đã nhận
+	resumed := recvPuts(t, c.watch(ctx, (leader+2)%5, got[0].Index+1), 2)
+	if resumed[0].Index != got[1].Index || resumed[1].Command != got[2].Command {
+		t.Fatalf("resume got %v, want %v", resumed, got[1:])
+	}
+}
+
+func TestWatchCommittedSnapshotThenTail(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "x", Value: "1"})
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "y", Value: "2"})
+
+	stream := c.watch(ctx, leader, -1)
+	ev, err := stream.Recv()
+	if err != nil || ev.Snapshot == nil || len(ev.Snapshot.Kvs) != 2 || ev.Snapshot.Kvs[1].Value != "2" {
+		t.Fatalf("snapshot = %v %v", ev, err)
+	}
+	c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: "z", Value: "3"})
+	tail := recvPuts(t, stream, 1)
+	if tail[0].Index <= ev.Snapshot.Index {
+		t.Fatalf("tail entry %d not after snapshot %d", tail[0].Index, ev.Snapshot.Index)
+	}
+}
+
+func TestWatchCommittedSnapshotIncludesLocks(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	a, err := c.lockClient(leader).Acquire(ctx, &proto.LockArgs{Name: "job", Owner: "worker-a", TtlMs: 60000})
+	if err != nil || !a.Success {
+		t.Fatalf("acquire: %v %v", a, err)
+	}
+
+	ev, err := c.watch(ctx, leader, -1).Recv()
+	if err != nil || ev.Snapshot == nil || len(ev.Snapshot.Locks) != 1 {
+		t.Fatalf("snapshot = %v %v", ev, err)
+	}
+	if l := ev.Snapshot.Locks[0]; l.Name != "job" || l.Owner != "worker-a" || l.Token != a.Token || l.ExpiresAtMs != a.ExpiresAtMs {
+		t.Fatalf("snapshot lock = %v, acquired %v", l, a)
+	}
+}
+
+func TestWatchCommittedBelowFirstIndexSendsSnapshot(t *testing.T) {
+	c := newTestCluster(t)
+	leader := c.waitLeader(3 * time.Second)
+	ctx := kvCtx(t)
+	for _, k := range []string{"x", "y", "z"} {
+		c.kv(leader).Put(ctx, &proto.KVPutArgs{Key: k, Value: k})
+	}
+	follower := (leader + 1) % 5
+	if !c.waitCommitted(follower, kv.Command{Op: kv.OpPut, Key: "z", Value: "z"}.Encode(), 3*time.Second) {
+		t.Fatal("follower did not commit z")
+	}
+
+	// Cô lập follower rồi giả lập compact: bỏ hai entry đầu khỏi log
+	var others []int32
+	for id := int32(0); id < 5; id++ {
+		if id != follower {
+			others = append(others, id)
+		}
+	}
+	c.partition([]int32{follower}, others)
+	rn := c.nodes[follower]
+	rn.mu.Lock()
+	rn.logs = rn.logs[2:]
+	rn.saved -= 2
+	first := rn.logs[0].Index
+	rn.mu.Unlock()
+
+	ev, err := c.watch(ctx, follower, first-1).Recv()
+	if err != nil || ev.Snapshot == nil || len(ev.Snapshot.Kvs) != 3 || ev.Snapshot.Index < first {
+		t.Fatalf("watch below first index %d = %v %v", first, ev, err)
+	}
+	// Từ đúng entry đầu còn giữ: đọc theo offset, không lệch index
+	ev, err = c.watch(ctx, follower, first).Recv()
+	if err != nil || ev.Entry == nil || ev.Entry.Index != first {
+		t.Fatalf("watch from first index %d = %v %v", first, ev, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package shard là state machine của shard map: danh sách khoảng key liên
+// tiếp phủ toàn bộ keyspace, mỗi khoảng thuộc một nhóm Raft. Map nằm trong
+// log của nhóm metadata (nhóm 0); mọi node apply cùng thứ tự nên cùng map.
+package shard
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	OpSplit = "shard_split"
+	OpMerge = "shard_merge"
+)
+
+// Handles cho biết command có thuộc shard map không.
+func Handles(op string) bool {
+	return strings.HasPrefix(op, "shard_")
+}
+
+// Command mô tả thay đổi map. Start/End/From là range mà người đề xuất đã
+// thấy; map đã đổi khác (đề xuất dựa trên map cũ) thì lệnh bị từ chối.
+//
+// Split: range [Start, End) của nhóm From tách tại Key, [Key, End) chuyển sang Group.
+// Merge: [Start, Key) của nhóm Group và [Key, End) của nhóm From gộp vào Group.
+type Command struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	From  int32  `json:"from"`
+	Group int32  `json:"group"`
+}
+
+func (c Command) Encode() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+type Range struct {
+	Start string
+	End   string // Rỗng = tới cuối keyspace
+	Group int32
+}
+
+// Contains: key thuộc [Start, End).
+func (r Range) Contains(key string) bool {
+	return key >= r.Start && (r.End == "" || key < r.End)
+}
+
+type Result struct {
+	OK     bool
+	Err    string
+	Ranges []Range // Map sau khi apply
+}
+
+// Map không tự khoá: RaftNode gọi khi đang giữ mu.
+type Map struct {
+	ranges  []Range
+	version int64
+}
+
+// NewMap: ban đầu cả keyspace thuộc nhóm 0.
+func NewMap() *Map {
+	return &Map{ranges: []Range{{Group: 0}}}
+}
+
+func (m *Map) Apply(index int64, command string) Result {
+	var c Command
+	if json.Unmarshal([]byte(command), &c) != nil {
+		return Result{Err: "bad command"}
+	}
+	switch c.Op {
+	case OpSplit:
+		i := m.find(c.Key)
+		r := m.ranges[i]
+		if r.Start != c.Start || r.End != c.End || r.Group != c.From {
+			return Result{Err: "stale map"}
+		}
+		if c.Key == r.Start {
+			return Result{Err: "split at range start"}
+		}
+		left, right := Range{Start: r.Start, End: c.Key, Group: r.Group}, Range{Start: c.Key, End: r.End, Group: c.Group}
+		m.ranges = append(m.ranges[:i], append([]Range{left, right}, m.ranges[i+1:]...)...)
+	case OpMerge:
+		i := m.find(c.Key)
+		if i == 0 || m.ranges[i].Start != c.Key {
+			return Result{Err: "no range boundary at key"}
+		}
+		left, right := m.ranges[i-1], m.ranges[i]
+		if left.Start != c.Start || left.Group != c.Group || right.End != c.End || right.Group != c.From {
+			return Result{Err: "stale map"}
+		}
+		m.ranges[i-1].End = right.End
+		m.ranges = append(m.ranges[:i], m.ranges[i+1:]...)
+	default:
+		return Result{Err: "unknown op " + c.Op}
+	}
+	m.version = index
+	return Result{OK: true, Ranges: m.Ranges()}
+}
+
+// find trả về vị trí range chứa key.
+func (m *Map) find(key string) int {
+	for i, r := range m.ranges {
+		if r.Contains(key) {
+			return i
+		}
+	}
+	return len(m.ranges) - 1
+}
+
+func (m *Map) Lookup(key string) Range {
+	return m.ranges[m.find(key)]
+}
+
+// Ranges trả về bản sao map theo thứ tự key.
+func (m *Map) Ranges() []Range {
+	return append([]Range(nil), m.ranges...)
+}
+
+// Version là index của entry metadata thay đổi map lần cuối (0 = map ban đầu).
+func (m *Map) Version() int64 {
+	return m.version
+}
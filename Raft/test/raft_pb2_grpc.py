@@ -1,269 +0,0 @@
-# Generated by the gRPC Python protocol compiler plugin. DO NOT EDIT!
-"""Client and server classes corresponding to protobuf-defined services."""
-import grpc
-import warnings
-
-import raft_pb2 as raft__pb2
-
-GRPC_GENERATED_VERSION = '1.76.0'
-GRPC_VERSION = grpc.__version__
-_version_not_supported = False
-
-try:
-    from grpc._utilities import first_version_is_lower
-    _version_not_supported = first_version_is_lower(GRPC_VERSION, GRPC_GENERATED_VERSION)
-except ImportError:
-    _version_not_supported = True
-
-if _version_not_supported:
-    raise RuntimeError(
-        f'The grpc package installed is at version {GRPC_VERSION},'
-        + ' but the generated code in raft_pb2_grpc.py depends on'
-        + f' grpcio>={GRPC_GENERATED_VERSION}.'
-        + f' Please upgrade your grpc module to grpcio>={GRPC_GENERATED_VERSION}'
-        + f' or downgrade your generated code using grpcio-tools<={GRPC_VERSION}.'
-    )
-
-
-class RaftServiceStub(object):
-    """Missing associated documentation comment in .proto file."""
-
-    def __init__(self, channel):
-        """Constructor.
-
-        Args:
-            channel: A grpc.Channel.
-        """
-        self.RequestVote = channel.unary_unary(
-                '/raft.RaftService/RequestVote',
-                request_serializer=raft__pb2.RequestVoteArgs.SerializeToString,
-                response_deserializer=raft__pb2.RequestVoteReply.FromString,
-                _registered_method=True)
-        self.AppendEntries = channel.unary_unary(
-                '/raft.RaftService/AppendEntries',
-                request_serializer=raft__pb2.AppendEntriesArgs.SerializeToString,
-                response_deserializer=raft__pb2.AppendEntriesReply.FromString,
-                _registered_method=True)
-        self.SetNetworkPartition = channel.unary_unary(
-                '/raft.RaftService/SetNetworkPartition',
-                request_serializer=raft__pb2.PartitionArgs.SerializeToString,
-                response_deserializer=raft__pb2.PartitionReply.FromString,
-                _registered_method=True)
-        self.GetStatus = channel.unary_unary(
-                '/raft.RaftService/GetStatus',
-                request_serializer=raft__pb2.Empty.SerializeToString,
-                response_deserializer=raft__pb2.StatusReply.FromString,
-                _registered_method=True)
-        self.Propose = channel.unary_unary(
-                '/raft.RaftService/Propose',
-                request_serializer=raft__pb2.ProposeArgs.SerializeToString,
-                response_deserializer=raft__pb2.ProposeReply.FromString,
-                _registered_method=True)
-
-
-class RaftServiceServicer(object):
-    """Missing associated documentation comment in .proto file."""
-
-    def RequestVote(self, request, context):
-        """Missing associated documentation comment in .proto file."""
-        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
-        context.set_details('Method not implemented!')
-        raise NotImplementedError('Method not implemented!')
-
-    def AppendEntries(self, request, context):
-        """Missing associated documentation comment in .proto file."""
-        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
-        context.set_details('Method not implemented!')
-        raise NotImplementedError('Method not implemented!')
-
-    def SetNetworkPartition(self, request, context):
-        """Missing associated documentation comment in .proto file."""
-        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
-        context.set_details('Method not implemented!')
-        raise NotImplementedError('Method not implemented!')
-
-    def GetStatus(self, request, context):
-        """Missing associated documentation comment in .proto file."""
-        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
-        context.set_details('Method not implemented!')
-        raise NotImplementedError('Method not implemented!')
-
-    def Propose(self, request, context):
-        """Missing associated documentation comment in .proto file."""
-        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
-        context.set_details('Method not implemented!')
-        raise NotImplementedError('Method not implemented!')
-
-
-def add_RaftServiceServicer_to_server(servicer, server):
-    rpc_method_handlers = {
-            'RequestVote': grpc.unary_unary_rpc_method_handler(
-                    servicer.RequestVote,
-                    request_deserializer=raft__pb2.RequestVoteArgs.FromString,
-                    response_serializer=raft__pb2.RequestVoteReply.SerializeToString,
-            ),
-            'AppendEntries': grpc.unary_unary_rpc_method_handler(
-                    servicer.AppendEntries,
-                    request_deserializer=raft__pb2.AppendEntriesArgs.FromString,
-                    response_serializer=raft__pb2.AppendEntriesReply.SerializeToString,
-            ),
-            'SetNetworkPartition': grpc.unary_unary_rpc_method_handler(
-                    servicer.SetNetworkPartition,
-                    request_deserializer=raft__pb2.PartitionArgs.FromString,
-                    response_serializer=raft__pb2.PartitionReply.SerializeToString,
-            ),
-            'GetStatus': grpc.unary_unary_rpc_method_handler(
-                    servicer.GetStatus,
-                    request_deserializer=raft__pb2.Empty.FromString,
-                    response_serializer=raft__pb2.StatusReply.SerializeToString,
-            ),
-            'Propose': grpc.unary_unary_rpc_method_handler(
-                    servicer.Propose,
-                    request_deserializer=raft__pb2.ProposeArgs.FromString,
-                    response_serializer=raft__pb2.ProposeReply.SerializeToString,
-            ),
-    }
-    generic_handler = grpc.method_handlers_generic_handler(
-            'raft.RaftService', rpc_method_handlers)
-    server.add_generic_rpc_handlers((generic_handler,))
-    server.add_registered_method_handlers('raft.RaftService', rpc_method_handlers)
-
-
- # This class is part of an EXPERIMENTAL API.
-class RaftService(object):
-    """Missing associated documentation comment in .proto file."""
-
-    @staticmethod
-    def RequestVote(request,
-            target,
-            options=(),
-            channel_credentials=None,
-            call_credentials=None,
-            insecure=False,
-            compression=None,
-            wait_for_ready=None,
-            timeout=None,
-            metadata=None):
-        return grpc.experimental.unary_unary(
-            request,
-            target,
-            '/raft.RaftService/RequestVote',
-            raft__pb2.RequestVoteArgs.SerializeToString,
-            raft__pb2.RequestVoteReply.FromString,
-            options,
-            channel_credentials,
-            insecure,
-            call_credentials,
-            compression,
-            wait_for_ready,
-            timeout,
-            metadata,
-            _registered_method=True)
-
-    @staticmethod
-    def AppendEntries(request,
-            target,
-            options=(),
-            channel_credentials=None,
-            call_credentials=None,
-            insecure=False,
-            compression=None,
-            wait_for_ready=None,
-            timeout=None,
-            metadata=None):
-        return grpc.experimental.unary_unary(
-            request,
-            target,
-            '/raft.RaftService/AppendEntries',
-            raft__pb2.AppendEntriesArgs.SerializeToString,
-            raft__pb2.AppendEntriesReply.FromString,
-            options,
-            channel_credentials,
-            insecure,
-            call_credentials,
-            compression,
-            wait_for_ready,
-            timeout,
-            metadata,
-            _registered_method=True)
-
-    @staticmethod
-    def SetNetworkPartition(request,
-            target,
-            options=(),
-            channel_credentials=None,
-            call_credentials=None,
-            insecure=False,
-            compression=None,
-            wait_for_ready=None,
-            timeout=None,
-            metadata=None):
-        return grpc.experimental.unary_unary(
-            request,
-            target,
-            '/raft.RaftService/SetNetworkPartition',
-            raft__pb2.PartitionArgs.SerializeToString,
-            raft__pb2.PartitionReply.FromString,
-            options,
-            channel_credentials,
-            insecure,
-            call_credentials,
-            compression,
-            wait_for_ready,
-            timeout,
-            metadata,
-            _registered_method=True)
-
-    @staticmethod
-    def GetStatus(request,
-            target,
-            options=(),
-            channel_credentials=None,
-            call_credentials=None,
-            insecure=False,
-            compression=None,
-            wait_for_ready=None,
-            timeout=None,
-            metadata=None):
-        return grpc.experimental.unary_unary(
-            request,
-            target,
-            '/raft.RaftService/GetStatus',
-            raft__pb2.Empty.SerializeToString,
-            raft__pb2.StatusReply.FromString,
-            options,
-            channel_credentials,
-            insecure,
-            call_credentials,
-            compression,
-            wait_for_ready,
-            timeout,
-            metadata,
-            _registered_method=True)
-
-    @staticmethod
-    def Propose(request,
-            target,
-            options=(),
-            channel_credentials=None,
-            call_credentials=None,
-            insecure=False,
-            compression=None,
-            wait_for_ready=None,
-            timeout=None,
-            metadata=None):
-        return grpc.experimental.unary_unary(
-            request,
-            target,
-            '/raft.RaftService/Propose',
-            raft__pb2.ProposeArgs.SerializeToString,
-            raft__pb2.ProposeReply.FromString,
-            options,
-            channel_credentials,
-            insecure,
-            call_credentials,
-            compression,
-            wait_for_ready,
-            timeout,
-            metadata,
-            _registered_method=True)
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"consensus/chaos/runner"
+)
+
+// Chạy từ thư mục gốc repo:
+//
+//	go run ./chaos -scenario chaos/scenarios
+//	go run ./chaos -scenario chaos/scenarios/raft-partition-heal.json -report report.json
+//	go run ./chaos -monkey -algorithm pbft -seed 42 -duration 5m
+func main() {
+	scenarioPath := flag.String("scenario", "chaos/scenarios", "scenario JSON file or directory")
+	reportPath := flag.String("report", "", "write JSON report to this file")
+	raftBin := flag.String("raft-bin", "", "prebuilt Raft node binary (default: go build ./Raft/node)")
+	pbftBin := flag.String("pbft-bin", "", "prebuilt pBFT node binary (default: go build ./pBFT)")
+	verbose := flag.Bool("v", false, "forward node output to stdout")
+
+	monkeyMode := flag.Bool("monkey", false, "run randomized chaos monkey instead of scenarios")
+	algorithm := flag.String("algorithm", runner.Raft, "monkey: raft | pbft")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "monkey: random seed (print to reproduce)")
+	duration := flag.Duration("duration", time.Minute, "monkey: how long to inject faults")
+	interval := flag.Duration("interval", 2*time.Second, "monkey: time between faults")
+	maxFaulty := flag.Int("max-faulty", -1, "monkey: max simultaneously faulty nodes (default: f for the algorithm)")
+	flag.Parse()
+
+	if *monkeyMode {
+		runMonkey(*algorithm, *raftBin, *pbftBin, *verbose, *reportPath, runner.MonkeyConfig{
+			Seed: *seed, Duration: *duration, Interval: *interval, MaxFaulty: *maxFaulty, Settle: 5 * time.Second,
+		})
+		return
+	}
+
+	files, err := scenarioFiles(*scenarioPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmp, err := os.MkdirTemp("", "chaos-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	bins := map[string]*string{runner.Raft: raftBin, runner.PBFT: pbftBin}
+	var out *os.File
+	if *verbose {
+		out = os.Stdout
+	}
+
+	var reports []*runner.Report
+	passed := true
+	for i, f := range files {
+		sc, err := runner.LoadScenario(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bin := bins[sc.Algorithm]
+		if *bin == "" {
+			*bin = buildNode(sc.Algorithm, tmp)
+		}
+		topology := ""
+		if sc.Netem != "" {
+			topology, _ = filepath.Abs(sc.Netem)
+		}
+		// Mỗi scenario chạy trong thư mục riêng để storage Raft bắt đầu sạch
+		dir := filepath.Join(tmp, fmt.Sprintf("run-%d", i))
+		os.MkdirAll(dir, 0755)
+		cluster, err := runner.NewCluster(sc.Algorithm, *bin, dir, topology, out)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("=== %s (%s)\n", sc.Name, sc.Algorithm)
+		rep := runner.Run(context.Background(), sc, cluster)
+		cluster.Close()
+		rep.Print(os.Stdout)
+		reports = append(reports, rep)
+		passed = passed && rep.Passed
+	}
+
+	if *reportPath != "" {
+		data, _ := json.MarshalIndent(reports, "", "  ")
+		if err := os.WriteFile(*reportPath, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+func buildNode(algorithm, dir string) string {
+	pkgs := map[string]string{runner.Raft: "./Raft/node", runner.PBFT: "./pBFT"}
+	bin := filepath.Join(dir, algorithm+"_node")
+	if b, err := exec.Command("go", "build", "-o", bin, pkgs[algorithm]).CombinedOutput(); err != nil {
+		log.Fatalf("build %s: %v\n%s", algorithm, err, b)
+	}
+	return bin
+}
+
+func runMonkey(algorithm, raftBin, pbftBin string, verbose bool, reportPath string, cfg runner.MonkeyConfig) {
+	tmp, err := os.MkdirTemp("", "chaos-monkey-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := map[string]string{runner.Raft: raftBin, runner.PBFT: pbftBin}[algorithm]
+	if bin == "" {
+		bin = buildNode(algorithm, tmp)
+	}
+	if cfg.MaxFaulty < 0 {
+		cfg.MaxFaulty = runner.DefaultMaxFaulty(algorithm)
+	}
+	var out *os.File
+	if verbose {
+		out = os.Stdout
+	}
+	cluster, err := runner.NewCluster(algorithm, bin, tmp, "", out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("=== chaos monkey (%s) seed=%d duration=%v interval=%v max-faulty=%d\n", algorithm, cfg.Seed, cfg.Duration, cfg.Interval, cfg.MaxFaulty)
+	rep := runner.RunMonkey(context.Background(), cluster, cfg)
+	cluster.Close()
+	rep.Print(os.Stdout)
+
+	if reportPath != "" {
+		data, _ := json.MarshalIndent(rep, "", "  ")
+		if err := os.WriteFile(reportPath, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !rep.Passed {
+		os.Exit(1)
+	}
+}
+
+func scenarioFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,347 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"consensus/common/procman"
+	"consensus/common/proto"
+)
+
+const (
+	Raft = "raft"
+	PBFT = "pbft"
+
+	// Cả hai thuật toán dùng gRPC port 50050+id (Raft: 0..4, pBFT: node1..node5)
+	basePort     = 50050
+	pbftHTTPBase = 60050
+)
+
+// processes là phần của procman.Manager mà Cluster dùng (test thay bằng bản giả).
+type processes interface {
+	Start(id int) error
+	Stop(id int)
+	IsRunning(id int) bool
+	StopAll()
+}
+
+// Cluster điều khiển 5 node của một thuật toán: tiến trình, mạng và RPC.
+type Cluster struct {
+	Algorithm string
+	Nodes     []int32
+
+	procs     processes
+	dial      func(id int32) (proto.ConsensusServiceClient, error)
+	http      http.Client
+	mu        sync.Mutex
+	conns     map[int32]*grpc.ClientConn
+	malicious map[int32]bool
+}
+
+// NewCluster chuẩn bị cluster; binary là file thực thi node đã build,
+// dir là thư mục làm việc (Raft ghi logs/ vào đây), topology có thể rỗng.
+func NewCluster(algorithm, binary, dir, topology string, out *os.File) (*Cluster, error) {
+	c := &Cluster{
+		Algorithm: algorithm,
+		http:      http.Client{Timeout: 500 * time.Millisecond},
+		conns:     make(map[int32]*grpc.ClientConn),
+		malicious: make(map[int32]bool),
+	}
+	c.dial = c.grpcClient
+	var args func(id int) []string
+	switch algorithm {
+	case Raft:
+		c.Nodes = []int32{0, 1, 2, 3, 4}
+		args = func(id int) []string { return []string{"-id", strconv.Itoa(id)} }
+	case PBFT:
+		c.Nodes = []int32{1, 2, 3, 4, 5}
+		args = func(id int) []string {
+			return []string{"-id", fmt.Sprintf("node%d", id), "-port", strconv.Itoa(basePort + id)}
+		}
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	if topology != "" {
+		base := args
+		args = func(id int) []string { return append(base(id), "-netem", topology) }
+	}
+	c.procs = &procman.Manager{Binary: binary, Dir: dir, Args: args, Stdout: out}
+	return c, nil
+}
+
+func (c *Cluster) client(id int32) (proto.ConsensusServiceClient, error) {
+	return c.dial(id)
+}
+
+func (c *Cluster) grpcClient(id int32) (proto.ConsensusServiceClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, ok := c.conns[id]
+	if !ok {
+		var err error
+		// Node bị kill/restart liên tục: reconnect nhanh và chờ sẵn sàng trong hạn của từng RPC
+		conn, err = grpc.NewClient(fmt.Sprintf("localhost:%d", basePort+int(id)),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.Config{BaseDelay: 50 * time.Millisecond, Multiplier: 1.6, MaxDelay: 500 * time.Millisecond}}),
+			grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
+		if err != nil {
+			return nil, err
+		}
+		c.conns[id] = conn
+	}
+	return proto.NewConsensusServiceClient(conn), nil
+}
+
+func (c *Cluster) Start(id int32) error {
+	c.mu.Lock()
+	c.malicious[id] = false
+	c.mu.Unlock()
+	return c.procs.Start(int(id))
+}
+
+func (c *Cluster) Stop(id int32) {
+	c.procs.Stop(int(id))
+}
+
+func (c *Cluster) IsRunning(id int32) bool {
+	return c.procs.IsRunning(int(id))
+}
+
+// Honest trả về các node đang chạy và không bị đánh dấu malicious.
+func (c *Cluster) Honest() []int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []int32
+	for _, id := range c.Nodes {
+		if c.procs.IsRunning(int(id)) && !c.malicious[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (c *Cluster) Close() {
+	c.procs.StopAll()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
+
+func (c *Cluster) Status(ctx context.Context, id int32) (*proto.StatusReply, error) {
+	cl, err := c.client(id)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	return cl.GetStatus(ctx, &proto.Empty{})
+}
+
+func (c *Cluster) Ledger(ctx context.Context, id int32) ([]*proto.LedgerEntry, error) {
+	cl, err := c.client(id)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	reply, err := cl.GetLedger(ctx, &proto.LedgerArgs{})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Entries, nil
+}
+
+// Leader trả về Leader (Raft) hoặc Primary (pBFT) hiện hành: node ở trạng thái
+// lãnh đạo mà term/view của nó được đa số (Raft) hoặc 2f+1 (pBFT) node chia sẻ.
+// Với N = 5 cả hai ngưỡng đều là 3.
+func (c *Cluster) Leader(ctx context.Context) (int32, bool) {
+	statuses := make(map[int32]*proto.StatusReply)
+	for _, id := range c.Honest() {
+		if st, err := c.Status(ctx, id); err == nil {
+			statuses[id] = st
+		}
+	}
+	leaderState := map[string]string{Raft: "Leader", PBFT: "Primary"}[c.Algorithm]
+	for id, st := range statuses {
+		if st.State != leaderState {
+			continue
+		}
+		agree := 0
+		for _, other := range statuses {
+			if other.Term == st.Term {
+				agree++
+			}
+		}
+		if agree >= len(c.Nodes)/2+1 {
+			return id, true
+		}
+	}
+	return -1, false
+}
+
+// Propose gửi một lệnh tới Leader/Primary, chờ tới khi nó được commit
+// và trả về entry đã commit (đọc từ ledger của Leader).
+func (c *Cluster) Propose(ctx context.Context, command string) (*proto.LedgerEntry, error) {
+	for {
+		if e, err := c.proposeOnce(ctx, command); err == nil {
+			return e, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("propose %q: %w", command, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Cluster) proposeOnce(ctx context.Context, command string) (*proto.LedgerEntry, error) {
+	leader, ok := c.Leader(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no leader")
+	}
+	before, err := c.Status(ctx, leader)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Algorithm {
+	case Raft:
+		cl, err := c.client(leader)
+		if err != nil {
+			return nil, err
+		}
+		rctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		reply, err := cl.Propose(rctx, &proto.ProposeArgs{Command: command})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if !reply.Success {
+			return nil, fmt.Errorf("node %d rejected proposal", leader)
+		}
+	case PBFT:
+		resp, err := c.http.Post(fmt.Sprintf("http://localhost:%d/start", pbftHTTPBase+int(leader)), "application/json", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("node %d rejected proposal: %s", leader, resp.Status)
+		}
+	}
+	if err := c.waitCommitted(ctx, leader, before.Committed+1); err != nil {
+		return nil, err
+	}
+	entries, err := c.Ledger(ctx, leader)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		// Raft: tìm theo command; pBFT: block ngay sau mốc trước khi propose
+		if (c.Algorithm == Raft && e.Data == command) || (c.Algorithm == PBFT && e.Index == before.Committed+1) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("node %d committed but entry not found in ledger", leader)
+}
+
+func (c *Cluster) waitCommitted(ctx context.Context, id int32, n int64) error {
+	for {
+		if st, err := c.Status(ctx, id); err == nil && st.Committed >= n {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node %d did not commit entry %d: %w", id, n, ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Partition chặn mọi link giữa các nhóm khác nhau (qua SetLinkFaults).
+// Node không thuộc nhóm nào bị cô lập hoàn toàn.
+func (c *Cluster) Partition(ctx context.Context, groups [][]int32) error {
+	group := make(map[int32]int)
+	for i, g := range groups {
+		for _, id := range g {
+			group[id] = i + 1
+		}
+	}
+	for _, from := range c.Nodes {
+		var links []*proto.LinkFault
+		for _, to := range c.Nodes {
+			if to != from && (group[from] == 0 || group[from] != group[to]) {
+				links = append(links, &proto.LinkFault{ToNode: to, Blocked: true})
+			}
+		}
+		if err := c.SetLinkFaults(ctx, from, links); err != nil && c.IsRunning(from) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) Heal(ctx context.Context) error {
+	for _, id := range c.Nodes {
+		if err := c.SetLinkFaults(ctx, id, nil); err != nil && c.IsRunning(id) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetNetworkPartition gọi RPC partition phía nhận có sẵn trên node `id`.
+func (c *Cluster) SetNetworkPartition(ctx context.Context, id int32, isolated []int32) error {
+	cl, err := c.client(id)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = cl.SetNetworkPartition(ctx, &proto.PartitionArgs{IsolatedNodeIds: isolated})
+	return err
+}
+
+func (c *Cluster) SetLinkFaults(ctx context.Context, id int32, links []*proto.LinkFault) error {
+	cl, err := c.client(id)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = cl.SetLinkFaults(ctx, &proto.LinkFaultArgs{Links: links})
+	return err
+}
+
+// SetMalicious chỉ có ý nghĩa với pBFT (HTTP /config của node).
+func (c *Cluster) SetMalicious(id int32, malicious bool) error {
+	if c.Algorithm != PBFT {
+		return fmt.Errorf("%s has no malicious mode", c.Algorithm)
+	}
+	action := "honest"
+	if malicious {
+		action = "malicious"
+	}
+	body, _ := json.Marshal(map[string]string{"action": action})
+	resp, err := c.http.Post(fmt.Sprintf("http://localhost:%d/config", pbftHTTPBase+int(id)), "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	c.mu.Lock()
+	c.malicious[id] = malicious
+	c.mu.Unlock()
+	return nil
+}
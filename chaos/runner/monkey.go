@@ -0,0 +1,365 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"consensus/common/proto"
+)
+
+// MonkeyConfig điều khiển chaos monkey. Cùng Seed sẽ sinh cùng chuỗi lỗi
+// (thời điểm thực thi vẫn phụ thuộc scheduler, nhưng quyết định thì lặp lại được).
+type MonkeyConfig struct {
+	Seed      int64
+	Duration  time.Duration // Thời gian gây lỗi
+	Interval  time.Duration // Khoảng cách giữa hai lần gây lỗi
+	MaxFaulty int           // Số node lỗi tối đa cùng lúc (kill + bị cô lập + malicious)
+	Settle    time.Duration // Thời gian chờ cluster hội tụ sau khi dọn lỗi
+}
+
+type MonkeyReport struct {
+	Algorithm string        `json:"algorithm"`
+	Seed      int64         `json:"seed"`
+	Passed    bool          `json:"passed"`
+	Events    []string      `json:"events"`
+	Proposed  int           `json:"proposed"`
+	Acked     int           `json:"acked"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+type monkey struct {
+	c   *Cluster
+	cfg MonkeyConfig
+	rng *rand.Rand
+	rep *MonkeyReport
+
+	down      map[int32]bool
+	isolated  map[int32]bool // Nhóm thiểu số đang bị partition
+	malicious map[int32]bool
+	start     time.Time
+}
+
+// DefaultMaxFaulty: Raft chịu được ⌊(N-1)/2⌋ node crash, pBFT chịu được f node Byzantine.
+func DefaultMaxFaulty(algorithm string) int {
+	if algorithm == PBFT {
+		return 1
+	}
+	return 2
+}
+
+// RunMonkey khởi động cluster, chạy workload propose liên tục trong khi
+// ngẫu nhiên kill/restart node, partition (SetNetworkPartition) và bật
+// malicious (pBFT). Cuối cùng dọn lỗi, chờ hội tụ và kiểm tra mọi entry
+// đã được xác nhận commit vẫn còn nguyên và các ledger không phân kỳ.
+func RunMonkey(ctx context.Context, c *Cluster, cfg MonkeyConfig) *MonkeyReport {
+	m := newMonkey(c, cfg)
+	defer func() { m.rep.Duration = time.Since(m.start) }()
+
+	for _, id := range c.Nodes {
+		if err := c.Start(id); err != nil {
+			return m.fail(err)
+		}
+	}
+	if _, err := waitLeader(ctx, c, 15*time.Second); err != nil {
+		return m.fail(err)
+	}
+
+	// Workload: propose liên tục, ghi nhận các entry Leader xác nhận đã commit
+	wctx, stopWorkload := context.WithCancel(ctx)
+	var acked []*proto.LedgerEntry
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; wctx.Err() == nil; n++ {
+			cmd, _ := json.Marshal(map[string]interface{}{"monkey": cfg.Seed, "n": n})
+			pctx, cancel := context.WithTimeout(wctx, 3*time.Second)
+			e, err := c.Propose(pctx, string(cmd))
+			cancel()
+			m.rep.Proposed++
+			if err == nil {
+				acked = append(acked, e)
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(cfg.Duration)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			stopWorkload()
+			wg.Wait()
+			return m.fail(ctx.Err())
+		case <-time.After(cfg.Interval):
+		}
+		m.step(ctx)
+	}
+
+	stopWorkload()
+	wg.Wait()
+	m.rep.Acked = len(acked)
+	if err := m.heal(ctx); err != nil {
+		return m.fail(err)
+	}
+	if err := m.verify(ctx, acked); err != nil {
+		return m.fail(err)
+	}
+	m.rep.Passed = true
+	return m.rep
+}
+
+func newMonkey(c *Cluster, cfg MonkeyConfig) *monkey {
+	return &monkey{
+		c:         c,
+		cfg:       cfg,
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		rep:       &MonkeyReport{Algorithm: c.Algorithm, Seed: cfg.Seed},
+		down:      make(map[int32]bool),
+		isolated:  make(map[int32]bool),
+		malicious: make(map[int32]bool),
+		start:     time.Now(),
+	}
+}
+
+func (m *monkey) fail(err error) *MonkeyReport {
+	m.rep.Error = err.Error()
+	return m.rep
+}
+
+func (m *monkey) logf(format string, args ...interface{}) {
+	m.rep.Events = append(m.rep.Events, fmt.Sprintf("%8v  ", time.Since(m.start).Round(time.Millisecond))+fmt.Sprintf(format, args...))
+}
+
+func (m *monkey) faulty() int {
+	ids := make(map[int32]bool)
+	for _, set := range []map[int32]bool{m.down, m.isolated, m.malicious} {
+		for id, v := range set {
+			if v {
+				ids[id] = true
+			}
+		}
+	}
+	return len(ids)
+}
+
+func (m *monkey) pick(pred func(int32) bool) (int32, bool) {
+	var ids []int32
+	for _, id := range m.c.Nodes {
+		if pred(id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, false
+	}
+	return ids[m.rng.Intn(len(ids))], true
+}
+
+func (m *monkey) healthy(id int32) bool {
+	return !m.down[id] && !m.isolated[id] && !m.malicious[id]
+}
+
+// step chọn ngẫu nhiên một hành động áp dụng được: gây lỗi khi còn dưới
+// ngưỡng MaxFaulty, hoặc phục hồi một lỗi đang tồn tại.
+func (m *monkey) step(ctx context.Context) {
+	var actions []string
+	if budget := m.cfg.MaxFaulty - m.faulty(); budget > 0 {
+		actions = append(actions, "kill")
+		if len(m.isolated) == 0 {
+			actions = append(actions, "partition")
+		}
+		if m.c.Algorithm == PBFT {
+			actions = append(actions, "malicious")
+		}
+	}
+	if len(keys(m.down)) > 0 {
+		actions = append(actions, "restart")
+	}
+	if len(m.isolated) > 0 {
+		actions = append(actions, "heal")
+	}
+	if len(keys(m.malicious)) > 0 {
+		actions = append(actions, "honest")
+	}
+	if len(actions) == 0 {
+		return
+	}
+
+	switch actions[m.rng.Intn(len(actions))] {
+	case "kill":
+		if id, ok := m.pick(m.healthy); ok {
+			m.c.Stop(id)
+			m.down[id] = true
+			m.logf("kill node %d", id)
+		}
+	case "restart":
+		if id, ok := m.pick(func(id int32) bool { return m.down[id] }); ok {
+			if err := m.c.Start(id); err != nil {
+				m.logf("restart node %d failed: %v", id, err)
+				break
+			}
+			m.down[id] = false
+			m.logf("restart node %d", id)
+			// Node mới khởi động có mạng lành: áp lại partition hiện hành
+			if len(m.isolated) > 0 {
+				m.partition(ctx, m.isolated)
+			}
+		}
+	case "partition":
+		// Cô lập một nhóm thiểu số ngẫu nhiên (chỉ gồm node khoẻ) khỏi phần còn lại
+		size := 1 + m.rng.Intn(m.cfg.MaxFaulty-m.faulty())
+		minority := make(map[int32]bool)
+		for len(minority) < size {
+			id, ok := m.pick(func(id int32) bool { return m.healthy(id) && !minority[id] })
+			if !ok {
+				break
+			}
+			minority[id] = true
+		}
+		m.partition(ctx, minority)
+		m.logf("partition %v from the rest", keys(minority))
+	case "heal":
+		m.partition(ctx, nil)
+		m.logf("heal partition")
+	case "malicious":
+		if id, ok := m.pick(m.healthy); ok {
+			m.c.SetMalicious(id, true)
+			m.malicious[id] = true
+			m.logf("node %d becomes malicious", id)
+		}
+	case "honest":
+		if id, ok := m.pick(func(id int32) bool { return m.malicious[id] }); ok {
+			m.c.SetMalicious(id, false)
+			m.malicious[id] = false
+			m.logf("node %d becomes honest", id)
+		}
+	}
+}
+
+// partition cô lập nhóm `minority` (nil = heal) qua SetNetworkPartition trên mọi node.
+func (m *monkey) partition(ctx context.Context, minority map[int32]bool) {
+	isolated := make(map[int32]bool)
+	for _, id := range m.c.Nodes {
+		var blocked []int32
+		for _, other := range m.c.Nodes {
+			if other != id && minority[id] != minority[other] {
+				blocked = append(blocked, other)
+			}
+		}
+		if minority[id] {
+			isolated[id] = true
+		}
+		if !m.down[id] {
+			m.c.SetNetworkPartition(ctx, id, blocked)
+		}
+	}
+	m.isolated = isolated
+}
+
+// heal dọn mọi lỗi; node không khởi động lại được vẫn được coi là down và
+// làm monkey thất bại với chính lỗi đó thay vì lỗi verify khó hiểu.
+func (m *monkey) heal(ctx context.Context) error {
+	m.partition(ctx, nil)
+	var failed error
+	for _, id := range m.c.Nodes {
+		if m.down[id] {
+			if err := m.c.Start(id); err != nil {
+				m.logf("restart node %d failed: %v", id, err)
+				failed = fmt.Errorf("restart node %d: %w", id, err)
+			} else {
+				m.down[id] = false
+			}
+		}
+		if m.malicious[id] {
+			m.c.SetMalicious(id, false)
+			m.malicious[id] = false
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	m.logf("healed: all nodes up, honest and connected")
+	time.Sleep(m.cfg.Settle)
+	return nil
+}
+
+// verify: ledger không phân kỳ và mọi entry đã ack còn nguyên vẹn.
+// Raft lưu log xuống đĩa nên mọi node phải có đủ entry đã ack.
+// pBFT giữ chain trong RAM (node restart mất chain) nên chỉ đòi f+1 node giữ entry.
+func (m *monkey) verify(ctx context.Context, acked []*proto.LedgerEntry) error {
+	if _, err := CheckConsistent(ctx, m.c, m.c.Nodes); err != nil {
+		return err
+	}
+	need := len(m.c.Nodes)
+	if m.c.Algorithm == PBFT {
+		need = 2
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		missing := m.missing(ctx, acked, need)
+		if missing == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("acked entry %d (%q) found on fewer than %d nodes", missing.Index, missing.Data, need)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func (m *monkey) missing(ctx context.Context, acked []*proto.LedgerEntry, need int) *proto.LedgerEntry {
+	count := make(map[int64]int)
+	for _, id := range m.c.Nodes {
+		entries, err := m.c.Ledger(ctx, id)
+		if err != nil {
+			continue
+		}
+		have := make(map[int64]*proto.LedgerEntry)
+		for _, e := range entries {
+			have[e.Index] = e
+		}
+		for _, a := range acked {
+			if e, ok := have[a.Index]; ok && e.Term == a.Term && e.Hash == a.Hash && e.Data == a.Data {
+				count[a.Index]++
+			}
+		}
+	}
+	for _, a := range acked {
+		if count[a.Index] < need {
+			return a
+		}
+	}
+	return nil
+}
+
+// keys trả về các id có giá trị true, đã sắp xếp.
+func keys(set map[int32]bool) []int32 {
+	var ids []int32
+	for id, v := range set {
+		if v {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (r *MonkeyReport) Print(w io.Writer) {
+	for _, e := range r.Events {
+		fmt.Fprintln(w, "  "+e)
+	}
+	if r.Passed {
+		fmt.Fprintf(w, "PASS monkey (%s, seed %d): %d/%d proposals acked, no committed data lost or diverged, in %v\n",
+			r.Algorithm, r.Seed, r.Acked, r.Proposed, r.Duration.Round(time.Millisecond))
+		return
+	}
+	fmt.Fprintf(w, "FAIL monkey (%s, seed %d): %s (%d/%d proposals acked) in %v\n",
+		r.Algorithm, r.Seed, r.Error, r.Acked, r.Proposed, r.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// events bỏ mốc thời gian ở đầu mỗi dòng, chỉ giữ quyết định của monkey.
+func events(m *monkey) []string {
+	var evs []string
+	for _, e := range m.rep.Events {
+		evs = append(evs, strings.Join(strings.Fields(e)[1:], " "))
+	}
+	return evs
+}
+
+func TestMonkeyStep(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	m := newMonkey(c, MonkeyConfig{Seed: 7, MaxFaulty: DefaultMaxFaulty(Raft)})
+	for i := 0; i < 200; i++ {
+		m.step(ctx)
+		if n := m.faulty(); n > m.cfg.MaxFaulty {
+			t.Fatalf("step %d: %d faulty nodes, max %d (%v)", i, n, m.cfg.MaxFaulty, m.rep.Events)
+		}
+		// Trạng thái monkey khớp với cluster
+		for _, id := range c.Nodes {
+			if c.IsRunning(id) == m.down[id] {
+				t.Fatalf("step %d: node %d running=%v but monkey down=%v", i, id, c.IsRunning(id), m.down[id])
+			}
+			if m.down[id] {
+				continue
+			}
+			// Node đang chạy phải chặn đúng các node ở phía bên kia partition
+			var want []int32
+			for _, other := range c.Nodes {
+				if other != id && m.isolated[id] != m.isolated[other] {
+					want = append(want, other)
+				}
+			}
+			if got := f.nodes[id].partition; !slices.Equal(got, want) {
+				t.Fatalf("step %d: node %d blocks %v, want %v", i, id, got, want)
+			}
+		}
+	}
+	for _, kind := range []string{"kill node", "restart node", "partition", "heal partition"} {
+		found := false
+		for _, e := range events(m) {
+			found = found || strings.HasPrefix(e, kind)
+		}
+		if !found {
+			t.Errorf("200 steps never did %q", kind)
+		}
+	}
+
+	// Cùng seed -> cùng chuỗi lỗi
+	c2, _ := newFakeCluster()
+	m2 := newMonkey(c2, MonkeyConfig{Seed: 7, MaxFaulty: DefaultMaxFaulty(Raft)})
+	for i := 0; i < 200; i++ {
+		m2.step(ctx)
+	}
+	if !slices.Equal(events(m), events(m2)) {
+		t.Fatalf("same seed produced different events:\n%v\n%v", events(m), events(m2))
+	}
+
+	if err := m.heal(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if m.faulty() != 0 || len(c.Honest()) != len(c.Nodes) {
+		t.Fatalf("after heal: %d faulty, honest %v", m.faulty(), c.Honest())
+	}
+	for _, id := range c.Nodes {
+		if len(f.nodes[id].partition) != 0 {
+			t.Fatalf("node %d still partitioned from %v", id, f.nodes[id].partition)
+		}
+	}
+}
+
+// Node không khởi động lại được vẫn là down: monkey báo chính lỗi restart.
+func TestMonkeyRestartFailure(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	m := newMonkey(c, MonkeyConfig{Seed: 3, MaxFaulty: 1})
+	m.c.Stop(2)
+	m.down[2] = true
+	f.broken[2] = true
+	for i := 0; i < 50; i++ {
+		m.step(ctx)
+		if m.down[2] == c.IsRunning(2) || !m.down[2] {
+			t.Fatalf("step %d: node 2 down=%v running=%v", i, m.down[2], c.IsRunning(2))
+		}
+	}
+	found := false
+	for _, e := range events(m) {
+		found = found || strings.HasPrefix(e, "restart node 2 failed")
+	}
+	if !found {
+		t.Fatalf("failed restart not logged: %v", events(m))
+	}
+	if err := m.heal(ctx); err == nil || !strings.Contains(err.Error(), "restart node 2") {
+		t.Fatalf("heal with a broken node: got %v", err)
+	}
+	if !m.down[2] {
+		t.Fatal("node 2 marked up after a failed restart")
+	}
+}
+
+func TestMonkeyVerify(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	m := newMonkey(c, MonkeyConfig{Seed: 1})
+	for _, n := range f.nodes {
+		n.ledger = entries("a", "b", "c")
+	}
+	acked := entries("a", "b", "c")[1:]
+	if err := m.verify(ctx, acked); err != nil {
+		t.Fatal(err)
+	}
+
+	// Raft: entry đã ack phải có mặt trên mọi node
+	f.nodes[2].ledger = entries("a", "b")
+	if got := m.missing(ctx, acked, len(c.Nodes)); got == nil || got.Index != 2 {
+		t.Fatalf("missing entry: got %v, want index 2", got)
+	}
+	// pBFT chỉ đòi f+1 node
+	if got := m.missing(ctx, acked, 2); got != nil {
+		t.Fatalf("f+1 copies: got missing %v", got)
+	}
+	// Entry đã ack bị thay nội dung cũng tính là mất
+	f.nodes[2].ledger = entries("a", "b", "c")
+	changed := entries("a", "b", "z")[2:]
+	if got := m.missing(ctx, changed, 1); got == nil {
+		t.Fatal("rewritten entry should be reported missing")
+	}
+
+	f.nodes[4].ledger = entries("a", "x", "c")
+	if err := m.verify(ctx, acked); err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Fatalf("diverged ledgers: got %v", err)
+	}
+}
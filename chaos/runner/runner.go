@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"consensus/common/proto"
+)
+
+const defaultWithin = 5 * time.Second
+
+type StepResult struct {
+	Step     int           `json:"step"`
+	Action   string        `json:"action"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+type Report struct {
+	Scenario  string        `json:"scenario"`
+	Algorithm string        `json:"algorithm"`
+	Passed    bool          `json:"passed"`
+	Steps     []StepResult  `json:"steps"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Run chạy lần lượt các bước của scenario. Bước đầu tiên thất bại
+// đánh rớt scenario; các bước còn lại được ghi nhận là skipped.
+func Run(ctx context.Context, sc *Scenario, c *Cluster) *Report {
+	rep := &Report{Scenario: sc.Name, Algorithm: sc.Algorithm, Passed: true}
+	start := time.Now()
+	for i, st := range sc.Steps {
+		res := StepResult{Step: i + 1, Action: st.Action}
+		if !rep.Passed {
+			res.Skipped = true
+			rep.Steps = append(rep.Steps, res)
+			continue
+		}
+		t := time.Now()
+		detail, err := runStep(ctx, sc, i, st, c)
+		res.Duration, res.Detail = time.Since(t), detail
+		if err != nil {
+			res.Error = err.Error()
+			rep.Passed = false
+		} else {
+			res.Passed = true
+		}
+		rep.Steps = append(rep.Steps, res)
+	}
+	rep.Duration = time.Since(start)
+	return rep
+}
+
+func within(st Step) time.Duration {
+	if st.WithinMs > 0 {
+		return time.Duration(st.WithinMs) * time.Millisecond
+	}
+	return defaultWithin
+}
+
+func nodesOr(ids, def []int32) []int32 {
+	if len(ids) > 0 {
+		return ids
+	}
+	return def
+}
+
+func runStep(ctx context.Context, sc *Scenario, idx int, st Step, c *Cluster) (string, error) {
+	switch st.Action {
+	case "start":
+		for _, id := range nodesOr(st.Nodes, c.Nodes) {
+			if err := c.Start(id); err != nil {
+				return "", err
+			}
+		}
+	case "stop":
+		for _, id := range nodesOr(st.Nodes, c.Nodes) {
+			c.Stop(id)
+		}
+	case "restart":
+		for _, id := range nodesOr(st.Nodes, c.Nodes) {
+			c.Stop(id)
+			if err := c.Start(id); err != nil {
+				return "", err
+			}
+		}
+	case "kill_leader":
+		id, err := waitLeader(ctx, c, within(st))
+		if err != nil {
+			return "", err
+		}
+		c.Stop(id)
+		return fmt.Sprintf("killed node %d", id), nil
+	case "propose":
+		n := max(st.Count, 1)
+		for k := 0; k < n; k++ {
+			cmd, _ := json.Marshal(map[string]interface{}{"scenario": sc.Name, "step": idx + 1, "n": k})
+			pctx, cancel := context.WithTimeout(ctx, within(st))
+			_, err := c.Propose(pctx, string(cmd))
+			cancel()
+			if err != nil {
+				return fmt.Sprintf("%d/%d committed", k, n), err
+			}
+		}
+		return fmt.Sprintf("%d committed", n), nil
+	case "partition":
+		return "", c.Partition(ctx, st.Groups)
+	case "heal":
+		return "", c.Heal(ctx)
+	case "faults":
+		links := make([]*proto.LinkFault, 0, len(st.Links))
+		for _, l := range st.Links {
+			links = append(links, &proto.LinkFault{
+				ToNode: l.To, Blocked: l.Blocked, DropRate: l.DropRate,
+				DelayMs: l.DelayMs, JitterMs: l.JitterMs,
+				DuplicateRate: l.DuplicateRate, ReorderRate: l.ReorderRate, ReorderWindowMs: l.ReorderWindowMs,
+			})
+		}
+		return "", c.SetLinkFaults(ctx, st.Node, links)
+	case "malicious", "honest":
+		for _, id := range st.Nodes {
+			if err := c.SetMalicious(id, st.Action == "malicious"); err != nil {
+				return "", err
+			}
+		}
+	case "sleep":
+		select {
+		case <-time.After(time.Duration(st.Ms) * time.Millisecond):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	case "expect_leader":
+		id, err := waitLeader(ctx, c, within(st))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("leader is node %d", id), nil
+	case "expect_no_leader":
+		deadline := time.Now().Add(within(st))
+		for time.Now().Before(deadline) {
+			if id, ok := c.Leader(ctx); ok {
+				return "", fmt.Errorf("unexpected leader node %d", id)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	case "expect_committed":
+		return "", waitCommittedAll(ctx, c, nodesOr(st.Nodes, c.Honest()), st.Min, within(st))
+	case "expect_consistent":
+		return CheckConsistent(ctx, c, nodesOr(st.Nodes, c.Honest()))
+	}
+	return "", nil
+}
+
+func waitLeader(ctx context.Context, c *Cluster, d time.Duration) (int32, error) {
+	deadline := time.Now().Add(d)
+	for {
+		if id, ok := c.Leader(ctx); ok {
+			return id, nil
+		}
+		if time.Now().After(deadline) {
+			return -1, fmt.Errorf("no leader elected within %v", d)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func waitCommittedAll(ctx context.Context, c *Cluster, ids []int32, n int64, d time.Duration) error {
+	deadline := time.Now().Add(d)
+	for {
+		var lagging []int32
+		for _, id := range ids {
+			if st, err := c.Status(ctx, id); err != nil || st.Committed < n {
+				lagging = append(lagging, id)
+			}
+		}
+		if len(lagging) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("nodes %v committed fewer than %d entries within %v", lagging, n, d)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// CheckConsistent so sánh ledger đã commit của các node:
+// mọi index mà hai node cùng có phải giống hệt nhau (term, hash, data).
+func CheckConsistent(ctx context.Context, c *Cluster, ids []int32) (string, error) {
+	ref := make(map[int64]*proto.LedgerEntry)
+	owner := make(map[int64]int32)
+	longest := 0
+	for _, id := range ids {
+		entries, err := c.Ledger(ctx, id)
+		if err != nil {
+			return "", fmt.Errorf("node %d: %w", id, err)
+		}
+		longest = max(longest, len(entries))
+		for _, e := range entries {
+			r, ok := ref[e.Index]
+			if !ok {
+				ref[e.Index], owner[e.Index] = e, id
+				continue
+			}
+			if r.Term != e.Term || r.Hash != e.Hash || r.PrevHash != e.PrevHash || r.Data != e.Data {
+				return "", fmt.Errorf("entry %d diverged: node %d has %q, node %d has %q", e.Index, owner[e.Index], r.Data, id, e.Data)
+			}
+		}
+	}
+	return fmt.Sprintf("%d nodes agree on %d committed entries", len(ids), longest), nil
+}
+
+func (r *Report) Print(w io.Writer) {
+	for _, s := range r.Steps {
+		status := "PASS"
+		switch {
+		case s.Skipped:
+			status = "SKIP"
+		case !s.Passed:
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("  [%s] %2d %-18s %8v", status, s.Step, s.Action, s.Duration.Round(time.Millisecond))
+		if s.Detail != "" {
+			line += "  " + s.Detail
+		}
+		if s.Error != "" {
+			line += "  error: " + s.Error
+		}
+		fmt.Fprintln(w, line)
+	}
+	result := "PASS"
+	if !r.Passed {
+		result = "FAIL"
+	}
+	fmt.Fprintf(w, "%s %s (%s) in %v\n", result, r.Scenario, r.Algorithm, r.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,313 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"consensus/common/proto"
+)
+
+// fakeNode là trạng thái của một node Raft giả mà fakeCluster phục vụ qua RPC.
+type fakeNode struct {
+	state     string
+	term      int64
+	ledger    []*proto.LedgerEntry
+	partition []int32
+	links     []*proto.LinkFault
+}
+
+// fakeCluster thay tiến trình và gRPC của Cluster bằng trạng thái trong bộ nhớ.
+type fakeCluster struct {
+	mu      sync.Mutex
+	running map[int]bool
+	broken  map[int]bool // Node không khởi động được (VD: binary lỗi, port bị chiếm)
+	nodes   map[int32]*fakeNode
+}
+
+// Start giống tiến trình mới khởi động: mạng lành, giữ nguyên ledger (Raft lưu đĩa).
+func (f *fakeCluster) Start(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.broken[id] {
+		return fmt.Errorf("node %d failed to start", id)
+	}
+	if !f.running[id] {
+		n := f.nodes[int32(id)]
+		n.partition, n.links = nil, nil
+	}
+	f.running[id] = true
+	return nil
+}
+
+func (f *fakeCluster) Stop(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.running, id)
+}
+
+func (f *fakeCluster) IsRunning(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.running[id]
+}
+
+func (f *fakeCluster) StopAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = make(map[int]bool)
+}
+
+type fakeClient struct {
+	proto.ConsensusServiceClient // Các RPC runner không dùng: gọi tới sẽ panic
+	f                            *fakeCluster
+	id                           int32
+}
+
+func (c *fakeClient) up() (*fakeNode, error) {
+	if !c.f.running[int(c.id)] {
+		return nil, fmt.Errorf("node %d is down", c.id)
+	}
+	return c.f.nodes[c.id], nil
+}
+
+func (c *fakeClient) GetStatus(ctx context.Context, _ *proto.Empty, _ ...grpc.CallOption) (*proto.StatusReply, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	n, err := c.up()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.StatusReply{Id: c.id, State: n.state, Term: n.term, Committed: int64(len(n.ledger))}, nil
+}
+
+func (c *fakeClient) GetLedger(ctx context.Context, _ *proto.LedgerArgs, _ ...grpc.CallOption) (*proto.LedgerReply, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	n, err := c.up()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.LedgerReply{Entries: n.ledger}, nil
+}
+
+// Propose: Leader giả commit ngay và nhân bản entry tới mọi node đang chạy.
+func (c *fakeClient) Propose(ctx context.Context, args *proto.ProposeArgs, _ ...grpc.CallOption) (*proto.ProposeReply, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	n, err := c.up()
+	if err != nil {
+		return nil, err
+	}
+	if n.state != "Leader" {
+		return &proto.ProposeReply{Success: false}, nil
+	}
+	e := &proto.LedgerEntry{Index: int64(len(n.ledger)), Term: n.term, Data: args.Command}
+	for id, other := range c.f.nodes {
+		if c.f.running[int(id)] {
+			other.ledger = append(other.ledger, e)
+		}
+	}
+	return &proto.ProposeReply{Success: true, LeaderId: c.id}, nil
+}
+
+func (c *fakeClient) SetLinkFaults(ctx context.Context, args *proto.LinkFaultArgs, _ ...grpc.CallOption) (*proto.LinkFaultReply, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	n, err := c.up()
+	if err != nil {
+		return nil, err
+	}
+	n.links = args.Links
+	return &proto.LinkFaultReply{Success: true}, nil
+}
+
+func (c *fakeClient) SetNetworkPartition(ctx context.Context, args *proto.PartitionArgs, _ ...grpc.CallOption) (*proto.PartitionReply, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	n, err := c.up()
+	if err != nil {
+		return nil, err
+	}
+	n.partition = args.IsolatedNodeIds
+	return &proto.PartitionReply{Success: true}, nil
+}
+
+// newFakeCluster tạo cluster Raft 5 node giả, tất cả đang chạy, node 0 là Leader term 1.
+func newFakeCluster() (*Cluster, *fakeCluster) {
+	f := &fakeCluster{running: make(map[int]bool), broken: make(map[int]bool), nodes: make(map[int32]*fakeNode)}
+	c := &Cluster{Algorithm: Raft, Nodes: []int32{0, 1, 2, 3, 4}, procs: f, malicious: make(map[int32]bool)}
+	c.dial = func(id int32) (proto.ConsensusServiceClient, error) { return &fakeClient{f: f, id: id}, nil }
+	for _, id := range c.Nodes {
+		f.nodes[id] = &fakeNode{state: "Follower", term: 1}
+		f.running[int(id)] = true
+	}
+	f.nodes[0].state = "Leader"
+	return c, f
+}
+
+func entries(data ...string) []*proto.LedgerEntry {
+	var es []*proto.LedgerEntry
+	for i, d := range data {
+		es = append(es, &proto.LedgerEntry{Index: int64(i), Term: 1, Data: d})
+	}
+	return es
+}
+
+func TestLoadScenarioFiles(t *testing.T) {
+	files, err := filepath.Glob("../scenarios/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no scenarios found: %v", err)
+	}
+	for _, path := range files {
+		sc, err := LoadScenario(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if len(sc.Steps) == 0 {
+			t.Errorf("%s: no steps", path)
+		}
+	}
+}
+
+func TestLoadScenarioValidation(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name, body, wantErr string
+	}{
+		{"ok", `{"algorithm": "raft", "steps": [{"action": "start"}, {"action": "expect_committed", "min": 3, "nodes": [0, 1]}]}`, ""},
+		{"bad-json", `{"algorithm": "raft", "steps": [`, "unexpected end"},
+		{"bad-algorithm", `{"algorithm": "paxos", "steps": []}`, `unknown algorithm "paxos"`},
+		{"bad-action", `{"algorithm": "pbft", "steps": [{"action": "start"}, {"action": "explode"}]}`, `step 2: unknown action "explode"`},
+	} {
+		path := filepath.Join(dir, tc.name+".json")
+		if err := os.WriteFile(path, []byte(tc.body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sc, err := LoadScenario(path)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			// Không khai báo name -> dùng đường dẫn file
+			if sc.Name != path || sc.Steps[1].Min != 3 || len(sc.Steps[1].Nodes) != 2 {
+				t.Fatalf("%s: parsed %+v", tc.name, sc)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("%s: got error %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestExpectCommitted(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	for id, n := range f.nodes {
+		n.ledger = entries("a", "b", "c")
+		if id == 4 {
+			n.ledger = entries("a")
+		}
+	}
+	step := Step{Action: "expect_committed", Min: 3, WithinMs: 200}
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err == nil || !strings.Contains(err.Error(), "[4]") {
+		t.Fatalf("lagging node 4: got %v", err)
+	}
+	step.Nodes = []int32{0, 1, 2, 3}
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err != nil {
+		t.Fatalf("nodes 0..3 committed 3: %v", err)
+	}
+	// Mặc định chỉ xét node đang chạy
+	step.Nodes = nil
+	c.Stop(4)
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err != nil {
+		t.Fatalf("stopped node must be ignored: %v", err)
+	}
+}
+
+func TestExpectConsistent(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	for _, n := range f.nodes {
+		n.ledger = entries("a", "b", "c")
+	}
+	f.nodes[3].ledger = entries("a", "b") // Ngắn hơn nhưng không phân kỳ
+	detail, err := runStep(ctx, &Scenario{}, 0, Step{Action: "expect_consistent"}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail != "5 nodes agree on 3 committed entries" {
+		t.Fatalf("detail %q", detail)
+	}
+
+	f.nodes[2].ledger = entries("a", "x", "c")
+	_, err = runStep(ctx, &Scenario{}, 0, Step{Action: "expect_consistent"}, c)
+	if err == nil || !strings.Contains(err.Error(), "entry 1 diverged") {
+		t.Fatalf("divergence at index 1: got %v", err)
+	}
+	// Loại node phân kỳ khỏi phép so sánh
+	if _, err := runStep(ctx, &Scenario{}, 0, Step{Action: "expect_consistent", Nodes: []int32{0, 1, 3, 4}}, c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpectNoLeader(t *testing.T) {
+	c, f := newFakeCluster()
+	ctx := context.Background()
+	step := Step{Action: "expect_no_leader", WithinMs: 150}
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err == nil || !strings.Contains(err.Error(), "node 0") {
+		t.Fatalf("leader 0 present: got %v", err)
+	}
+
+	// Leader không được đa số cùng term công nhận thì không tính
+	f.nodes[0].term = 7
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err != nil {
+		t.Fatalf("stale leader: %v", err)
+	}
+	f.nodes[0].term = 1
+	c.Stop(0)
+	if _, err := runStep(ctx, &Scenario{}, 0, step, c); err != nil {
+		t.Fatalf("leader stopped: %v", err)
+	}
+}
+
+func TestRunSkipsAfterFailure(t *testing.T) {
+	c, f := newFakeCluster()
+	sc := &Scenario{Name: "t", Algorithm: Raft, Steps: []Step{
+		{Action: "expect_leader", WithinMs: 200},
+		{Action: "propose", Count: 2, WithinMs: 500},
+		{Action: "expect_committed", Min: 2},
+		{Action: "partition", Groups: [][]int32{{0, 1}, {2, 3, 4}}},
+		{Action: "expect_committed", Min: 5, WithinMs: 100},
+		{Action: "heal"},
+	}}
+	rep := Run(context.Background(), sc, c)
+	if rep.Passed {
+		t.Fatal("scenario should fail at step 5")
+	}
+	for i, want := range []string{"PASS", "PASS", "PASS", "PASS", "FAIL", "SKIP"} {
+		s := rep.Steps[i]
+		got := map[bool]string{true: "PASS", false: "FAIL"}[s.Passed]
+		if s.Skipped {
+			got = "SKIP"
+		}
+		if got != want {
+			t.Fatalf("step %d: %s, want %s (%+v)", i+1, got, want, s)
+		}
+	}
+	if rep.Steps[1].Detail != "2 committed" {
+		t.Fatalf("propose detail %q", rep.Steps[1].Detail)
+	}
+	// partition chặn đúng các link giữa hai nhóm
+	if links := f.nodes[1].links; len(links) != 3 || links[0].ToNode != 2 || !links[0].Blocked {
+		t.Fatalf("node 1 links %v", links)
+	}
+}
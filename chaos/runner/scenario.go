@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Scenario là một timeline các bước (hành động + kiểm tra) chạy trên một thuật toán.
+//
+//	{
+//	  "name": "raft-partition-heal",
+//	  "algorithm": "raft",
+//	  "steps": [
+//	    {"action": "start"},
+//	    {"action": "expect_leader", "within_ms": 3000},
+//	    {"action": "propose", "count": 3},
+//	    {"action": "partition", "groups": [[0, 1], [2, 3, 4]]},
+//	    {"action": "heal"},
+//	    {"action": "expect_consistent"}
+//	  ]
+//	}
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Algorithm   string `json:"algorithm"`       // "raft" | "pbft"
+	Netem       string `json:"netem,omitempty"` // Topology WAN (đường dẫn tương đối từ gốc repo)
+	Steps       []Step `json:"steps"`
+}
+
+type Step struct {
+	Action   string    `json:"action"`
+	Nodes    []int32   `json:"nodes,omitempty"`     // Mặc định: mọi node (hoặc mọi node trung thực với expect_*)
+	Groups   [][]int32 `json:"groups,omitempty"`    // partition
+	Node     int32     `json:"node,omitempty"`      // faults
+	Links    []Link    `json:"links,omitempty"`     // faults
+	Count    int       `json:"count,omitempty"`     // propose
+	Min      int64     `json:"min,omitempty"`       // expect_committed
+	Ms       int64     `json:"ms,omitempty"`        // sleep
+	WithinMs int64     `json:"within_ms,omitempty"` // Thời hạn cho propose/expect_*
+}
+
+// Link tương ứng proto.LinkFault (xem RPC SetLinkFaults).
+type Link struct {
+	To              int32   `json:"to"`
+	Blocked         bool    `json:"blocked,omitempty"`
+	DropRate        float64 `json:"drop_rate,omitempty"`
+	DelayMs         int64   `json:"delay_ms,omitempty"`
+	JitterMs        int64   `json:"jitter_ms,omitempty"`
+	DuplicateRate   float64 `json:"duplicate_rate,omitempty"`
+	ReorderRate     float64 `json:"reorder_rate,omitempty"`
+	ReorderWindowMs int64   `json:"reorder_window_ms,omitempty"`
+}
+
+// Các action được hỗ trợ. expect_* là assertion; phần còn lại là hành động.
+var actions = map[string]bool{
+	"start": true, "stop": true, "restart": true, "kill_leader": true,
+	"propose": true, "partition": true, "heal": true, "faults": true,
+	"malicious": true, "honest": true, "sleep": true,
+	"expect_leader": true, "expect_no_leader": true, "expect_committed": true, "expect_consistent": true,
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if sc.Algorithm != Raft && sc.Algorithm != PBFT {
+		return nil, fmt.Errorf("%s: unknown algorithm %q", path, sc.Algorithm)
+	}
+	for i, st := range sc.Steps {
+		if !actions[st.Action] {
+			return nil, fmt.Errorf("%s: step %d: unknown action %q", path, i+1, st.Action)
+		}
+	}
+	if sc.Name == "" {
+		sc.Name = path
+	}
+	return &sc, nil
+}
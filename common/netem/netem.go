@@ -0,0 +1,151 @@
+// Package netem giả lập mạng không hoàn hảo cho traffic ConsensusService.
+//
+// Mỗi node giữ một Injector mô tả các link gửi đi (node hiện tại -> peer):
+// lỗi do người vận hành bật qua RPC SetLinkFaults và profile WAN (wan.go).
+// Injector được gắn vào từng kết nối gRPC dưới dạng UnaryClientInterceptor,
+// nên cả Raft lẫn pBFT dùng chung một cơ chế mà không cần sửa handler.
+package netem
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"consensus/common/proto"
+)
+
+// Link mô tả lỗi áp dụng cho một chiều gửi tới một peer.
+type Link struct {
+	Blocked       bool
+	DropRate      float64
+	Delay         time.Duration
+	Jitter        time.Duration
+	DuplicateRate float64
+	ReorderRate   float64
+	ReorderWindow time.Duration
+}
+
+type Injector struct {
+	mu        sync.Mutex
+	links     map[int32]Link
+	profiles  map[int32]Profile
+	busyUntil map[int32]time.Time
+	rng       *rand.Rand
+}
+
+func New() *Injector {
+	return NewSeeded(time.Now().UnixNano())
+}
+
+// NewSeeded tạo Injector với nguồn ngẫu nhiên cố định (test cần kết quả lặp lại được).
+func NewSeeded(seed int64) *Injector {
+	return &Injector{
+		links:     make(map[int32]Link),
+		profiles:  make(map[int32]Profile),
+		busyUntil: make(map[int32]time.Time),
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetFaults thay thế toàn bộ cấu hình link. Danh sách rỗng = mạng lành.
+func (in *Injector) SetFaults(faults []*proto.LinkFault) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.links = make(map[int32]Link)
+	for _, f := range faults {
+		in.links[f.ToNode] = Link{
+			Blocked:       f.Blocked,
+			DropRate:      f.DropRate,
+			Delay:         time.Duration(f.DelayMs) * time.Millisecond,
+			Jitter:        time.Duration(f.JitterMs) * time.Millisecond,
+			DuplicateRate: f.DuplicateRate,
+			ReorderRate:   f.ReorderRate,
+			ReorderWindow: time.Duration(f.ReorderWindowMs) * time.Millisecond,
+		}
+	}
+}
+
+func (in *Injector) Link(to int32) (Link, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	l, ok := in.links[to]
+	return l, ok
+}
+
+func (in *Injector) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rng.Float64() < p
+}
+
+func (in *Injector) random(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return time.Duration(in.rng.Int63n(int64(d) + 1))
+}
+
+// DialOption gắn Injector vào một kết nối tới peer `to`.
+func (in *Injector) DialOption(to int32) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(in.UnaryClientInterceptor(to))
+}
+
+func (in *Injector) UnaryClientInterceptor(to int32) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		link, _ := in.Link(to)
+		if link.Blocked || in.chance(link.DropRate) {
+			return status.Error(codes.Unavailable, "netem: message dropped")
+		}
+
+		delay := link.Delay + in.random(link.Jitter) + in.transit(to, protobuf.Size(req.(protobuf.Message)), true)
+		// Reorder: giữ tin nhắn thêm một khoảng để tin gửi sau vượt lên trước
+		if in.chance(link.ReorderRate) {
+			delay += in.random(link.ReorderWindow)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		if in.chance(link.DuplicateRate) {
+			dup := protobuf.Clone(reply.(protobuf.Message))
+			go func() {
+				dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+				defer cancel()
+				invoker(dctx, method, req, dup, cc, opts...)
+			}()
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		// Chiều về của reply đi qua cùng link (giả định đối xứng)
+		if err := sleep(ctx, in.transit(to, protobuf.Size(reply.(protobuf.Message)), false)); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		return nil
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,173 @@
+package netem
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"consensus/common/proto"
+)
+
+const testSeed = 42
+
+// call gửi một tin nhắn qua interceptor tới `to`; invoker giả chỉ đếm số lần được gọi.
+func call(t *testing.T, in *Injector, to int32, calls *atomic.Int32) (time.Duration, error) {
+	t.Helper()
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls.Add(1)
+		return nil
+	}
+	start := time.Now()
+	err := in.UnaryClientInterceptor(to)(context.Background(), "/common.ConsensusService/Test", &proto.Empty{}, &proto.Empty{}, nil, invoker)
+	return time.Since(start), err
+}
+
+func TestDropRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate     float64
+		min, max int
+	}{
+		{0, 0, 0},
+		{0.3, 250, 350},
+		{1, 1000, 1000},
+	} {
+		in := NewSeeded(testSeed)
+		in.SetFaults([]*proto.LinkFault{{ToNode: 1, DropRate: tc.rate}})
+		var calls atomic.Int32
+		dropped := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := call(t, in, 1, &calls); err != nil {
+				dropped++
+			}
+		}
+		if dropped < tc.min || dropped > tc.max {
+			t.Errorf("drop_rate %.1f: dropped %d/1000, want [%d, %d]", tc.rate, dropped, tc.min, tc.max)
+		}
+		if int(calls.Load()) != 1000-dropped {
+			t.Errorf("drop_rate %.1f: invoker called %d times, want %d", tc.rate, calls.Load(), 1000-dropped)
+		}
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	in := NewSeeded(testSeed)
+	in.SetFaults([]*proto.LinkFault{{ToNode: 1, DuplicateRate: 1}})
+	var calls atomic.Int32
+	if _, err := call(t, in, 1, &calls); err != nil {
+		t.Fatal(err)
+	}
+	// Bản sao được gửi trong goroutine riêng
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("duplicate_rate 1: invoker called %d times, want 2", n)
+	}
+
+	in.SetFaults([]*proto.LinkFault{{ToNode: 1, DuplicateRate: 0}})
+	calls.Store(0)
+	call(t, in, 1, &calls)
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("duplicate_rate 0: invoker called %d times, want 1", n)
+	}
+}
+
+func TestReorderWindow(t *testing.T) {
+	const window = 200 * time.Millisecond
+	in := NewSeeded(testSeed)
+	in.SetFaults([]*proto.LinkFault{{ToNode: 1, ReorderRate: 1, ReorderWindowMs: window.Milliseconds()}})
+
+	// Cùng seed -> tái tạo đúng các lần rút ngẫu nhiên của interceptor:
+	// một lần cho ReorderRate, một lần cho độ trễ trong cửa sổ.
+	rng := rand.New(rand.NewSource(testSeed))
+	rng.Float64()
+	want := time.Duration(rng.Int63n(int64(window) + 1))
+	if want < 20*time.Millisecond {
+		t.Fatalf("seed %d gives reorder delay %v, pick another seed", testSeed, want)
+	}
+
+	// Tin đầu bị giữ lại trong cửa sổ reorder, tin gửi sau (link lành) vượt lên trước
+	order := make(chan string, 2)
+	var calls atomic.Int32
+	done := make(chan time.Duration)
+	go func() {
+		elapsed, _ := call(t, in, 1, &calls)
+		order <- "first"
+		done <- elapsed
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if _, err := call(t, in, 2, &calls); err != nil {
+		t.Fatal(err)
+	}
+	order <- "second"
+	elapsed := <-done
+
+	if got := <-order; got != "second" {
+		t.Fatalf("reordered message delivered %s, want after the later one", got)
+	}
+	if elapsed < want || elapsed > want+50*time.Millisecond {
+		t.Fatalf("reorder delay %v, want ~%v (window %v)", elapsed, want, window)
+	}
+}
+
+func TestBlockedIsOneWay(t *testing.T) {
+	// Node 0 chặn chiều 0 -> 1; Injector của node 1 (chiều 1 -> 0) không bị ảnh hưởng
+	a, b := NewSeeded(testSeed), NewSeeded(testSeed)
+	a.SetFaults([]*proto.LinkFault{{ToNode: 1, Blocked: true}})
+	var calls atomic.Int32
+	if _, err := call(t, a, 1, &calls); err == nil {
+		t.Fatal("0 -> 1 should be blocked")
+	}
+	if _, err := call(t, a, 2, &calls); err != nil {
+		t.Fatalf("0 -> 2 should pass: %v", err)
+	}
+	if _, err := call(t, b, 0, &calls); err != nil {
+		t.Fatalf("1 -> 0 should pass: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("invoker called %d times, want 2", n)
+	}
+
+	a.SetFaults(nil)
+	if _, err := call(t, a, 1, &calls); err != nil {
+		t.Fatalf("0 -> 1 after clearing faults: %v", err)
+	}
+}
+
+func TestDelayJitterBounds(t *testing.T) {
+	const delay, jitter = 20 * time.Millisecond, 30 * time.Millisecond
+	in := NewSeeded(testSeed)
+	in.SetFaults([]*proto.LinkFault{{ToNode: 1, DelayMs: delay.Milliseconds(), JitterMs: jitter.Milliseconds()}})
+	var calls atomic.Int32
+	for i := 0; i < 10; i++ {
+		elapsed, err := call(t, in, 1, &calls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed < delay || elapsed > delay+jitter+30*time.Millisecond {
+			t.Fatalf("call %d took %v, want within [%v, %v]", i, elapsed, delay, delay+jitter)
+		}
+	}
+
+	// random() luôn nằm trong [0, d] và thực sự dao động
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 1000; i++ {
+		j := in.random(jitter)
+		if j < 0 || j > jitter {
+			t.Fatalf("jitter %v outside [0, %v]", j, jitter)
+		}
+		seen[j] = true
+	}
+	if len(seen) < 100 {
+		t.Fatalf("jitter took only %d distinct values", len(seen))
+	}
+	if in.random(0) != 0 {
+		t.Fatal("zero jitter must add no delay")
+	}
+}
@@ -0,0 +1,139 @@
+package netem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Profile mô tả đặc tính WAN của một link: trễ một chiều, jitter và băng thông.
+type Profile struct {
+	Latency   time.Duration
+	Jitter    time.Duration
+	Bandwidth int64 // bytes/s, 0 = không giới hạn
+}
+
+// Topology là ma trận latency/jitter/bandwidth giữa các region.
+// Mỗi link áp dụng cho cả hai chiều, trừ khi chiều ngược lại được khai báo riêng.
+//
+//	{
+//	  "regions": {"us": [0, 1], "eu": [2, 3], "ap": [4]},
+//	  "links": [{"from": "us", "to": "eu", "latency_ms": 40, "jitter_ms": 5, "bandwidth_kbps": 10000}]
+//	}
+type Topology struct {
+	Regions map[string][]int32 `json:"regions"`
+	Links   []TopologyLink     `json:"links"`
+}
+
+type TopologyLink struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	LatencyMs     int64  `json:"latency_ms"`
+	JitterMs      int64  `json:"jitter_ms"`
+	BandwidthKbps int64  `json:"bandwidth_kbps"`
+}
+
+func LoadTopology(path string) (*Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Topology
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("netem: parse %s: %w", path, err)
+	}
+	for _, l := range t.Links {
+		if _, ok := t.Regions[l.From]; !ok {
+			return nil, fmt.Errorf("netem: unknown region %q", l.From)
+		}
+		if _, ok := t.Regions[l.To]; !ok {
+			return nil, fmt.Errorf("netem: unknown region %q", l.To)
+		}
+	}
+	return &t, nil
+}
+
+func (t *Topology) regionOf(id int32) (string, bool) {
+	for name, ids := range t.Regions {
+		for _, n := range ids {
+			if n == id {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Profiles trả về profile của mọi link gửi đi từ node `self`.
+func (t *Topology) Profiles(self int32) map[int32]Profile {
+	res := make(map[int32]Profile)
+	from, ok := t.regionOf(self)
+	if !ok {
+		return res
+	}
+	byPair := make(map[[2]string]TopologyLink)
+	for _, l := range t.Links {
+		if _, ok := byPair[[2]string{l.To, l.From}]; !ok {
+			byPair[[2]string{l.To, l.From}] = l
+		}
+	}
+	// Khai báo tường minh luôn thắng chiều ngược được suy ra
+	for _, l := range t.Links {
+		byPair[[2]string{l.From, l.To}] = l
+	}
+	for to, ids := range t.Regions {
+		l, ok := byPair[[2]string{from, to}]
+		if !ok {
+			continue
+		}
+		for _, id := range ids {
+			if id == self {
+				continue
+			}
+			res[id] = Profile{
+				Latency:   time.Duration(l.LatencyMs) * time.Millisecond,
+				Jitter:    time.Duration(l.JitterMs) * time.Millisecond,
+				Bandwidth: l.BandwidthKbps * 1000 / 8,
+			}
+		}
+	}
+	return res
+}
+
+// SetProfiles thay thế ma trận WAN đang áp dụng.
+func (in *Injector) SetProfiles(profiles map[int32]Profile) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.profiles = profiles
+	in.busyUntil = make(map[int32]time.Time)
+}
+
+// transit tính thời gian một tin nhắn `size` bytes đi hết link tới `to`.
+// Băng thông được mô phỏng như một hàng đợi: tin sau phải chờ tin trước truyền xong.
+func (in *Injector) transit(to int32, size int, queued bool) time.Duration {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	p, ok := in.profiles[to]
+	if !ok {
+		return 0
+	}
+	d := p.Latency
+	if p.Jitter > 0 {
+		d += time.Duration(in.rng.Int63n(int64(p.Jitter) + 1))
+	}
+	if p.Bandwidth > 0 {
+		tx := time.Duration(int64(size) * int64(time.Second) / p.Bandwidth)
+		if !queued {
+			return d + tx
+		}
+		now := time.Now()
+		start := in.busyUntil[to]
+		if start.Before(now) {
+			start = now
+		}
+		in.busyUntil[to] = start.Add(tx)
+		d += in.busyUntil[to].Sub(now)
+	}
+	return d
+}
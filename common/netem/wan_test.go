@@ -0,0 +1,140 @@
+package netem
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopologyProfiles(t *testing.T) {
+	ms := time.Millisecond
+	regions := map[string][]int32{"us": {0, 1}, "eu": {2, 3}, "ap": {4}}
+	for _, tc := range []struct {
+		name  string
+		links []TopologyLink
+		self  int32
+		want  map[int32]Profile
+	}{
+		{
+			name:  "reverse inferred from single entry",
+			links: []TopologyLink{{From: "us", To: "eu", LatencyMs: 40, JitterMs: 5, BandwidthKbps: 8000}},
+			self:  2,
+			want: map[int32]Profile{
+				0: {Latency: 40 * ms, Jitter: 5 * ms, Bandwidth: 1_000_000},
+				1: {Latency: 40 * ms, Jitter: 5 * ms, Bandwidth: 1_000_000},
+			},
+		},
+		{
+			name: "asymmetric links keep their own direction",
+			links: []TopologyLink{
+				{From: "us", To: "eu", LatencyMs: 40},
+				{From: "eu", To: "us", LatencyMs: 90, BandwidthKbps: 800},
+			},
+			self: 0,
+			want: map[int32]Profile{
+				2: {Latency: 40 * ms},
+				3: {Latency: 40 * ms},
+			},
+		},
+		{
+			name: "asymmetric links from the other side",
+			links: []TopologyLink{
+				{From: "us", To: "eu", LatencyMs: 40},
+				{From: "eu", To: "us", LatencyMs: 90, BandwidthKbps: 800},
+			},
+			self: 3,
+			want: map[int32]Profile{
+				0: {Latency: 90 * ms, Bandwidth: 100_000},
+				1: {Latency: 90 * ms, Bandwidth: 100_000},
+			},
+		},
+		{
+			name: "explicit entry wins over inferred reverse regardless of order",
+			links: []TopologyLink{
+				{From: "eu", To: "us", LatencyMs: 90},
+				{From: "us", To: "eu", LatencyMs: 40},
+			},
+			self: 1,
+			want: map[int32]Profile{
+				2: {Latency: 40 * ms},
+				3: {Latency: 40 * ms},
+			},
+		},
+		{
+			name: "intra-region link excludes self",
+			links: []TopologyLink{
+				{From: "us", To: "us", LatencyMs: 1},
+				{From: "us", To: "ap", LatencyMs: 120},
+			},
+			self: 0,
+			want: map[int32]Profile{
+				1: {Latency: 1 * ms},
+				4: {Latency: 120 * ms},
+			},
+		},
+		{
+			name:  "no entry in either direction leaves link unshaped",
+			links: []TopologyLink{{From: "us", To: "eu", LatencyMs: 40}},
+			self:  4,
+			want:  map[int32]Profile{},
+		},
+		{
+			name:  "node outside every region",
+			links: []TopologyLink{{From: "us", To: "eu", LatencyMs: 40}},
+			self:  9,
+			want:  map[int32]Profile{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			topo := &Topology{Regions: regions, Links: tc.links}
+			got := topo.Profiles(tc.self)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for id, w := range tc.want {
+				if got[id] != w {
+					t.Errorf("link %d -> %d: got %+v, want %+v", tc.self, id, got[id], w)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitBandwidth(t *testing.T) {
+	const latency = 10 * time.Millisecond
+	for _, tc := range []struct {
+		name      string
+		bandwidth int64 // bytes/s
+		size      int
+		queued    int // số tin gửi liên tiếp trên hàng đợi
+		wantTx    time.Duration
+	}{
+		{"unlimited", 0, 100_000, 3, 0},
+		{"1 MB/s, 1 KB", 1_000_000, 1_000, 1, time.Millisecond},
+		{"1 MB/s, 1 KB x3 queued", 1_000_000, 1_000, 3, time.Millisecond},
+		{"100 KB/s, 10 KB x2 queued", 100_000, 10_000, 2, 100 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			in := NewSeeded(testSeed)
+			in.SetProfiles(map[int32]Profile{1: {Latency: latency, Bandwidth: tc.bandwidth}})
+
+			// Chiều về (không xếp hàng): latency + thời gian truyền, không phụ thuộc tin trước
+			if got := in.transit(1, tc.size, false); got != latency+tc.wantTx {
+				t.Fatalf("reply transit %v, want %v", got, latency+tc.wantTx)
+			}
+			// Chiều đi: tin thứ k phải chờ k-1 tin trước truyền xong
+			for k := 1; k <= tc.queued; k++ {
+				got := in.transit(1, tc.size, true)
+				want := latency + time.Duration(k)*tc.wantTx
+				if got > want || got < want-5*time.Millisecond {
+					t.Fatalf("message %d: transit %v, want ~%v", k, got, want)
+				}
+			}
+		})
+	}
+
+	// Link không có profile không bị trễ
+	in := NewSeeded(testSeed)
+	if d := in.transit(7, 1_000, true); d != 0 {
+		t.Fatalf("unshaped link transit %v, want 0", d)
+	}
+}
@@ -0,0 +1,78 @@
+// Package procman quản lý tiến trình các node (bật/tắt theo id).
+// Dùng chung bởi dashboard Raft và chaos runner.
+package procman
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+)
+
+type Manager struct {
+	Binary string // Đường dẫn file thực thi của node
+	Dir    string // Thư mục làm việc (logs/ sẽ nằm ở đây)
+	Args   func(id int) []string
+	Stdout *os.File // nil = bỏ output
+
+	mu        sync.Mutex
+	processes map[int]*exec.Cmd
+}
+
+func (m *Manager) Start(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.processes == nil {
+		m.processes = make(map[int]*exec.Cmd)
+	}
+	if _, running := m.processes[id]; running {
+		return nil
+	}
+	cmd := exec.Command(m.Binary, m.Args(id)...)
+	cmd.Dir = m.Dir
+	if m.Stdout != nil {
+		cmd.Stdout, cmd.Stderr = m.Stdout, m.Stdout
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("procman: start node %d: %w", id, err)
+	}
+	m.processes[id] = cmd
+	return nil
+}
+
+// Stop giết tiến trình (giả lập crash, không shutdown nhẹ nhàng).
+func (m *Manager) Stop(id int) {
+	m.mu.Lock()
+	cmd, ok := m.processes[id]
+	delete(m.processes, id)
+	m.mu.Unlock()
+	if ok {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+}
+
+func (m *Manager) StopAll() {
+	for _, id := range m.Running() {
+		m.Stop(id)
+	}
+}
+
+func (m *Manager) IsRunning(id int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.processes[id]
+	return ok
+}
+
+func (m *Manager) Running() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int, 0, len(m.processes))
+	for id := range m.processes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
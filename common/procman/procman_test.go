@@ -0,0 +1,75 @@
+package procman
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess đóng vai một node: chạy tới khi bị kill.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("PROCMAN_HELPER") != "1" {
+		return
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Setenv("PROCMAN_HELPER", "1") // Tiến trình con kế thừa môi trường
+	m := &Manager{
+		Binary: os.Args[0],
+		Dir:    t.TempDir(),
+		Args: func(id int) []string {
+			return []string{"-test.run=^TestHelperProcess$", "--", strconv.Itoa(id)}
+		},
+	}
+	t.Cleanup(m.StopAll)
+	return m
+}
+
+func TestStartStop(t *testing.T) {
+	m := newTestManager(t)
+	for _, id := range []int{3, 1, 2} {
+		if err := m.Start(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := m.processes[1]
+	// Start node đang chạy là no-op, không sinh tiến trình thứ hai
+	if err := m.Start(1); err != nil || m.processes[1] != first {
+		t.Fatalf("restarting a running node: err %v, replaced %v", err, m.processes[1] != first)
+	}
+	if got := m.Running(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("running %v", got)
+	}
+
+	m.Stop(2)
+	if m.IsRunning(2) || !m.IsRunning(1) {
+		t.Fatalf("after stop 2: running %v", m.Running())
+	}
+	if first.ProcessState != nil {
+		t.Fatal("node 1 exited although only node 2 was stopped")
+	}
+	m.Stop(2) // Stop node đã dừng là no-op
+
+	m.StopAll()
+	if len(m.Running()) != 0 {
+		t.Fatalf("after StopAll: running %v", m.Running())
+	}
+	if first.ProcessState == nil {
+		t.Fatal("node 1 process was not reaped")
+	}
+}
+
+func TestStartError(t *testing.T) {
+	m := &Manager{Binary: "/nonexistent/node", Args: func(int) []string { return nil }}
+	if err := m.Start(0); err == nil {
+		t.Fatal("expected error for missing binary")
+	}
+	if m.IsRunning(0) {
+		t.Fatal("failed start must not be tracked")
+	}
+}
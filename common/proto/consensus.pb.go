@@ -123,7 +123,10 @@ func (x *LogEntry) GetCommand() string {
 type RequestVoteArgs struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
-	CandidateId   int32                  `protobuf:"varint,2,opt,name=candidateId,proto3" json:"candidateId,omitempty"` // Raft dùng int32 ID
+	CandidateId   int32                  `protobuf:"varint,2,opt,name=candidateId,proto3" json:"candidateId,omitempty"`   // Raft dùng int32 ID
+	LastLogIndex  int64                  `protobuf:"varint,3,opt,name=lastLogIndex,proto3" json:"lastLogIndex,omitempty"` // Election restriction: chỉ bầu cho log đủ mới
+	LastLogTerm   int64                  `protobuf:"varint,4,opt,name=lastLogTerm,proto3" json:"lastLogTerm,omitempty"`
+	GroupId       int32                  `protobuf:"varint,5,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"` // Multi-Raft: nhóm Raft nhận message (mặc định 0)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -172,6 +175,27 @@ func (x *RequestVoteArgs) GetCandidateId() int32 {
 	return 0
 }
 
+func (x *RequestVoteArgs) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *RequestVoteArgs) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+func (x *RequestVoteArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
 type RequestVoteReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
@@ -229,6 +253,11 @@ type AppendEntriesArgs struct {
 	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
 	LeaderId      int32                  `protobuf:"varint,2,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
 	Entries       []*LogEntry            `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit  int64                  `protobuf:"varint,4,opt,name=leaderCommit,proto3" json:"leaderCommit,omitempty"` // Index entry cuối đã commit trên Leader (-1 = chưa có)
+	GroupId       int32                  `protobuf:"varint,5,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	PrevLogIndex  int64                  `protobuf:"varint,6,opt,name=prev_log_index,json=prevLogIndex,proto3" json:"prev_log_index,omitempty"` // entries nối tiếp sau entry này (-1 = đầu log)
+	PrevLogTerm   int64                  `protobuf:"varint,7,opt,name=prev_log_term,json=prevLogTerm,proto3" json:"prev_log_term,omitempty"`
+	LeaderTimeMs  int64                  `protobuf:"varint,8,opt,name=leader_time_ms,json=leaderTimeMs,proto3" json:"leader_time_ms,omitempty"` // Đồng hồ Leader (Unix ms) lúc gửi khi còn lease, 0 = không có
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -284,10 +313,47 @@ func (x *AppendEntriesArgs) GetEntries() []*LogEntry {
 	return nil
 }
 
+func (x *AppendEntriesArgs) GetLeaderCommit() int64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+func (x *AppendEntriesArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *AppendEntriesArgs) GetPrevLogIndex() int64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *AppendEntriesArgs) GetPrevLogTerm() int64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *AppendEntriesArgs) GetLeaderTimeMs() int64 {
+	if x != nil {
+		return x.LeaderTimeMs
+	}
+	return 0
+}
+
 type AppendEntriesReply struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Term    int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	// Success: index cuối khớp với Leader; thất bại: Leader gửi lại từ match_index + 1
+	MatchIndex    int64 `protobuf:"varint,3,opt,name=match_index,json=matchIndex,proto3" json:"match_index,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -336,18 +402,174 @@ func (x *AppendEntriesReply) GetSuccess() bool {
 	return false
 }
 
+func (x *AppendEntriesReply) GetMatchIndex() int64 {
+	if x != nil {
+		return x.MatchIndex
+	}
+	return 0
+}
+
+type TimeoutNowArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          int64                  `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      int32                  `protobuf:"varint,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	GroupId       int32                  `protobuf:"varint,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeoutNowArgs) Reset() {
+	*x = TimeoutNowArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeoutNowArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeoutNowArgs) ProtoMessage() {}
+
+func (x *TimeoutNowArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeoutNowArgs.ProtoReflect.Descriptor instead.
+func (*TimeoutNowArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TimeoutNowArgs) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *TimeoutNowArgs) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *TimeoutNowArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type AppendEntriesBatchArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*AppendEntriesArgs   `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesBatchArgs) Reset() {
+	*x = AppendEntriesBatchArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesBatchArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesBatchArgs) ProtoMessage() {}
+
+func (x *AppendEntriesBatchArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesBatchArgs.ProtoReflect.Descriptor instead.
+func (*AppendEntriesBatchArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AppendEntriesBatchArgs) GetGroups() []*AppendEntriesArgs {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type AppendEntriesBatchReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*AppendEntriesReply  `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"` // Cùng thứ tự với request
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesBatchReply) Reset() {
+	*x = AppendEntriesBatchReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesBatchReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesBatchReply) ProtoMessage() {}
+
+func (x *AppendEntriesBatchReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesBatchReply.ProtoReflect.Descriptor instead.
+func (*AppendEntriesBatchReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AppendEntriesBatchReply) GetGroups() []*AppendEntriesReply {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
 type StatusReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
-	Term          int64                  `protobuf:"varint,3,opt,name=term,proto3" json:"term,omitempty"`
+	Term          int64                  `protobuf:"varint,3,opt,name=term,proto3" json:"term,omitempty"`           // pBFT: View
+	Committed     int64                  `protobuf:"varint,4,opt,name=committed,proto3" json:"committed,omitempty"` // Số entry/block đã commit (không tính Genesis)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StatusReply) Reset() {
 	*x = StatusReply{}
-	mi := &file_common_proto_consensus_proto_msgTypes[6]
+	mi := &file_common_proto_consensus_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -359,7 +581,7 @@ func (x *StatusReply) String() string {
 func (*StatusReply) ProtoMessage() {}
 
 func (x *StatusReply) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[6]
+	mi := &file_common_proto_consensus_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -372,7 +594,7 @@ func (x *StatusReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusReply.ProtoReflect.Descriptor instead.
 func (*StatusReply) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{6}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *StatusReply) GetId() int32 {
@@ -396,16 +618,25 @@ func (x *StatusReply) GetTerm() int64 {
 	return 0
 }
 
+func (x *StatusReply) GetCommitted() int64 {
+	if x != nil {
+		return x.Committed
+	}
+	return 0
+}
+
 type ProposeArgs struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	GroupId       int32                  `protobuf:"varint,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"` // Khác rỗng: router chọn nhóm sở hữu key thay cho group_id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ProposeArgs) Reset() {
 	*x = ProposeArgs{}
-	mi := &file_common_proto_consensus_proto_msgTypes[7]
+	mi := &file_common_proto_consensus_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -417,7 +648,7 @@ func (x *ProposeArgs) String() string {
 func (*ProposeArgs) ProtoMessage() {}
 
 func (x *ProposeArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[7]
+	mi := &file_common_proto_consensus_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -430,7 +661,7 @@ func (x *ProposeArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProposeArgs.ProtoReflect.Descriptor instead.
 func (*ProposeArgs) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{7}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ProposeArgs) GetCommand() string {
@@ -440,6 +671,20 @@ func (x *ProposeArgs) GetCommand() string {
 	return ""
 }
 
+func (x *ProposeArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *ProposeArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
 type ProposeReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -450,7 +695,7 @@ type ProposeReply struct {
 
 func (x *ProposeReply) Reset() {
 	*x = ProposeReply{}
-	mi := &file_common_proto_consensus_proto_msgTypes[8]
+	mi := &file_common_proto_consensus_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -462,7 +707,7 @@ func (x *ProposeReply) String() string {
 func (*ProposeReply) ProtoMessage() {}
 
 func (x *ProposeReply) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[8]
+	mi := &file_common_proto_consensus_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -475,7 +720,7 @@ func (x *ProposeReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProposeReply.ProtoReflect.Descriptor instead.
 func (*ProposeReply) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{8}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ProposeReply) GetSuccess() bool {
@@ -501,7 +746,7 @@ type PartitionArgs struct {
 
 func (x *PartitionArgs) Reset() {
 	*x = PartitionArgs{}
-	mi := &file_common_proto_consensus_proto_msgTypes[9]
+	mi := &file_common_proto_consensus_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -513,7 +758,7 @@ func (x *PartitionArgs) String() string {
 func (*PartitionArgs) ProtoMessage() {}
 
 func (x *PartitionArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[9]
+	mi := &file_common_proto_consensus_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -526,7 +771,7 @@ func (x *PartitionArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PartitionArgs.ProtoReflect.Descriptor instead.
 func (*PartitionArgs) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{9}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *PartitionArgs) GetIsolatedNodeIds() []int32 {
@@ -545,7 +790,7 @@ type PartitionReply struct {
 
 func (x *PartitionReply) Reset() {
 	*x = PartitionReply{}
-	mi := &file_common_proto_consensus_proto_msgTypes[10]
+	mi := &file_common_proto_consensus_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -557,7 +802,7 @@ func (x *PartitionReply) String() string {
 func (*PartitionReply) ProtoMessage() {}
 
 func (x *PartitionReply) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[10]
+	mi := &file_common_proto_consensus_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -570,7 +815,7 @@ func (x *PartitionReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PartitionReply.ProtoReflect.Descriptor instead.
 func (*PartitionReply) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{10}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *PartitionReply) GetSuccess() bool {
@@ -580,38 +825,36 @@ func (x *PartitionReply) GetSuccess() bool {
 	return false
 }
 
-type PbftMessage struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Header
-	Type   string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                   // "PrePrepare", "Prepare", "Commit"
-	NodeId string `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // pBFT dùng string ID (VD: "node1")
-	// Payload (View/Sequence)
-	View     int64 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"` // Thay cho Epoch (để rõ nghĩa pBFT)
-	Sequence int64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
-	// Block Info
-	BlockHash     string `protobuf:"bytes,5,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
-	PrevBlockHash string `protobuf:"bytes,6,opt,name=prev_block_hash,json=prevBlockHash,proto3" json:"prev_block_hash,omitempty"`
-	Data          string `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"` // Nội dung Block
-	Timestamp     int64  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Cấu hình lỗi cho link một chiều: node hiện tại -> to_node
+type LinkFault struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ToNode          int32                  `protobuf:"varint,1,opt,name=to_node,json=toNode,proto3" json:"to_node,omitempty"`
+	Blocked         bool                   `protobuf:"varint,2,opt,name=blocked,proto3" json:"blocked,omitempty"`                                          // Partition một chiều (asymmetric)
+	DropRate        float64                `protobuf:"fixed64,3,opt,name=drop_rate,json=dropRate,proto3" json:"drop_rate,omitempty"`                       // Xác suất bỏ tin nhắn [0, 1]
+	DelayMs         int64                  `protobuf:"varint,4,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`                           // Độ trễ cố định
+	JitterMs        int64                  `protobuf:"varint,5,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`                        // Độ trễ ngẫu nhiên thêm [0, jitter_ms]
+	DuplicateRate   float64                `protobuf:"fixed64,6,opt,name=duplicate_rate,json=duplicateRate,proto3" json:"duplicate_rate,omitempty"`        // Xác suất gửi lặp tin nhắn
+	ReorderRate     float64                `protobuf:"fixed64,7,opt,name=reorder_rate,json=reorderRate,proto3" json:"reorder_rate,omitempty"`              // Xác suất giữ tin nhắn lại để bị vượt mặt
+	ReorderWindowMs int64                  `protobuf:"varint,8,opt,name=reorder_window_ms,json=reorderWindowMs,proto3" json:"reorder_window_ms,omitempty"` // Thời gian giữ tối đa khi reorder
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *PbftMessage) Reset() {
-	*x = PbftMessage{}
-	mi := &file_common_proto_consensus_proto_msgTypes[11]
+func (x *LinkFault) Reset() {
+	*x = LinkFault{}
+	mi := &file_common_proto_consensus_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PbftMessage) String() string {
+func (x *LinkFault) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PbftMessage) ProtoMessage() {}
+func (*LinkFault) ProtoMessage() {}
 
-func (x *PbftMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[11]
+func (x *LinkFault) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -622,78 +865,2606 @@ func (x *PbftMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PbftMessage.ProtoReflect.Descriptor instead.
-func (*PbftMessage) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use LinkFault.ProtoReflect.Descriptor instead.
+func (*LinkFault) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *PbftMessage) GetType() string {
+func (x *LinkFault) GetToNode() int32 {
 	if x != nil {
-		return x.Type
+		return x.ToNode
 	}
-	return ""
+	return 0
 }
 
-func (x *PbftMessage) GetNodeId() string {
+func (x *LinkFault) GetBlocked() bool {
 	if x != nil {
-		return x.NodeId
+		return x.Blocked
 	}
-	return ""
+	return false
 }
 
-func (x *PbftMessage) GetView() int64 {
+func (x *LinkFault) GetDropRate() float64 {
 	if x != nil {
-		return x.View
+		return x.DropRate
 	}
 	return 0
 }
 
-func (x *PbftMessage) GetSequence() int64 {
+func (x *LinkFault) GetDelayMs() int64 {
 	if x != nil {
-		return x.Sequence
+		return x.DelayMs
 	}
 	return 0
 }
 
-func (x *PbftMessage) GetBlockHash() string {
+func (x *LinkFault) GetJitterMs() int64 {
 	if x != nil {
-		return x.BlockHash
+		return x.JitterMs
 	}
-	return ""
+	return 0
 }
 
-func (x *PbftMessage) GetPrevBlockHash() string {
+func (x *LinkFault) GetDuplicateRate() float64 {
 	if x != nil {
-		return x.PrevBlockHash
+		return x.DuplicateRate
 	}
-	return ""
+	return 0
 }
 
-func (x *PbftMessage) GetData() string {
+func (x *LinkFault) GetReorderRate() float64 {
 	if x != nil {
-		return x.Data
+		return x.ReorderRate
 	}
-	return ""
+	return 0
 }
 
-func (x *PbftMessage) GetTimestamp() int64 {
+func (x *LinkFault) GetReorderWindowMs() int64 {
 	if x != nil {
-		return x.Timestamp
+		return x.ReorderWindowMs
 	}
 	return 0
 }
 
-type PbftResponse struct {
+type LinkFaultArgs struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Links         []*LinkFault           `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"` // Thay thế toàn bộ cấu hình cũ; rỗng = heal
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PbftResponse) Reset() {
+func (x *LinkFaultArgs) Reset() {
+	*x = LinkFaultArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkFaultArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkFaultArgs) ProtoMessage() {}
+
+func (x *LinkFaultArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkFaultArgs.ProtoReflect.Descriptor instead.
+func (*LinkFaultArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LinkFaultArgs) GetLinks() []*LinkFault {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type LinkFaultReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkFaultReply) Reset() {
+	*x = LinkFaultReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkFaultReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkFaultReply) ProtoMessage() {}
+
+func (x *LinkFaultReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkFaultReply.ProtoReflect.Descriptor instead.
+func (*LinkFaultReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *LinkFaultReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type LedgerArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromIndex     int64                  `protobuf:"varint,1,opt,name=from_index,json=fromIndex,proto3" json:"from_index,omitempty"`
+	GroupId       int32                  `protobuf:"varint,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"` // Raft Multi-Raft; pBFT bỏ qua
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LedgerArgs) Reset() {
+	*x = LedgerArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerArgs) ProtoMessage() {}
+
+func (x *LedgerArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerArgs.ProtoReflect.Descriptor instead.
+func (*LedgerArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LedgerArgs) GetFromIndex() int64 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+func (x *LedgerArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+// Raft: index/term/command của LogEntry. pBFT: sequence/hash/prev_hash/data của Block.
+type LedgerEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Term          int64                  `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	PrevHash      string                 `protobuf:"bytes,4,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Data          string                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Requests      []*PbftRequest         `protobuf:"bytes,6,rep,name=requests,proto3" json:"requests,omitempty"` // pBFT: batch request trong block, để node nhận state tính lại hash
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LedgerEntry) Reset() {
+	*x = LedgerEntry{}
+	mi := &file_common_proto_consensus_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerEntry) ProtoMessage() {}
+
+func (x *LedgerEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerEntry.ProtoReflect.Descriptor instead.
+func (*LedgerEntry) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LedgerEntry) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetRequests() []*PbftRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type LedgerReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*LedgerEntry         `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LedgerReply) Reset() {
+	*x = LedgerReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerReply) ProtoMessage() {}
+
+func (x *LedgerReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerReply.ProtoReflect.Descriptor instead.
+func (*LedgerReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *LedgerReply) GetEntries() []*LedgerEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type WatchArgs struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Index entry đầu tiên muốn nhận; resume sau khi mất kết nối = index cuối đã nhận + 1.
+	// < 0: bắt đầu bằng snapshot trạng thái KV hiện tại rồi stream tiếp các entry sau đó.
+	FromIndex     int64 `protobuf:"varint,1,opt,name=from_index,json=fromIndex,proto3" json:"from_index,omitempty"`
+	GroupId       int32 `protobuf:"varint,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchArgs) Reset() {
+	*x = WatchArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchArgs) ProtoMessage() {}
+
+func (x *WatchArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchArgs.ProtoReflect.Descriptor instead.
+func (*WatchArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchArgs) GetFromIndex() int64 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+func (x *WatchArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+// Mỗi event chứa đúng một trong hai: entry đã commit hoặc snapshot
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entry         *LogEntry              `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+	Snapshot      *KVSnapshot            `protobuf:"bytes,2,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_common_proto_consensus_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchEvent) GetEntry() *LogEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetSnapshot() *KVSnapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type KVSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // Entry cuối đã apply vào snapshot; entry tiếp theo là index + 1
+	Kvs           []*KeyValue            `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	Locks         []*LockLease           `protobuf:"bytes,3,rep,name=locks,proto3" json:"locks,omitempty"` // Lease đang giữ trong bảng lock tại index
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVSnapshot) Reset() {
+	*x = KVSnapshot{}
+	mi := &file_common_proto_consensus_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVSnapshot) ProtoMessage() {}
+
+func (x *KVSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVSnapshot.ProtoReflect.Descriptor instead.
+func (*KVSnapshot) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *KVSnapshot) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *KVSnapshot) GetKvs() []*KeyValue {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+func (x *KVSnapshot) GetLocks() []*LockLease {
+	if x != nil {
+		return x.Locks
+	}
+	return nil
+}
+
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // Index của LogEntry ghi key lần cuối
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_common_proto_consensus_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValue) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type KVPutArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVPutArgs) Reset() {
+	*x = KVPutArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVPutArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVPutArgs) ProtoMessage() {}
+
+func (x *KVPutArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVPutArgs.ProtoReflect.Descriptor instead.
+func (*KVPutArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *KVPutArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVPutArgs) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type KVGetArgs struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Follower read: > 0 cho phép node nhận yêu cầu đọc bản sao cục bộ nếu trễ
+	// không quá bound (cả hai cùng đặt thì phải thoả cả hai); vượt bound thì đọc qua Leader
+	MaxStalenessMs int64 `protobuf:"varint,2,opt,name=max_staleness_ms,json=maxStalenessMs,proto3" json:"max_staleness_ms,omitempty"`
+	MaxLagEntries  int64 `protobuf:"varint,3,opt,name=max_lag_entries,json=maxLagEntries,proto3" json:"max_lag_entries,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *KVGetArgs) Reset() {
+	*x = KVGetArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVGetArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVGetArgs) ProtoMessage() {}
+
+func (x *KVGetArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVGetArgs.ProtoReflect.Descriptor instead.
+func (*KVGetArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *KVGetArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVGetArgs) GetMaxStalenessMs() int64 {
+	if x != nil {
+		return x.MaxStalenessMs
+	}
+	return 0
+}
+
+func (x *KVGetArgs) GetMaxLagEntries() int64 {
+	if x != nil {
+		return x.MaxLagEntries
+	}
+	return 0
+}
+
+type KVDeleteArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVDeleteArgs) Reset() {
+	*x = KVDeleteArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVDeleteArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVDeleteArgs) ProtoMessage() {}
+
+func (x *KVDeleteArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVDeleteArgs.ProtoReflect.Descriptor instead.
+func (*KVDeleteArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *KVDeleteArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type KVCasArgs struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 0 = key phải chưa tồn tại
+	Value           string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KVCasArgs) Reset() {
+	*x = KVCasArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVCasArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVCasArgs) ProtoMessage() {}
+
+func (x *KVCasArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVCasArgs.ProtoReflect.Descriptor instead.
+func (*KVCasArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *KVCasArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVCasArgs) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *KVCasArgs) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type KVScanArgs struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Start          string                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`                                            // [start, end)
+	End            string                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`                                                // Rỗng = tới key cuối
+	Limit          int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                                           // 0 = không giới hạn
+	MaxStalenessMs int64                  `protobuf:"varint,4,opt,name=max_staleness_ms,json=maxStalenessMs,proto3" json:"max_staleness_ms,omitempty"` // Như KVGetArgs
+	MaxLagEntries  int64                  `protobuf:"varint,5,opt,name=max_lag_entries,json=maxLagEntries,proto3" json:"max_lag_entries,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *KVScanArgs) Reset() {
+	*x = KVScanArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVScanArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVScanArgs) ProtoMessage() {}
+
+func (x *KVScanArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVScanArgs.ProtoReflect.Descriptor instead.
+func (*KVScanArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *KVScanArgs) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *KVScanArgs) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *KVScanArgs) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *KVScanArgs) GetMaxStalenessMs() int64 {
+	if x != nil {
+		return x.MaxStalenessMs
+	}
+	return 0
+}
+
+func (x *KVScanArgs) GetMaxLagEntries() int64 {
+	if x != nil {
+		return x.MaxLagEntries
+	}
+	return 0
+}
+
+type KVReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`                                // Get/Delete: key có tồn tại không
+	Kv            *KeyValue              `protobuf:"bytes,3,opt,name=kv,proto3" json:"kv,omitempty"`                                       // Giá trị hiện tại (CAS thất bại: giá trị đang có)
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`                                 // "not leader", "version mismatch", ...
+	LeaderId      int32                  `protobuf:"varint,5,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`          // Gợi ý Leader khi node không phải Leader (-1 = chưa biết)
+	StalenessMs   int64                  `protobuf:"varint,6,opt,name=staleness_ms,json=stalenessMs,proto3" json:"staleness_ms,omitempty"` // Follower read: độ trễ tối đa so với Leader (0 = linearizable)
+	LagEntries    int64                  `protobuf:"varint,7,opt,name=lag_entries,json=lagEntries,proto3" json:"lag_entries,omitempty"`    // Follower read: số entry Leader đã commit mà bản sao chưa apply
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVReply) Reset() {
+	*x = KVReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVReply) ProtoMessage() {}
+
+func (x *KVReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVReply.ProtoReflect.Descriptor instead.
+func (*KVReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *KVReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KVReply) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *KVReply) GetKv() *KeyValue {
+	if x != nil {
+		return x.Kv
+	}
+	return nil
+}
+
+func (x *KVReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *KVReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *KVReply) GetStalenessMs() int64 {
+	if x != nil {
+		return x.StalenessMs
+	}
+	return 0
+}
+
+func (x *KVReply) GetLagEntries() int64 {
+	if x != nil {
+		return x.LagEntries
+	}
+	return 0
+}
+
+type KVScanReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Kvs           []*KeyValue            `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	LeaderId      int32                  `protobuf:"varint,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	StalenessMs   int64                  `protobuf:"varint,5,opt,name=staleness_ms,json=stalenessMs,proto3" json:"staleness_ms,omitempty"`
+	LagEntries    int64                  `protobuf:"varint,6,opt,name=lag_entries,json=lagEntries,proto3" json:"lag_entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVScanReply) Reset() {
+	*x = KVScanReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVScanReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVScanReply) ProtoMessage() {}
+
+func (x *KVScanReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVScanReply.ProtoReflect.Descriptor instead.
+func (*KVScanReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *KVScanReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KVScanReply) GetKvs() []*KeyValue {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+func (x *KVScanReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *KVScanReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *KVScanReply) GetStalenessMs() int64 {
+	if x != nil {
+		return x.StalenessMs
+	}
+	return 0
+}
+
+func (x *KVScanReply) GetLagEntries() int64 {
+	if x != nil {
+		return x.LagEntries
+	}
+	return 0
+}
+
+// Điều kiện trong read-set: version hiện tại của key phải bằng `version`
+type KVCompare struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // 0 = key phải chưa tồn tại
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVCompare) Reset() {
+	*x = KVCompare{}
+	mi := &file_common_proto_consensus_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVCompare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVCompare) ProtoMessage() {}
+
+func (x *KVCompare) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVCompare.ProtoReflect.Descriptor instead.
+func (*KVCompare) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *KVCompare) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVCompare) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type KVWrite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Delete        bool                   `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVWrite) Reset() {
+	*x = KVWrite{}
+	mi := &file_common_proto_consensus_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVWrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVWrite) ProtoMessage() {}
+
+func (x *KVWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVWrite.ProtoReflect.Descriptor instead.
+func (*KVWrite) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *KVWrite) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVWrite) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KVWrite) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+type KVTxnArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compares      []*KVCompare           `protobuf:"bytes,1,rep,name=compares,proto3" json:"compares,omitempty"`
+	Writes        []*KVWrite             `protobuf:"bytes,2,rep,name=writes,proto3" json:"writes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVTxnArgs) Reset() {
+	*x = KVTxnArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVTxnArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVTxnArgs) ProtoMessage() {}
+
+func (x *KVTxnArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVTxnArgs.ProtoReflect.Descriptor instead.
+func (*KVTxnArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *KVTxnArgs) GetCompares() []*KVCompare {
+	if x != nil {
+		return x.Compares
+	}
+	return nil
+}
+
+func (x *KVTxnArgs) GetWrites() []*KVWrite {
+	if x != nil {
+		return x.Writes
+	}
+	return nil
+}
+
+// Kết quả từng key: compares trước, writes sau, theo thứ tự trong request
+type KVKeyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Kv            *KeyValue              `protobuf:"bytes,3,opt,name=kv,proto3" json:"kv,omitempty"`       // Compare: giá trị hiện tại; Write: giá trị sau khi ghi
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"` // "version mismatch", "aborted"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVKeyResult) Reset() {
+	*x = KVKeyResult{}
+	mi := &file_common_proto_consensus_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVKeyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVKeyResult) ProtoMessage() {}
+
+func (x *KVKeyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVKeyResult.ProtoReflect.Descriptor instead.
+func (*KVKeyResult) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *KVKeyResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVKeyResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KVKeyResult) GetKv() *KeyValue {
+	if x != nil {
+		return x.Kv
+	}
+	return nil
+}
+
+func (x *KVKeyResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type KVTxnReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // true = đã commit toàn bộ writes
+	Results       []*KVKeyResult         `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	LeaderId      int32                  `protobuf:"varint,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVTxnReply) Reset() {
+	*x = KVTxnReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVTxnReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVTxnReply) ProtoMessage() {}
+
+func (x *KVTxnReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVTxnReply.ProtoReflect.Descriptor instead.
+func (*KVTxnReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *KVTxnReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KVTxnReply) GetResults() []*KVKeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *KVTxnReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *KVTxnReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type LockArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`               // Định danh client giữ lock
+	TtlMs         int64                  `protobuf:"varint,3,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"` // Acquire/Renew: thời hạn lease
+	Token         int64                  `protobuf:"varint,4,opt,name=token,proto3" json:"token,omitempty"`              // Release/Renew: fencing token nhận được khi Acquire
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockArgs) Reset() {
+	*x = LockArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockArgs) ProtoMessage() {}
+
+func (x *LockArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockArgs.ProtoReflect.Descriptor instead.
+func (*LockArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *LockArgs) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LockArgs) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *LockArgs) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+func (x *LockArgs) GetToken() int64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+type LockReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                   // "held", "not holder", "lease expired", "not leader"
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`                                   // Chủ hiện tại của lock
+	Token         int64                  `protobuf:"varint,4,opt,name=token,proto3" json:"token,omitempty"`                                  // Fencing token của lease hiện tại
+	ExpiresAtMs   int64                  `protobuf:"varint,5,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"` // Unix ms theo đồng hồ Leader
+	LeaderId      int32                  `protobuf:"varint,6,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockReply) Reset() {
+	*x = LockReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockReply) ProtoMessage() {}
+
+func (x *LockReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockReply.ProtoReflect.Descriptor instead.
+func (*LockReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *LockReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *LockReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *LockReply) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *LockReply) GetToken() int64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+func (x *LockReply) GetExpiresAtMs() int64 {
+	if x != nil {
+		return x.ExpiresAtMs
+	}
+	return 0
+}
+
+func (x *LockReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type LockWatchArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockWatchArgs) Reset() {
+	*x = LockWatchArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockWatchArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockWatchArgs) ProtoMessage() {}
+
+func (x *LockWatchArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockWatchArgs.ProtoReflect.Descriptor instead.
+func (*LockWatchArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *LockWatchArgs) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type LockEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`   // "released" | "expired"
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"` // Chủ vừa mất lock
+	Token         int64                  `protobuf:"varint,4,opt,name=token,proto3" json:"token,omitempty"`
+	Index         int64                  `protobuf:"varint,5,opt,name=index,proto3" json:"index,omitempty"` // Index entry gây ra sự kiện
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockEvent) Reset() {
+	*x = LockEvent{}
+	mi := &file_common_proto_consensus_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockEvent) ProtoMessage() {}
+
+func (x *LockEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockEvent.ProtoReflect.Descriptor instead.
+func (*LockEvent) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *LockEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LockEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *LockEvent) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *LockEvent) GetToken() int64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+func (x *LockEvent) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type LockLease struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Token         int64                  `protobuf:"varint,3,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAtMs   int64                  `protobuf:"varint,4,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"` // Unix ms theo đồng hồ Leader lúc propose
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockLease) Reset() {
+	*x = LockLease{}
+	mi := &file_common_proto_consensus_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockLease) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockLease) ProtoMessage() {}
+
+func (x *LockLease) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockLease.ProtoReflect.Descriptor instead.
+func (*LockLease) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *LockLease) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LockLease) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *LockLease) GetToken() int64 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+func (x *LockLease) GetExpiresAtMs() int64 {
+	if x != nil {
+		return x.ExpiresAtMs
+	}
+	return 0
+}
+
+// =========================================================
+// RANGE SHARDING
+// =========================================================
+type ShardRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         string                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"` // Rỗng = tới cuối keyspace
+	GroupId       int32                  `protobuf:"varint,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardRange) Reset() {
+	*x = ShardRange{}
+	mi := &file_common_proto_consensus_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardRange) ProtoMessage() {}
+
+func (x *ShardRange) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardRange.ProtoReflect.Descriptor instead.
+func (*ShardRange) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ShardRange) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ShardRange) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ShardRange) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type ShardMapReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ranges        []*ShardRange          `protobuf:"bytes,1,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // Index entry metadata thay đổi map lần cuối
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardMapReply) Reset() {
+	*x = ShardMapReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardMapReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardMapReply) ProtoMessage() {}
+
+func (x *ShardMapReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardMapReply.ProtoReflect.Descriptor instead.
+func (*ShardMapReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ShardMapReply) GetRanges() []*ShardRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *ShardMapReply) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ShardSplitArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	GroupId       int32                  `protobuf:"varint,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardSplitArgs) Reset() {
+	*x = ShardSplitArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardSplitArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardSplitArgs) ProtoMessage() {}
+
+func (x *ShardSplitArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardSplitArgs.ProtoReflect.Descriptor instead.
+func (*ShardSplitArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ShardSplitArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ShardSplitArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type ShardMergeArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardMergeArgs) Reset() {
+	*x = ShardMergeArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardMergeArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardMergeArgs) ProtoMessage() {}
+
+func (x *ShardMergeArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardMergeArgs.ProtoReflect.Descriptor instead.
+func (*ShardMergeArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ShardMergeArgs) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ShardReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Ranges        []*ShardRange          `protobuf:"bytes,3,rep,name=ranges,proto3" json:"ranges,omitempty"`                      // Map sau khi thay đổi
+	LeaderId      int32                  `protobuf:"varint,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"` // Leader nhóm metadata đã thực hiện
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardReply) Reset() {
+	*x = ShardReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardReply) ProtoMessage() {}
+
+func (x *ShardReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardReply.ProtoReflect.Descriptor instead.
+func (*ShardReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ShardReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ShardReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ShardReply) GetRanges() []*ShardRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *ShardReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type ShardSubmitArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int32                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardSubmitArgs) Reset() {
+	*x = ShardSubmitArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardSubmitArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardSubmitArgs) ProtoMessage() {}
+
+func (x *ShardSubmitArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardSubmitArgs.ProtoReflect.Descriptor instead.
+func (*ShardSubmitArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ShardSubmitArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *ShardSubmitArgs) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type ShardSubmitReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Result        string                 `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"` // Kết quả state machine dạng JSON
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	LeaderId      int32                  `protobuf:"varint,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardSubmitReply) Reset() {
+	*x = ShardSubmitReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardSubmitReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardSubmitReply) ProtoMessage() {}
+
+func (x *ShardSubmitReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardSubmitReply.ProtoReflect.Descriptor instead.
+func (*ShardSubmitReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ShardSubmitReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ShardSubmitReply) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *ShardSubmitReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ShardSubmitReply) GetLeaderId() int32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+type ShardReadArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int32                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	Start         string                 `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShardReadArgs) Reset() {
+	*x = ShardReadArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShardReadArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShardReadArgs) ProtoMessage() {}
+
+func (x *ShardReadArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShardReadArgs.ProtoReflect.Descriptor instead.
+func (*ShardReadArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ShardReadArgs) GetGroupId() int32 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *ShardReadArgs) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ShardReadArgs) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ShardReadArgs) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type PbftMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Header
+	Type   string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                   // "PrePrepare", "Prepare", "Commit", "Checkpoint", "ViewChange", "NewView", "NewKey"
+	NodeId string `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // pBFT dùng string ID (VD: "node1")
+	// Payload (View/Sequence)
+	View     int64 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"` // Thay cho Epoch (để rõ nghĩa pBFT)
+	Sequence int64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Block Info
+	BlockHash      string            `protobuf:"bytes,5,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	PrevBlockHash  string            `protobuf:"bytes,6,opt,name=prev_block_hash,json=prevBlockHash,proto3" json:"prev_block_hash,omitempty"`
+	Data           string            `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"` // Nội dung Block
+	Timestamp      int64             `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Requests       []*PbftRequest    `protobuf:"bytes,9,rep,name=requests,proto3" json:"requests,omitempty"`                                                                                        // PrePrepare: batch request của client được gán sequence (rỗng: null request)
+	Signature      []byte            `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`                                                                                     // ed25519 của node_id trên các trường còn lại
+	SessionKey     []byte            `protobuf:"bytes,11,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"`                                                                 // NewKey: public key X25519 tạm thời để lập session key với từng node
+	Authenticators map[string][]byte `protobuf:"bytes,12,rep,name=authenticators,proto3" json:"authenticators,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Chế độ MAC: HMAC cho từng node nhận, theo NodeID
+	// ViewChange <VIEW-CHANGE, v+1, n, C, P, i>: sequence/block_hash là checkpoint ổn định (n, digest)
+	CheckpointProof []*PbftMessage  `protobuf:"bytes,13,rep,name=checkpoint_proof,json=checkpointProof,proto3" json:"checkpoint_proof,omitempty"` // C: Checkpoint của 2f+1 node chứng minh checkpoint n
+	Prepared        []*PreparedCert `protobuf:"bytes,14,rep,name=prepared,proto3" json:"prepared,omitempty"`                                      // P: chứng chỉ prepared của mỗi sequence > n
+	// NewView <NEW-VIEW, v+1, V, O>
+	ViewChanges   []*PbftMessage `protobuf:"bytes,15,rep,name=view_changes,json=viewChanges,proto3" json:"view_changes,omitempty"` // V: 2f+1 ViewChange hợp lệ cho view mới
+	PrePrepares   []*PbftMessage `protobuf:"bytes,16,rep,name=pre_prepares,json=prePrepares,proto3" json:"pre_prepares,omitempty"` // O: PrePrepare phát lại trong view mới (kể cả null request)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PbftMessage) Reset() {
+	*x = PbftMessage{}
+	mi := &file_common_proto_consensus_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PbftMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PbftMessage) ProtoMessage() {}
+
+func (x *PbftMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PbftMessage.ProtoReflect.Descriptor instead.
+func (*PbftMessage) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *PbftMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetView() int64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetPrevBlockHash() string {
+	if x != nil {
+		return x.PrevBlockHash
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetRequests() []*PbftRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetSessionKey() []byte {
+	if x != nil {
+		return x.SessionKey
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetAuthenticators() map[string][]byte {
+	if x != nil {
+		return x.Authenticators
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetCheckpointProof() []*PbftMessage {
+	if x != nil {
+		return x.CheckpointProof
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetPrepared() []*PreparedCert {
+	if x != nil {
+		return x.Prepared
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetViewChanges() []*PbftMessage {
+	if x != nil {
+		return x.ViewChanges
+	}
+	return nil
+}
+
+func (x *PbftMessage) GetPrePrepares() []*PbftMessage {
+	if x != nil {
+		return x.PrePrepares
+	}
+	return nil
+}
+
+// Chứng chỉ prepared: PrePrepare và 2f Prepare khớp digest từ các Backup khác nhau
+type PreparedCert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PrePrepare    *PbftMessage           `protobuf:"bytes,1,opt,name=pre_prepare,json=prePrepare,proto3" json:"pre_prepare,omitempty"`
+	Prepares      []*PbftMessage         `protobuf:"bytes,2,rep,name=prepares,proto3" json:"prepares,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreparedCert) Reset() {
+	*x = PreparedCert{}
+	mi := &file_common_proto_consensus_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreparedCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreparedCert) ProtoMessage() {}
+
+func (x *PreparedCert) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreparedCert.ProtoReflect.Descriptor instead.
+func (*PreparedCert) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *PreparedCert) GetPrePrepare() *PbftMessage {
+	if x != nil {
+		return x.PrePrepare
+	}
+	return nil
+}
+
+func (x *PreparedCert) GetPrepares() []*PbftMessage {
+	if x != nil {
+		return x.Prepares
+	}
+	return nil
+}
+
+// Request <REQUEST, o, t, c> của client
+type PbftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Tăng dần theo từng client; request cũ hơn lần thực thi cuối bị bỏ qua
+	Operation     []byte                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`  // Payload tuỳ ý của ứng dụng
+	Signature     []byte                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`  // Chữ ký ed25519 của client trên request (trừ trường này)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PbftRequest) Reset() {
+	*x = PbftRequest{}
+	mi := &file_common_proto_consensus_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PbftRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PbftRequest) ProtoMessage() {}
+
+func (x *PbftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PbftRequest.ProtoReflect.Descriptor instead.
+func (*PbftRequest) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *PbftRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *PbftRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PbftRequest) GetOperation() []byte {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+func (x *PbftRequest) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// Reply <REPLY, v, t, c, i, r> gửi cho client sau khi thực thi; r là
+// sequence và hash của block chứa request
+type PbftReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	View          int64                  `protobuf:"varint,1,opt,name=view,proto3" json:"view,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ClientId      string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	NodeId        string                 `protobuf:"bytes,4,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Sequence      int64                  `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	BlockHash     string                 `protobuf:"bytes,6,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`                     // ed25519 của replica (chế độ chữ ký; reply mở đầu stream ở chế độ MAC)
+	Authenticator []byte                 `protobuf:"bytes,8,opt,name=authenticator,proto3" json:"authenticator,omitempty"`             // HMAC bằng session key giữa replica và client (chế độ MAC)
+	SessionKey    []byte                 `protobuf:"bytes,9,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"` // Reply mở đầu stream ở chế độ MAC: public key X25519 của replica
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PbftReply) Reset() {
+	*x = PbftReply{}
+	mi := &file_common_proto_consensus_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PbftReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PbftReply) ProtoMessage() {}
+
+func (x *PbftReply) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PbftReply.ProtoReflect.Descriptor instead.
+func (*PbftReply) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *PbftReply) GetView() int64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *PbftReply) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PbftReply) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *PbftReply) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *PbftReply) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *PbftReply) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *PbftReply) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *PbftReply) GetAuthenticator() []byte {
+	if x != nil {
+		return x.Authenticator
+	}
+	return nil
+}
+
+func (x *PbftReply) GetSessionKey() []byte {
+	if x != nil {
+		return x.SessionKey
+	}
+	return nil
+}
+
+type ReplyWatchArgs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	SessionKey    []byte                 `protobuf:"bytes,2,opt,name=session_key,json=sessionKey,proto3" json:"session_key,omitempty"` // Public key X25519 tạm thời của client (chế độ MAC)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplyWatchArgs) Reset() {
+	*x = ReplyWatchArgs{}
+	mi := &file_common_proto_consensus_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplyWatchArgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplyWatchArgs) ProtoMessage() {}
+
+func (x *ReplyWatchArgs) ProtoReflect() protoreflect.Message {
+	mi := &file_common_proto_consensus_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplyWatchArgs.ProtoReflect.Descriptor instead.
+func (*ReplyWatchArgs) Descriptor() ([]byte, []int) {
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ReplyWatchArgs) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ReplyWatchArgs) GetSessionKey() []byte {
+	if x != nil {
+		return x.SessionKey
+	}
+	return nil
+}
+
+type PbftResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PbftResponse) Reset() {
 	*x = PbftResponse{}
-	mi := &file_common_proto_consensus_proto_msgTypes[12]
+	mi := &file_common_proto_consensus_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -705,7 +3476,7 @@ func (x *PbftResponse) String() string {
 func (*PbftResponse) ProtoMessage() {}
 
 func (x *PbftResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_common_proto_consensus_proto_msgTypes[12]
+	mi := &file_common_proto_consensus_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -718,7 +3489,7 @@ func (x *PbftResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PbftResponse.ProtoReflect.Descriptor instead.
 func (*PbftResponse) Descriptor() ([]byte, []int) {
-	return file_common_proto_consensus_proto_rawDescGZIP(), []int{12}
+	return file_common_proto_consensus_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *PbftResponse) GetSuccess() bool {
@@ -744,33 +3515,213 @@ const file_common_proto_consensus_proto_rawDesc = "" +
 	"\bLogEntry\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x14\n" +
 	"\x05index\x18\x02 \x01(\x03R\x05index\x12\x18\n" +
-	"\acommand\x18\x03 \x01(\tR\acommand\"G\n" +
+	"\acommand\x18\x03 \x01(\tR\acommand\"\xa8\x01\n" +
 	"\x0fRequestVoteArgs\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x03R\x04term\x12 \n" +
-	"\vcandidateId\x18\x02 \x01(\x05R\vcandidateId\"I\n" +
+	"\vcandidateId\x18\x02 \x01(\x05R\vcandidateId\x12\"\n" +
+	"\flastLogIndex\x18\x03 \x01(\x03R\flastLogIndex\x12 \n" +
+	"\vlastLogTerm\x18\x04 \x01(\x03R\vlastLogTerm\x12\x19\n" +
+	"\bgroup_id\x18\x05 \x01(\x05R\agroupId\"I\n" +
 	"\x10RequestVoteReply\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x03R\x04term\x12!\n" +
-	"\fvote_granted\x18\x02 \x01(\bR\vvoteGranted\"o\n" +
+	"\fvote_granted\x18\x02 \x01(\bR\vvoteGranted\"\x9e\x02\n" +
 	"\x11AppendEntriesArgs\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1a\n" +
 	"\bleaderId\x18\x02 \x01(\x05R\bleaderId\x12*\n" +
-	"\aentries\x18\x03 \x03(\v2\x10.common.LogEntryR\aentries\"B\n" +
+	"\aentries\x18\x03 \x03(\v2\x10.common.LogEntryR\aentries\x12\"\n" +
+	"\fleaderCommit\x18\x04 \x01(\x03R\fleaderCommit\x12\x19\n" +
+	"\bgroup_id\x18\x05 \x01(\x05R\agroupId\x12$\n" +
+	"\x0eprev_log_index\x18\x06 \x01(\x03R\fprevLogIndex\x12\"\n" +
+	"\rprev_log_term\x18\a \x01(\x03R\vprevLogTerm\x12$\n" +
+	"\x0eleader_time_ms\x18\b \x01(\x03R\fleaderTimeMs\"c\n" +
 	"\x12AppendEntriesReply\x12\x12\n" +
 	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\"G\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x1f\n" +
+	"\vmatch_index\x18\x03 \x01(\x03R\n" +
+	"matchIndex\"\\\n" +
+	"\x0eTimeoutNowArgs\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x03R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\x05R\bleaderId\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\x05R\agroupId\"K\n" +
+	"\x16AppendEntriesBatchArgs\x121\n" +
+	"\x06groups\x18\x01 \x03(\v2\x19.common.AppendEntriesArgsR\x06groups\"M\n" +
+	"\x17AppendEntriesBatchReply\x122\n" +
+	"\x06groups\x18\x01 \x03(\v2\x1a.common.AppendEntriesReplyR\x06groups\"e\n" +
 	"\vStatusReply\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05state\x18\x02 \x01(\tR\x05state\x12\x12\n" +
-	"\x04term\x18\x03 \x01(\x03R\x04term\"'\n" +
+	"\x04term\x18\x03 \x01(\x03R\x04term\x12\x1c\n" +
+	"\tcommitted\x18\x04 \x01(\x03R\tcommitted\"T\n" +
 	"\vProposeArgs\x12\x18\n" +
-	"\acommand\x18\x01 \x01(\tR\acommand\"E\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\x05R\agroupId\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\"E\n" +
 	"\fProposeReply\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1b\n" +
 	"\tleader_id\x18\x02 \x01(\x05R\bleaderId\"9\n" +
 	"\rPartitionArgs\x12(\n" +
 	"\x0fisolatedNodeIds\x18\x01 \x03(\x05R\x0fisolatedNodeIds\"*\n" +
 	"\x0ePartitionReply\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xe3\x01\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x89\x02\n" +
+	"\tLinkFault\x12\x17\n" +
+	"\ato_node\x18\x01 \x01(\x05R\x06toNode\x12\x18\n" +
+	"\ablocked\x18\x02 \x01(\bR\ablocked\x12\x1b\n" +
+	"\tdrop_rate\x18\x03 \x01(\x01R\bdropRate\x12\x19\n" +
+	"\bdelay_ms\x18\x04 \x01(\x03R\adelayMs\x12\x1b\n" +
+	"\tjitter_ms\x18\x05 \x01(\x03R\bjitterMs\x12%\n" +
+	"\x0eduplicate_rate\x18\x06 \x01(\x01R\rduplicateRate\x12!\n" +
+	"\freorder_rate\x18\a \x01(\x01R\vreorderRate\x12*\n" +
+	"\x11reorder_window_ms\x18\b \x01(\x03R\x0freorderWindowMs\"8\n" +
+	"\rLinkFaultArgs\x12'\n" +
+	"\x05links\x18\x01 \x03(\v2\x11.common.LinkFaultR\x05links\"*\n" +
+	"\x0eLinkFaultReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"F\n" +
+	"\n" +
+	"LedgerArgs\x12\x1d\n" +
+	"\n" +
+	"from_index\x18\x01 \x01(\x03R\tfromIndex\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\x05R\agroupId\"\xad\x01\n" +
+	"\vLedgerEntry\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x03R\x04term\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x1b\n" +
+	"\tprev_hash\x18\x04 \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\tR\x04data\x12/\n" +
+	"\brequests\x18\x06 \x03(\v2\x13.common.PbftRequestR\brequests\"<\n" +
+	"\vLedgerReply\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.common.LedgerEntryR\aentries\"E\n" +
+	"\tWatchArgs\x12\x1d\n" +
+	"\n" +
+	"from_index\x18\x01 \x01(\x03R\tfromIndex\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\x05R\agroupId\"d\n" +
+	"\n" +
+	"WatchEvent\x12&\n" +
+	"\x05entry\x18\x01 \x01(\v2\x10.common.LogEntryR\x05entry\x12.\n" +
+	"\bsnapshot\x18\x02 \x01(\v2\x12.common.KVSnapshotR\bsnapshot\"o\n" +
+	"\n" +
+	"KVSnapshot\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\"\n" +
+	"\x03kvs\x18\x02 \x03(\v2\x10.common.KeyValueR\x03kvs\x12'\n" +
+	"\x05locks\x18\x03 \x03(\v2\x11.common.LockLeaseR\x05locks\"L\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"3\n" +
+	"\tKVPutArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"o\n" +
+	"\tKVGetArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x10max_staleness_ms\x18\x02 \x01(\x03R\x0emaxStalenessMs\x12&\n" +
+	"\x0fmax_lag_entries\x18\x03 \x01(\x03R\rmaxLagEntries\" \n" +
+	"\fKVDeleteArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"^\n" +
+	"\tKVCasArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x03R\x0fexpectedVersion\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"\x9c\x01\n" +
+	"\n" +
+	"KVScanArgs\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12(\n" +
+	"\x10max_staleness_ms\x18\x04 \x01(\x03R\x0emaxStalenessMs\x12&\n" +
+	"\x0fmax_lag_entries\x18\x05 \x01(\x03R\rmaxLagEntries\"\xd2\x01\n" +
+	"\aKVReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12 \n" +
+	"\x02kv\x18\x03 \x01(\v2\x10.common.KeyValueR\x02kv\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1b\n" +
+	"\tleader_id\x18\x05 \x01(\x05R\bleaderId\x12!\n" +
+	"\fstaleness_ms\x18\x06 \x01(\x03R\vstalenessMs\x12\x1f\n" +
+	"\vlag_entries\x18\a \x01(\x03R\n" +
+	"lagEntries\"\xc2\x01\n" +
+	"\vKVScanReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\"\n" +
+	"\x03kvs\x18\x02 \x03(\v2\x10.common.KeyValueR\x03kvs\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\x05R\bleaderId\x12!\n" +
+	"\fstaleness_ms\x18\x05 \x01(\x03R\vstalenessMs\x12\x1f\n" +
+	"\vlag_entries\x18\x06 \x01(\x03R\n" +
+	"lagEntries\"7\n" +
+	"\tKVCompare\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"I\n" +
+	"\aKVWrite\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\bR\x06delete\"c\n" +
+	"\tKVTxnArgs\x12-\n" +
+	"\bcompares\x18\x01 \x03(\v2\x11.common.KVCompareR\bcompares\x12'\n" +
+	"\x06writes\x18\x02 \x03(\v2\x0f.common.KVWriteR\x06writes\"q\n" +
+	"\vKVKeyResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12 \n" +
+	"\x02kv\x18\x03 \x01(\v2\x10.common.KeyValueR\x02kv\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\x88\x01\n" +
+	"\n" +
+	"KVTxnReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12-\n" +
+	"\aresults\x18\x02 \x03(\v2\x13.common.KVKeyResultR\aresults\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\x05R\bleaderId\"a\n" +
+	"\bLockArgs\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x15\n" +
+	"\x06ttl_ms\x18\x03 \x01(\x03R\x05ttlMs\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\x03R\x05token\"\xa8\x01\n" +
+	"\tLockReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\x03R\x05token\x12\"\n" +
+	"\rexpires_at_ms\x18\x05 \x01(\x03R\vexpiresAtMs\x12\x1b\n" +
+	"\tleader_id\x18\x06 \x01(\x05R\bleaderId\"#\n" +
+	"\rLockWatchArgs\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"u\n" +
+	"\tLockEvent\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\x03R\x05token\x12\x14\n" +
+	"\x05index\x18\x05 \x01(\x03R\x05index\"o\n" +
+	"\tLockLease\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\x03R\x05token\x12\"\n" +
+	"\rexpires_at_ms\x18\x04 \x01(\x03R\vexpiresAtMs\"O\n" +
+	"\n" +
+	"ShardRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\x05R\agroupId\"U\n" +
+	"\rShardMapReply\x12*\n" +
+	"\x06ranges\x18\x01 \x03(\v2\x12.common.ShardRangeR\x06ranges\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"=\n" +
+	"\x0eShardSplitArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\x05R\agroupId\"\"\n" +
+	"\x0eShardMergeArgs\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x85\x01\n" +
+	"\n" +
+	"ShardReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12*\n" +
+	"\x06ranges\x18\x03 \x03(\v2\x12.common.ShardRangeR\x06ranges\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\x05R\bleaderId\"F\n" +
+	"\x0fShardSubmitArgs\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x05R\agroupId\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\"w\n" +
+	"\x10ShardSubmitReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x16\n" +
+	"\x06result\x18\x02 \x01(\tR\x06result\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\x05R\bleaderId\"h\n" +
+	"\rShardReadArgs\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x05R\agroupId\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\tR\x03end\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\xc9\x05\n" +
 	"\vPbftMessage\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x17\n" +
 	"\anode_id\x18\x02 \x01(\tR\x06nodeId\x12\x12\n" +
@@ -780,18 +3731,82 @@ const file_common_proto_consensus_proto_rawDesc = "" +
 	"block_hash\x18\x05 \x01(\tR\tblockHash\x12&\n" +
 	"\x0fprev_block_hash\x18\x06 \x01(\tR\rprevBlockHash\x12\x12\n" +
 	"\x04data\x18\a \x01(\tR\x04data\x12\x1c\n" +
-	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\"B\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12/\n" +
+	"\brequests\x18\t \x03(\v2\x13.common.PbftRequestR\brequests\x12\x1c\n" +
+	"\tsignature\x18\n" +
+	" \x01(\fR\tsignature\x12\x1f\n" +
+	"\vsession_key\x18\v \x01(\fR\n" +
+	"sessionKey\x12O\n" +
+	"\x0eauthenticators\x18\f \x03(\v2'.common.PbftMessage.AuthenticatorsEntryR\x0eauthenticators\x12>\n" +
+	"\x10checkpoint_proof\x18\r \x03(\v2\x13.common.PbftMessageR\x0fcheckpointProof\x120\n" +
+	"\bprepared\x18\x0e \x03(\v2\x14.common.PreparedCertR\bprepared\x126\n" +
+	"\fview_changes\x18\x0f \x03(\v2\x13.common.PbftMessageR\vviewChanges\x126\n" +
+	"\fpre_prepares\x18\x10 \x03(\v2\x13.common.PbftMessageR\vprePrepares\x1aA\n" +
+	"\x13AuthenticatorsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"u\n" +
+	"\fPreparedCert\x124\n" +
+	"\vpre_prepare\x18\x01 \x01(\v2\x13.common.PbftMessageR\n" +
+	"prePrepare\x12/\n" +
+	"\bprepares\x18\x02 \x03(\v2\x13.common.PbftMessageR\bprepares\"\x84\x01\n" +
+	"\vPbftRequest\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\fR\toperation\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\"\x93\x02\n" +
+	"\tPbftReply\x12\x12\n" +
+	"\x04view\x18\x01 \x01(\x03R\x04view\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12\x17\n" +
+	"\anode_id\x18\x04 \x01(\tR\x06nodeId\x12\x1a\n" +
+	"\bsequence\x18\x05 \x01(\x03R\bsequence\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x06 \x01(\tR\tblockHash\x12\x1c\n" +
+	"\tsignature\x18\a \x01(\fR\tsignature\x12$\n" +
+	"\rauthenticator\x18\b \x01(\fR\rauthenticator\x12\x1f\n" +
+	"\vsession_key\x18\t \x01(\fR\n" +
+	"sessionKey\"N\n" +
+	"\x0eReplyWatchArgs\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x1f\n" +
+	"\vsession_key\x18\x02 \x01(\fR\n" +
+	"sessionKey\"B\n" +
 	"\fPbftResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xb6\x03\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2\xec\x06\n" +
 	"\x10ConsensusService\x12@\n" +
 	"\vRequestVote\x12\x17.common.RequestVoteArgs\x1a\x18.common.RequestVoteReply\x12F\n" +
-	"\rAppendEntries\x12\x19.common.AppendEntriesArgs\x1a\x1a.common.AppendEntriesReply\x12D\n" +
+	"\rAppendEntries\x12\x19.common.AppendEntriesArgs\x1a\x1a.common.AppendEntriesReply\x12U\n" +
+	"\x12AppendEntriesBatch\x12\x1e.common.AppendEntriesBatchArgs\x1a\x1f.common.AppendEntriesBatchReply\x123\n" +
+	"\n" +
+	"TimeoutNow\x12\x16.common.TimeoutNowArgs\x1a\r.common.Empty\x12D\n" +
 	"\x13SetNetworkPartition\x12\x15.common.PartitionArgs\x1a\x16.common.PartitionReply\x12/\n" +
 	"\tGetStatus\x12\r.common.Empty\x1a\x13.common.StatusReply\x124\n" +
 	"\aPropose\x12\x13.common.ProposeArgs\x1a\x14.common.ProposeReply\x12+\n" +
 	"\vForceLeader\x12\r.common.Empty\x1a\r.common.Empty\x12>\n" +
-	"\x11HandlePbftMessage\x12\x13.common.PbftMessage\x1a\x14.common.PbftResponseB\x0eZ\fcommon/protob\x06proto3"
+	"\rSetLinkFaults\x12\x15.common.LinkFaultArgs\x1a\x16.common.LinkFaultReply\x124\n" +
+	"\tGetLedger\x12\x12.common.LedgerArgs\x1a\x13.common.LedgerReply\x129\n" +
+	"\x0eWatchCommitted\x12\x11.common.WatchArgs\x1a\x12.common.WatchEvent0\x01\x12>\n" +
+	"\x11HandlePbftMessage\x12\x13.common.PbftMessage\x1a\x14.common.PbftResponse\x12:\n" +
+	"\rSubmitRequest\x12\x13.common.PbftRequest\x1a\x14.common.PbftResponse\x12;\n" +
+	"\fWatchReplies\x12\x16.common.ReplyWatchArgs\x1a\x11.common.PbftReply0\x012\xa7\x02\n" +
+	"\tKVService\x12)\n" +
+	"\x03Put\x12\x11.common.KVPutArgs\x1a\x0f.common.KVReply\x12)\n" +
+	"\x03Get\x12\x11.common.KVGetArgs\x1a\x0f.common.KVReply\x12/\n" +
+	"\x06Delete\x12\x14.common.KVDeleteArgs\x1a\x0f.common.KVReply\x124\n" +
+	"\x0eCompareAndSwap\x12\x11.common.KVCasArgs\x1a\x0f.common.KVReply\x12/\n" +
+	"\x04Scan\x12\x12.common.KVScanArgs\x1a\x13.common.KVScanReply\x12,\n" +
+	"\x03Txn\x12\x11.common.KVTxnArgs\x1a\x12.common.KVTxnReply2\xd0\x01\n" +
+	"\vLockService\x12.\n" +
+	"\aAcquire\x12\x10.common.LockArgs\x1a\x11.common.LockReply\x12.\n" +
+	"\aRelease\x12\x10.common.LockArgs\x1a\x11.common.LockReply\x12,\n" +
+	"\x05Renew\x12\x10.common.LockArgs\x1a\x11.common.LockReply\x123\n" +
+	"\x05Watch\x12\x15.common.LockWatchArgs\x1a\x11.common.LockEvent0\x012\x9e\x02\n" +
+	"\fShardService\x123\n" +
+	"\vGetShardMap\x12\r.common.Empty\x1a\x15.common.ShardMapReply\x123\n" +
+	"\x05Split\x12\x16.common.ShardSplitArgs\x1a\x12.common.ShardReply\x123\n" +
+	"\x05Merge\x12\x16.common.ShardMergeArgs\x1a\x12.common.ShardReply\x12;\n" +
+	"\x06Submit\x12\x17.common.ShardSubmitArgs\x1a\x18.common.ShardSubmitReply\x122\n" +
+	"\x04Read\x12\x15.common.ShardReadArgs\x1a\x13.common.KVScanReplyB\x0eZ\fcommon/protob\x06proto3"
 
 var (
 	file_common_proto_consensus_proto_rawDescOnce sync.Once
@@ -805,43 +3820,155 @@ func file_common_proto_consensus_proto_rawDescGZIP() []byte {
 	return file_common_proto_consensus_proto_rawDescData
 }
 
-var file_common_proto_consensus_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_common_proto_consensus_proto_msgTypes = make([]protoimpl.MessageInfo, 56)
 var file_common_proto_consensus_proto_goTypes = []any{
-	(*Empty)(nil),              // 0: common.Empty
-	(*LogEntry)(nil),           // 1: common.LogEntry
-	(*RequestVoteArgs)(nil),    // 2: common.RequestVoteArgs
-	(*RequestVoteReply)(nil),   // 3: common.RequestVoteReply
-	(*AppendEntriesArgs)(nil),  // 4: common.AppendEntriesArgs
-	(*AppendEntriesReply)(nil), // 5: common.AppendEntriesReply
-	(*StatusReply)(nil),        // 6: common.StatusReply
-	(*ProposeArgs)(nil),        // 7: common.ProposeArgs
-	(*ProposeReply)(nil),       // 8: common.ProposeReply
-	(*PartitionArgs)(nil),      // 9: common.PartitionArgs
-	(*PartitionReply)(nil),     // 10: common.PartitionReply
-	(*PbftMessage)(nil),        // 11: common.PbftMessage
-	(*PbftResponse)(nil),       // 12: common.PbftResponse
+	(*Empty)(nil),                   // 0: common.Empty
+	(*LogEntry)(nil),                // 1: common.LogEntry
+	(*RequestVoteArgs)(nil),         // 2: common.RequestVoteArgs
+	(*RequestVoteReply)(nil),        // 3: common.RequestVoteReply
+	(*AppendEntriesArgs)(nil),       // 4: common.AppendEntriesArgs
+	(*AppendEntriesReply)(nil),      // 5: common.AppendEntriesReply
+	(*TimeoutNowArgs)(nil),          // 6: common.TimeoutNowArgs
+	(*AppendEntriesBatchArgs)(nil),  // 7: common.AppendEntriesBatchArgs
+	(*AppendEntriesBatchReply)(nil), // 8: common.AppendEntriesBatchReply
+	(*StatusReply)(nil),             // 9: common.StatusReply
+	(*ProposeArgs)(nil),             // 10: common.ProposeArgs
+	(*ProposeReply)(nil),            // 11: common.ProposeReply
+	(*PartitionArgs)(nil),           // 12: common.PartitionArgs
+	(*PartitionReply)(nil),          // 13: common.PartitionReply
+	(*LinkFault)(nil),               // 14: common.LinkFault
+	(*LinkFaultArgs)(nil),           // 15: common.LinkFaultArgs
+	(*LinkFaultReply)(nil),          // 16: common.LinkFaultReply
+	(*LedgerArgs)(nil),              // 17: common.LedgerArgs
+	(*LedgerEntry)(nil),             // 18: common.LedgerEntry
+	(*LedgerReply)(nil),             // 19: common.LedgerReply
+	(*WatchArgs)(nil),               // 20: common.WatchArgs
+	(*WatchEvent)(nil),              // 21: common.WatchEvent
+	(*KVSnapshot)(nil),              // 22: common.KVSnapshot
+	(*KeyValue)(nil),                // 23: common.KeyValue
+	(*KVPutArgs)(nil),               // 24: common.KVPutArgs
+	(*KVGetArgs)(nil),               // 25: common.KVGetArgs
+	(*KVDeleteArgs)(nil),            // 26: common.KVDeleteArgs
+	(*KVCasArgs)(nil),               // 27: common.KVCasArgs
+	(*KVScanArgs)(nil),              // 28: common.KVScanArgs
+	(*KVReply)(nil),                 // 29: common.KVReply
+	(*KVScanReply)(nil),             // 30: common.KVScanReply
+	(*KVCompare)(nil),               // 31: common.KVCompare
+	(*KVWrite)(nil),                 // 32: common.KVWrite
+	(*KVTxnArgs)(nil),               // 33: common.KVTxnArgs
+	(*KVKeyResult)(nil),             // 34: common.KVKeyResult
+	(*KVTxnReply)(nil),              // 35: common.KVTxnReply
+	(*LockArgs)(nil),                // 36: common.LockArgs
+	(*LockReply)(nil),               // 37: common.LockReply
+	(*LockWatchArgs)(nil),           // 38: common.LockWatchArgs
+	(*LockEvent)(nil),               // 39: common.LockEvent
+	(*LockLease)(nil),               // 40: common.LockLease
+	(*ShardRange)(nil),              // 41: common.ShardRange
+	(*ShardMapReply)(nil),           // 42: common.ShardMapReply
+	(*ShardSplitArgs)(nil),          // 43: common.ShardSplitArgs
+	(*ShardMergeArgs)(nil),          // 44: common.ShardMergeArgs
+	(*ShardReply)(nil),              // 45: common.ShardReply
+	(*ShardSubmitArgs)(nil),         // 46: common.ShardSubmitArgs
+	(*ShardSubmitReply)(nil),        // 47: common.ShardSubmitReply
+	(*ShardReadArgs)(nil),           // 48: common.ShardReadArgs
+	(*PbftMessage)(nil),             // 49: common.PbftMessage
+	(*PreparedCert)(nil),            // 50: common.PreparedCert
+	(*PbftRequest)(nil),             // 51: common.PbftRequest
+	(*PbftReply)(nil),               // 52: common.PbftReply
+	(*ReplyWatchArgs)(nil),          // 53: common.ReplyWatchArgs
+	(*PbftResponse)(nil),            // 54: common.PbftResponse
+	nil,                             // 55: common.PbftMessage.AuthenticatorsEntry
 }
 var file_common_proto_consensus_proto_depIdxs = []int32{
 	1,  // 0: common.AppendEntriesArgs.entries:type_name -> common.LogEntry
-	2,  // 1: common.ConsensusService.RequestVote:input_type -> common.RequestVoteArgs
-	4,  // 2: common.ConsensusService.AppendEntries:input_type -> common.AppendEntriesArgs
-	9,  // 3: common.ConsensusService.SetNetworkPartition:input_type -> common.PartitionArgs
-	0,  // 4: common.ConsensusService.GetStatus:input_type -> common.Empty
-	7,  // 5: common.ConsensusService.Propose:input_type -> common.ProposeArgs
-	0,  // 6: common.ConsensusService.ForceLeader:input_type -> common.Empty
-	11, // 7: common.ConsensusService.HandlePbftMessage:input_type -> common.PbftMessage
-	3,  // 8: common.ConsensusService.RequestVote:output_type -> common.RequestVoteReply
-	5,  // 9: common.ConsensusService.AppendEntries:output_type -> common.AppendEntriesReply
-	10, // 10: common.ConsensusService.SetNetworkPartition:output_type -> common.PartitionReply
-	6,  // 11: common.ConsensusService.GetStatus:output_type -> common.StatusReply
-	8,  // 12: common.ConsensusService.Propose:output_type -> common.ProposeReply
-	0,  // 13: common.ConsensusService.ForceLeader:output_type -> common.Empty
-	12, // 14: common.ConsensusService.HandlePbftMessage:output_type -> common.PbftResponse
-	8,  // [8:15] is the sub-list for method output_type
-	1,  // [1:8] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	4,  // 1: common.AppendEntriesBatchArgs.groups:type_name -> common.AppendEntriesArgs
+	5,  // 2: common.AppendEntriesBatchReply.groups:type_name -> common.AppendEntriesReply
+	14, // 3: common.LinkFaultArgs.links:type_name -> common.LinkFault
+	51, // 4: common.LedgerEntry.requests:type_name -> common.PbftRequest
+	18, // 5: common.LedgerReply.entries:type_name -> common.LedgerEntry
+	1,  // 6: common.WatchEvent.entry:type_name -> common.LogEntry
+	22, // 7: common.WatchEvent.snapshot:type_name -> common.KVSnapshot
+	23, // 8: common.KVSnapshot.kvs:type_name -> common.KeyValue
+	40, // 9: common.KVSnapshot.locks:type_name -> common.LockLease
+	23, // 10: common.KVReply.kv:type_name -> common.KeyValue
+	23, // 11: common.KVScanReply.kvs:type_name -> common.KeyValue
+	31, // 12: common.KVTxnArgs.compares:type_name -> common.KVCompare
+	32, // 13: common.KVTxnArgs.writes:type_name -> common.KVWrite
+	23, // 14: common.KVKeyResult.kv:type_name -> common.KeyValue
+	34, // 15: common.KVTxnReply.results:type_name -> common.KVKeyResult
+	41, // 16: common.ShardMapReply.ranges:type_name -> common.ShardRange
+	41, // 17: common.ShardReply.ranges:type_name -> common.ShardRange
+	51, // 18: common.PbftMessage.requests:type_name -> common.PbftRequest
+	55, // 19: common.PbftMessage.authenticators:type_name -> common.PbftMessage.AuthenticatorsEntry
+	49, // 20: common.PbftMessage.checkpoint_proof:type_name -> common.PbftMessage
+	50, // 21: common.PbftMessage.prepared:type_name -> common.PreparedCert
+	49, // 22: common.PbftMessage.view_changes:type_name -> common.PbftMessage
+	49, // 23: common.PbftMessage.pre_prepares:type_name -> common.PbftMessage
+	49, // 24: common.PreparedCert.pre_prepare:type_name -> common.PbftMessage
+	49, // 25: common.PreparedCert.prepares:type_name -> common.PbftMessage
+	2,  // 26: common.ConsensusService.RequestVote:input_type -> common.RequestVoteArgs
+	4,  // 27: common.ConsensusService.AppendEntries:input_type -> common.AppendEntriesArgs
+	7,  // 28: common.ConsensusService.AppendEntriesBatch:input_type -> common.AppendEntriesBatchArgs
+	6,  // 29: common.ConsensusService.TimeoutNow:input_type -> common.TimeoutNowArgs
+	12, // 30: common.ConsensusService.SetNetworkPartition:input_type -> common.PartitionArgs
+	0,  // 31: common.ConsensusService.GetStatus:input_type -> common.Empty
+	10, // 32: common.ConsensusService.Propose:input_type -> common.ProposeArgs
+	0,  // 33: common.ConsensusService.ForceLeader:input_type -> common.Empty
+	15, // 34: common.ConsensusService.SetLinkFaults:input_type -> common.LinkFaultArgs
+	17, // 35: common.ConsensusService.GetLedger:input_type -> common.LedgerArgs
+	20, // 36: common.ConsensusService.WatchCommitted:input_type -> common.WatchArgs
+	49, // 37: common.ConsensusService.HandlePbftMessage:input_type -> common.PbftMessage
+	51, // 38: common.ConsensusService.SubmitRequest:input_type -> common.PbftRequest
+	53, // 39: common.ConsensusService.WatchReplies:input_type -> common.ReplyWatchArgs
+	24, // 40: common.KVService.Put:input_type -> common.KVPutArgs
+	25, // 41: common.KVService.Get:input_type -> common.KVGetArgs
+	26, // 42: common.KVService.Delete:input_type -> common.KVDeleteArgs
+	27, // 43: common.KVService.CompareAndSwap:input_type -> common.KVCasArgs
+	28, // 44: common.KVService.Scan:input_type -> common.KVScanArgs
+	33, // 45: common.KVService.Txn:input_type -> common.KVTxnArgs
+	36, // 46: common.LockService.Acquire:input_type -> common.LockArgs
+	36, // 47: common.LockService.Release:input_type -> common.LockArgs
+	36, // 48: common.LockService.Renew:input_type -> common.LockArgs
+	38, // 49: common.LockService.Watch:input_type -> common.LockWatchArgs
+	0,  // 50: common.ShardService.GetShardMap:input_type -> common.Empty
+	43, // 51: common.ShardService.Split:input_type -> common.ShardSplitArgs
+	44, // 52: common.ShardService.Merge:input_type -> common.ShardMergeArgs
+	46, // 53: common.ShardService.Submit:input_type -> common.ShardSubmitArgs
+	48, // 54: common.ShardService.Read:input_type -> common.ShardReadArgs
+	3,  // 55: common.ConsensusService.RequestVote:output_type -> common.RequestVoteReply
+	5,  // 56: common.ConsensusService.AppendEntries:output_type -> common.AppendEntriesReply
+	8,  // 57: common.ConsensusService.AppendEntriesBatch:output_type -> common.AppendEntriesBatchReply
+	0,  // 58: common.ConsensusService.TimeoutNow:output_type -> common.Empty
+	13, // 59: common.ConsensusService.SetNetworkPartition:output_type -> common.PartitionReply
+	9,  // 60: common.ConsensusService.GetStatus:output_type -> common.StatusReply
+	11, // 61: common.ConsensusService.Propose:output_type -> common.ProposeReply
+	0,  // 62: common.ConsensusService.ForceLeader:output_type -> common.Empty
+	16, // 63: common.ConsensusService.SetLinkFaults:output_type -> common.LinkFaultReply
+	19, // 64: common.ConsensusService.GetLedger:output_type -> common.LedgerReply
+	21, // 65: common.ConsensusService.WatchCommitted:output_type -> common.WatchEvent
+	54, // 66: common.ConsensusService.HandlePbftMessage:output_type -> common.PbftResponse
+	54, // 67: common.ConsensusService.SubmitRequest:output_type -> common.PbftResponse
+	52, // 68: common.ConsensusService.WatchReplies:output_type -> common.PbftReply
+	29, // 69: common.KVService.Put:output_type -> common.KVReply
+	29, // 70: common.KVService.Get:output_type -> common.KVReply
+	29, // 71: common.KVService.Delete:output_type -> common.KVReply
+	29, // 72: common.KVService.CompareAndSwap:output_type -> common.KVReply
+	30, // 73: common.KVService.Scan:output_type -> common.KVScanReply
+	35, // 74: common.KVService.Txn:output_type -> common.KVTxnReply
+	37, // 75: common.LockService.Acquire:output_type -> common.LockReply
+	37, // 76: common.LockService.Release:output_type -> common.LockReply
+	37, // 77: common.LockService.Renew:output_type -> common.LockReply
+	39, // 78: common.LockService.Watch:output_type -> common.LockEvent
+	42, // 79: common.ShardService.GetShardMap:output_type -> common.ShardMapReply
+	45, // 80: common.ShardService.Split:output_type -> common.ShardReply
+	45, // 81: common.ShardService.Merge:output_type -> common.ShardReply
+	47, // 82: common.ShardService.Submit:output_type -> common.ShardSubmitReply
+	30, // 83: common.ShardService.Read:output_type -> common.KVScanReply
+	55, // [55:84] is the sub-list for method output_type
+	26, // [26:55] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_common_proto_consensus_proto_init() }
@@ -855,9 +3982,9 @@ func file_common_proto_consensus_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_common_proto_consensus_proto_rawDesc), len(file_common_proto_consensus_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   56,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   4,
 		},
 		GoTypes:           file_common_proto_consensus_proto_goTypes,
 		DependencyIndexes: file_common_proto_consensus_proto_depIdxs,
@@ -21,11 +21,18 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	ConsensusService_RequestVote_FullMethodName         = "/common.ConsensusService/RequestVote"
 	ConsensusService_AppendEntries_FullMethodName       = "/common.ConsensusService/AppendEntries"
+	ConsensusService_AppendEntriesBatch_FullMethodName  = "/common.ConsensusService/AppendEntriesBatch"
+	ConsensusService_TimeoutNow_FullMethodName          = "/common.ConsensusService/TimeoutNow"
 	ConsensusService_SetNetworkPartition_FullMethodName = "/common.ConsensusService/SetNetworkPartition"
 	ConsensusService_GetStatus_FullMethodName           = "/common.ConsensusService/GetStatus"
 	ConsensusService_Propose_FullMethodName             = "/common.ConsensusService/Propose"
 	ConsensusService_ForceLeader_FullMethodName         = "/common.ConsensusService/ForceLeader"
+	ConsensusService_SetLinkFaults_FullMethodName       = "/common.ConsensusService/SetLinkFaults"
+	ConsensusService_GetLedger_FullMethodName           = "/common.ConsensusService/GetLedger"
+	ConsensusService_WatchCommitted_FullMethodName      = "/common.ConsensusService/WatchCommitted"
 	ConsensusService_HandlePbftMessage_FullMethodName   = "/common.ConsensusService/HandlePbftMessage"
+	ConsensusService_SubmitRequest_FullMethodName       = "/common.ConsensusService/SubmitRequest"
+	ConsensusService_WatchReplies_FullMethodName        = "/common.ConsensusService/WatchReplies"
 )
 
 // ConsensusServiceClient is the client API for ConsensusService service.
@@ -40,15 +47,29 @@ type ConsensusServiceClient interface {
 	// ==========================================
 	RequestVote(ctx context.Context, in *RequestVoteArgs, opts ...grpc.CallOption) (*RequestVoteReply, error)
 	AppendEntries(ctx context.Context, in *AppendEntriesArgs, opts ...grpc.CallOption) (*AppendEntriesReply, error)
+	// Multi-Raft: gộp AppendEntries của mọi nhóm cùng Leader gửi tới một peer
+	AppendEntriesBatch(ctx context.Context, in *AppendEntriesBatchArgs, opts ...grpc.CallOption) (*AppendEntriesBatchReply, error)
+	// Chuyển quyền Leader (Raft §3.10): Leader yêu cầu node đích bầu cử ngay
+	TimeoutNow(ctx context.Context, in *TimeoutNowArgs, opts ...grpc.CallOption) (*Empty, error)
 	SetNetworkPartition(ctx context.Context, in *PartitionArgs, opts ...grpc.CallOption) (*PartitionReply, error)
 	GetStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StatusReply, error)
 	Propose(ctx context.Context, in *ProposeArgs, opts ...grpc.CallOption) (*ProposeReply, error)
 	ForceLeader(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	// Fault injection trên từng link gửi đi (dùng chung cho Raft & pBFT)
+	SetLinkFaults(ctx context.Context, in *LinkFaultArgs, opts ...grpc.CallOption) (*LinkFaultReply, error)
+	// Đọc các entry/block đã commit (dùng cho kiểm thử & chaos runner)
+	GetLedger(ctx context.Context, in *LedgerArgs, opts ...grpc.CallOption) (*LedgerReply, error)
+	// Stream các entry đã commit từ from_index trở đi (CDC); node nào cũng phục vụ được
+	WatchCommitted(ctx context.Context, in *WatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
 	// ==========================================
 	// PHẦN 2: pBFT RPCs
 	// Gom về 1 hàm xử lý chung
 	// ==========================================
 	HandlePbftMessage(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftResponse, error)
+	// Client gửi request tới Primary; Backup nhận được thì chuyển tiếp cho Primary
+	SubmitRequest(ctx context.Context, in *PbftRequest, opts ...grpc.CallOption) (*PbftResponse, error)
+	// Replica đẩy reply (đã xác thực) cho mỗi request của client ngay khi thực thi xong
+	WatchReplies(ctx context.Context, in *ReplyWatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PbftReply], error)
 }
 
 type consensusServiceClient struct {
@@ -79,6 +100,26 @@ func (c *consensusServiceClient) AppendEntries(ctx context.Context, in *AppendEn
 	return out, nil
 }
 
+func (c *consensusServiceClient) AppendEntriesBatch(ctx context.Context, in *AppendEntriesBatchArgs, opts ...grpc.CallOption) (*AppendEntriesBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendEntriesBatchReply)
+	err := c.cc.Invoke(ctx, ConsensusService_AppendEntriesBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusServiceClient) TimeoutNow(ctx context.Context, in *TimeoutNowArgs, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, ConsensusService_TimeoutNow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *consensusServiceClient) SetNetworkPartition(ctx context.Context, in *PartitionArgs, opts ...grpc.CallOption) (*PartitionReply, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PartitionReply)
@@ -119,6 +160,45 @@ func (c *consensusServiceClient) ForceLeader(ctx context.Context, in *Empty, opt
 	return out, nil
 }
 
+func (c *consensusServiceClient) SetLinkFaults(ctx context.Context, in *LinkFaultArgs, opts ...grpc.CallOption) (*LinkFaultReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkFaultReply)
+	err := c.cc.Invoke(ctx, ConsensusService_SetLinkFaults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusServiceClient) GetLedger(ctx context.Context, in *LedgerArgs, opts ...grpc.CallOption) (*LedgerReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LedgerReply)
+	err := c.cc.Invoke(ctx, ConsensusService_GetLedger_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusServiceClient) WatchCommitted(ctx context.Context, in *WatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConsensusService_ServiceDesc.Streams[0], ConsensusService_WatchCommitted_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchArgs, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConsensusService_WatchCommittedClient = grpc.ServerStreamingClient[WatchEvent]
+
 func (c *consensusServiceClient) HandlePbftMessage(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PbftResponse)
@@ -129,6 +209,35 @@ func (c *consensusServiceClient) HandlePbftMessage(ctx context.Context, in *Pbft
 	return out, nil
 }
 
+func (c *consensusServiceClient) SubmitRequest(ctx context.Context, in *PbftRequest, opts ...grpc.CallOption) (*PbftResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PbftResponse)
+	err := c.cc.Invoke(ctx, ConsensusService_SubmitRequest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusServiceClient) WatchReplies(ctx context.Context, in *ReplyWatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PbftReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConsensusService_ServiceDesc.Streams[1], ConsensusService_WatchReplies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplyWatchArgs, PbftReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConsensusService_WatchRepliesClient = grpc.ServerStreamingClient[PbftReply]
+
 // ConsensusServiceServer is the server API for ConsensusService service.
 // All implementations must embed UnimplementedConsensusServiceServer
 // for forward compatibility.
@@ -141,15 +250,29 @@ type ConsensusServiceServer interface {
 	// ==========================================
 	RequestVote(context.Context, *RequestVoteArgs) (*RequestVoteReply, error)
 	AppendEntries(context.Context, *AppendEntriesArgs) (*AppendEntriesReply, error)
+	// Multi-Raft: gộp AppendEntries của mọi nhóm cùng Leader gửi tới một peer
+	AppendEntriesBatch(context.Context, *AppendEntriesBatchArgs) (*AppendEntriesBatchReply, error)
+	// Chuyển quyền Leader (Raft §3.10): Leader yêu cầu node đích bầu cử ngay
+	TimeoutNow(context.Context, *TimeoutNowArgs) (*Empty, error)
 	SetNetworkPartition(context.Context, *PartitionArgs) (*PartitionReply, error)
 	GetStatus(context.Context, *Empty) (*StatusReply, error)
 	Propose(context.Context, *ProposeArgs) (*ProposeReply, error)
 	ForceLeader(context.Context, *Empty) (*Empty, error)
+	// Fault injection trên từng link gửi đi (dùng chung cho Raft & pBFT)
+	SetLinkFaults(context.Context, *LinkFaultArgs) (*LinkFaultReply, error)
+	// Đọc các entry/block đã commit (dùng cho kiểm thử & chaos runner)
+	GetLedger(context.Context, *LedgerArgs) (*LedgerReply, error)
+	// Stream các entry đã commit từ from_index trở đi (CDC); node nào cũng phục vụ được
+	WatchCommitted(*WatchArgs, grpc.ServerStreamingServer[WatchEvent]) error
 	// ==========================================
 	// PHẦN 2: pBFT RPCs
 	// Gom về 1 hàm xử lý chung
 	// ==========================================
 	HandlePbftMessage(context.Context, *PbftMessage) (*PbftResponse, error)
+	// Client gửi request tới Primary; Backup nhận được thì chuyển tiếp cho Primary
+	SubmitRequest(context.Context, *PbftRequest) (*PbftResponse, error)
+	// Replica đẩy reply (đã xác thực) cho mỗi request của client ngay khi thực thi xong
+	WatchReplies(*ReplyWatchArgs, grpc.ServerStreamingServer[PbftReply]) error
 	mustEmbedUnimplementedConsensusServiceServer()
 }
 
@@ -166,6 +289,12 @@ func (UnimplementedConsensusServiceServer) RequestVote(context.Context, *Request
 func (UnimplementedConsensusServiceServer) AppendEntries(context.Context, *AppendEntriesArgs) (*AppendEntriesReply, error) {
 	return nil, status.Error(codes.Unimplemented, "method AppendEntries not implemented")
 }
+func (UnimplementedConsensusServiceServer) AppendEntriesBatch(context.Context, *AppendEntriesBatchArgs) (*AppendEntriesBatchReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method AppendEntriesBatch not implemented")
+}
+func (UnimplementedConsensusServiceServer) TimeoutNow(context.Context, *TimeoutNowArgs) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method TimeoutNow not implemented")
+}
 func (UnimplementedConsensusServiceServer) SetNetworkPartition(context.Context, *PartitionArgs) (*PartitionReply, error) {
 	return nil, status.Error(codes.Unimplemented, "method SetNetworkPartition not implemented")
 }
@@ -178,9 +307,24 @@ func (UnimplementedConsensusServiceServer) Propose(context.Context, *ProposeArgs
 func (UnimplementedConsensusServiceServer) ForceLeader(context.Context, *Empty) (*Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "method ForceLeader not implemented")
 }
+func (UnimplementedConsensusServiceServer) SetLinkFaults(context.Context, *LinkFaultArgs) (*LinkFaultReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetLinkFaults not implemented")
+}
+func (UnimplementedConsensusServiceServer) GetLedger(context.Context, *LedgerArgs) (*LedgerReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetLedger not implemented")
+}
+func (UnimplementedConsensusServiceServer) WatchCommitted(*WatchArgs, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchCommitted not implemented")
+}
 func (UnimplementedConsensusServiceServer) HandlePbftMessage(context.Context, *PbftMessage) (*PbftResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method HandlePbftMessage not implemented")
 }
+func (UnimplementedConsensusServiceServer) SubmitRequest(context.Context, *PbftRequest) (*PbftResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitRequest not implemented")
+}
+func (UnimplementedConsensusServiceServer) WatchReplies(*ReplyWatchArgs, grpc.ServerStreamingServer[PbftReply]) error {
+	return status.Error(codes.Unimplemented, "method WatchReplies not implemented")
+}
 func (UnimplementedConsensusServiceServer) mustEmbedUnimplementedConsensusServiceServer() {}
 func (UnimplementedConsensusServiceServer) testEmbeddedByValue()                          {}
 
@@ -238,6 +382,42 @@ func _ConsensusService_AppendEntries_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConsensusService_AppendEntriesBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntriesBatchArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServiceServer).AppendEntriesBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConsensusService_AppendEntriesBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServiceServer).AppendEntriesBatch(ctx, req.(*AppendEntriesBatchArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConsensusService_TimeoutNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeoutNowArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServiceServer).TimeoutNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConsensusService_TimeoutNow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServiceServer).TimeoutNow(ctx, req.(*TimeoutNowArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ConsensusService_SetNetworkPartition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PartitionArgs)
 	if err := dec(in); err != nil {
@@ -310,6 +490,53 @@ func _ConsensusService_ForceLeader_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConsensusService_SetLinkFaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkFaultArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServiceServer).SetLinkFaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConsensusService_SetLinkFaults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServiceServer).SetLinkFaults(ctx, req.(*LinkFaultArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConsensusService_GetLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LedgerArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServiceServer).GetLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConsensusService_GetLedger_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServiceServer).GetLedger(ctx, req.(*LedgerArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConsensusService_WatchCommitted_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConsensusServiceServer).WatchCommitted(m, &grpc.GenericServerStream[WatchArgs, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConsensusService_WatchCommittedServer = grpc.ServerStreamingServer[WatchEvent]
+
 func _ConsensusService_HandlePbftMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PbftMessage)
 	if err := dec(in); err != nil {
@@ -328,6 +555,35 @@ func _ConsensusService_HandlePbftMessage_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConsensusService_SubmitRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PbftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServiceServer).SubmitRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConsensusService_SubmitRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServiceServer).SubmitRequest(ctx, req.(*PbftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConsensusService_WatchReplies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplyWatchArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConsensusServiceServer).WatchReplies(m, &grpc.GenericServerStream[ReplyWatchArgs, PbftReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConsensusService_WatchRepliesServer = grpc.ServerStreamingServer[PbftReply]
+
 // ConsensusService_ServiceDesc is the grpc.ServiceDesc for ConsensusService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -343,6 +599,14 @@ var ConsensusService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AppendEntries",
 			Handler:    _ConsensusService_AppendEntries_Handler,
 		},
+		{
+			MethodName: "AppendEntriesBatch",
+			Handler:    _ConsensusService_AppendEntriesBatch_Handler,
+		},
+		{
+			MethodName: "TimeoutNow",
+			Handler:    _ConsensusService_TimeoutNow_Handler,
+		},
 		{
 			MethodName: "SetNetworkPartition",
 			Handler:    _ConsensusService_SetNetworkPartition_Handler,
@@ -359,10 +623,829 @@ var ConsensusService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ForceLeader",
 			Handler:    _ConsensusService_ForceLeader_Handler,
 		},
+		{
+			MethodName: "SetLinkFaults",
+			Handler:    _ConsensusService_SetLinkFaults_Handler,
+		},
+		{
+			MethodName: "GetLedger",
+			Handler:    _ConsensusService_GetLedger_Handler,
+		},
 		{
 			MethodName: "HandlePbftMessage",
 			Handler:    _ConsensusService_HandlePbftMessage_Handler,
 		},
+		{
+			MethodName: "SubmitRequest",
+			Handler:    _ConsensusService_SubmitRequest_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCommitted",
+			Handler:       _ConsensusService_WatchCommitted_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchReplies",
+			Handler:       _ConsensusService_WatchReplies_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "common/proto/consensus.proto",
+}
+
+const (
+	KVService_Put_FullMethodName            = "/common.KVService/Put"
+	KVService_Get_FullMethodName            = "/common.KVService/Get"
+	KVService_Delete_FullMethodName         = "/common.KVService/Delete"
+	KVService_CompareAndSwap_FullMethodName = "/common.KVService/CompareAndSwap"
+	KVService_Scan_FullMethodName           = "/common.KVService/Scan"
+	KVService_Txn_FullMethodName            = "/common.KVService/Txn"
+)
+
+// KVServiceClient is the client API for KVService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// --- KEY-VALUE STORE TRÊN RAFT ---
+// Ghi đi qua log Raft (chỉ Leader nhận), đọc linearizable qua ReadIndex.
+type KVServiceClient interface {
+	Put(ctx context.Context, in *KVPutArgs, opts ...grpc.CallOption) (*KVReply, error)
+	Get(ctx context.Context, in *KVGetArgs, opts ...grpc.CallOption) (*KVReply, error)
+	Delete(ctx context.Context, in *KVDeleteArgs, opts ...grpc.CallOption) (*KVReply, error)
+	CompareAndSwap(ctx context.Context, in *KVCasArgs, opts ...grpc.CallOption) (*KVReply, error)
+	Scan(ctx context.Context, in *KVScanArgs, opts ...grpc.CallOption) (*KVScanReply, error)
+	// Giao dịch nhiều key: mọi compare đúng thì áp toàn bộ writes, ngược lại không ghi gì
+	Txn(ctx context.Context, in *KVTxnArgs, opts ...grpc.CallOption) (*KVTxnReply, error)
+}
+
+type kVServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKVServiceClient(cc grpc.ClientConnInterface) KVServiceClient {
+	return &kVServiceClient{cc}
+}
+
+func (c *kVServiceClient) Put(ctx context.Context, in *KVPutArgs, opts ...grpc.CallOption) (*KVReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVReply)
+	err := c.cc.Invoke(ctx, KVService_Put_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Get(ctx context.Context, in *KVGetArgs, opts ...grpc.CallOption) (*KVReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVReply)
+	err := c.cc.Invoke(ctx, KVService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Delete(ctx context.Context, in *KVDeleteArgs, opts ...grpc.CallOption) (*KVReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVReply)
+	err := c.cc.Invoke(ctx, KVService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) CompareAndSwap(ctx context.Context, in *KVCasArgs, opts ...grpc.CallOption) (*KVReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVReply)
+	err := c.cc.Invoke(ctx, KVService_CompareAndSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Scan(ctx context.Context, in *KVScanArgs, opts ...grpc.CallOption) (*KVScanReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVScanReply)
+	err := c.cc.Invoke(ctx, KVService_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Txn(ctx context.Context, in *KVTxnArgs, opts ...grpc.CallOption) (*KVTxnReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVTxnReply)
+	err := c.cc.Invoke(ctx, KVService_Txn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KVServiceServer is the server API for KVService service.
+// All implementations must embed UnimplementedKVServiceServer
+// for forward compatibility.
+//
+// --- KEY-VALUE STORE TRÊN RAFT ---
+// Ghi đi qua log Raft (chỉ Leader nhận), đọc linearizable qua ReadIndex.
+type KVServiceServer interface {
+	Put(context.Context, *KVPutArgs) (*KVReply, error)
+	Get(context.Context, *KVGetArgs) (*KVReply, error)
+	Delete(context.Context, *KVDeleteArgs) (*KVReply, error)
+	CompareAndSwap(context.Context, *KVCasArgs) (*KVReply, error)
+	Scan(context.Context, *KVScanArgs) (*KVScanReply, error)
+	// Giao dịch nhiều key: mọi compare đúng thì áp toàn bộ writes, ngược lại không ghi gì
+	Txn(context.Context, *KVTxnArgs) (*KVTxnReply, error)
+	mustEmbedUnimplementedKVServiceServer()
+}
+
+// UnimplementedKVServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKVServiceServer struct{}
+
+func (UnimplementedKVServiceServer) Put(context.Context, *KVPutArgs) (*KVReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Put not implemented")
+}
+func (UnimplementedKVServiceServer) Get(context.Context, *KVGetArgs) (*KVReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedKVServiceServer) Delete(context.Context, *KVDeleteArgs) (*KVReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedKVServiceServer) CompareAndSwap(context.Context, *KVCasArgs) (*KVReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method CompareAndSwap not implemented")
+}
+func (UnimplementedKVServiceServer) Scan(context.Context, *KVScanArgs) (*KVScanReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedKVServiceServer) Txn(context.Context, *KVTxnArgs) (*KVTxnReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Txn not implemented")
+}
+func (UnimplementedKVServiceServer) mustEmbedUnimplementedKVServiceServer() {}
+func (UnimplementedKVServiceServer) testEmbeddedByValue()                   {}
+
+// UnsafeKVServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KVServiceServer will
+// result in compilation errors.
+type UnsafeKVServiceServer interface {
+	mustEmbedUnimplementedKVServiceServer()
+}
+
+func RegisterKVServiceServer(s grpc.ServiceRegistrar, srv KVServiceServer) {
+	// If the following call panics, it indicates UnimplementedKVServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KVService_ServiceDesc, srv)
+}
+
+func _KVService_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVPutArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_Put_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Put(ctx, req.(*KVPutArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVGetArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Get(ctx, req.(*KVGetArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVDeleteArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Delete(ctx, req.(*KVDeleteArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_CompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVCasArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).CompareAndSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_CompareAndSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).CompareAndSwap(ctx, req.(*KVCasArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVScanArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Scan(ctx, req.(*KVScanArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Txn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVTxnArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Txn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVService_Txn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Txn(ctx, req.(*KVTxnArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KVService_ServiceDesc is the grpc.ServiceDesc for KVService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KVService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.KVService",
+	HandlerType: (*KVServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Put",
+			Handler:    _KVService_Put_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _KVService_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _KVService_Delete_Handler,
+		},
+		{
+			MethodName: "CompareAndSwap",
+			Handler:    _KVService_CompareAndSwap_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _KVService_Scan_Handler,
+		},
+		{
+			MethodName: "Txn",
+			Handler:    _KVService_Txn_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "common/proto/consensus.proto",
+}
+
+const (
+	LockService_Acquire_FullMethodName = "/common.LockService/Acquire"
+	LockService_Release_FullMethodName = "/common.LockService/Release"
+	LockService_Renew_FullMethodName   = "/common.LockService/Renew"
+	LockService_Watch_FullMethodName   = "/common.LockService/Watch"
+)
+
+// LockServiceClient is the client API for LockService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// --- LOCK / LEASE TRÊN RAFT ---
+// Lease có TTL, fencing token = index của entry Acquire trong log Raft.
+type LockServiceClient interface {
+	Acquire(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error)
+	Release(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error)
+	Renew(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error)
+	// Stream sự kiện khi lock được nhả (release) hoặc hết hạn (expired)
+	Watch(ctx context.Context, in *LockWatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LockEvent], error)
+}
+
+type lockServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLockServiceClient(cc grpc.ClientConnInterface) LockServiceClient {
+	return &lockServiceClient{cc}
+}
+
+func (c *lockServiceClient) Acquire(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockReply)
+	err := c.cc.Invoke(ctx, LockService_Acquire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) Release(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockReply)
+	err := c.cc.Invoke(ctx, LockService_Release_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) Renew(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*LockReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockReply)
+	err := c.cc.Invoke(ctx, LockService_Renew_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) Watch(ctx context.Context, in *LockWatchArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LockEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LockService_ServiceDesc.Streams[0], LockService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LockWatchArgs, LockEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_WatchClient = grpc.ServerStreamingClient[LockEvent]
+
+// LockServiceServer is the server API for LockService service.
+// All implementations must embed UnimplementedLockServiceServer
+// for forward compatibility.
+//
+// --- LOCK / LEASE TRÊN RAFT ---
+// Lease có TTL, fencing token = index của entry Acquire trong log Raft.
+type LockServiceServer interface {
+	Acquire(context.Context, *LockArgs) (*LockReply, error)
+	Release(context.Context, *LockArgs) (*LockReply, error)
+	Renew(context.Context, *LockArgs) (*LockReply, error)
+	// Stream sự kiện khi lock được nhả (release) hoặc hết hạn (expired)
+	Watch(*LockWatchArgs, grpc.ServerStreamingServer[LockEvent]) error
+	mustEmbedUnimplementedLockServiceServer()
+}
+
+// UnimplementedLockServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLockServiceServer struct{}
+
+func (UnimplementedLockServiceServer) Acquire(context.Context, *LockArgs) (*LockReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Acquire not implemented")
+}
+func (UnimplementedLockServiceServer) Release(context.Context, *LockArgs) (*LockReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Release not implemented")
+}
+func (UnimplementedLockServiceServer) Renew(context.Context, *LockArgs) (*LockReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Renew not implemented")
+}
+func (UnimplementedLockServiceServer) Watch(*LockWatchArgs, grpc.ServerStreamingServer[LockEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedLockServiceServer) mustEmbedUnimplementedLockServiceServer() {}
+func (UnimplementedLockServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeLockServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LockServiceServer will
+// result in compilation errors.
+type UnsafeLockServiceServer interface {
+	mustEmbedUnimplementedLockServiceServer()
+}
+
+func RegisterLockServiceServer(s grpc.ServiceRegistrar, srv LockServiceServer) {
+	// If the following call panics, it indicates UnimplementedLockServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LockService_ServiceDesc, srv)
+}
+
+func _LockService_Acquire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).Acquire(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_Acquire_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).Acquire(ctx, req.(*LockArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_Release_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).Release(ctx, req.(*LockArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_Renew_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).Renew(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_Renew_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).Renew(ctx, req.(*LockArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LockWatchArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LockServiceServer).Watch(m, &grpc.GenericServerStream[LockWatchArgs, LockEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_WatchServer = grpc.ServerStreamingServer[LockEvent]
+
+// LockService_ServiceDesc is the grpc.ServiceDesc for LockService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LockService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.LockService",
+	HandlerType: (*LockServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Acquire",
+			Handler:    _LockService_Acquire_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _LockService_Release_Handler,
+		},
+		{
+			MethodName: "Renew",
+			Handler:    _LockService_Renew_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _LockService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "common/proto/consensus.proto",
+}
+
+const (
+	ShardService_GetShardMap_FullMethodName = "/common.ShardService/GetShardMap"
+	ShardService_Split_FullMethodName       = "/common.ShardService/Split"
+	ShardService_Merge_FullMethodName       = "/common.ShardService/Merge"
+	ShardService_Submit_FullMethodName      = "/common.ShardService/Submit"
+	ShardService_Read_FullMethodName        = "/common.ShardService/Read"
+)
+
+// ShardServiceClient is the client API for ShardService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// --- RANGE SHARDING TRÊN MULTI-RAFT ---
+// Shard map (khoảng key -> nhóm Raft) nằm trong log của nhóm 0 (metadata).
+type ShardServiceClient interface {
+	GetShardMap(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ShardMapReply, error)
+	// Split/Merge chạy trên Leader của nhóm metadata (Host khác tự chuyển tiếp).
+	// Tách range chứa `key` tại `key`, nửa [key, end) chuyển sang nhóm group_id
+	Split(ctx context.Context, in *ShardSplitArgs, opts ...grpc.CallOption) (*ShardReply, error)
+	// Gộp range kết thúc tại `key` với range bắt đầu tại `key` vào nhóm của range bên trái
+	Merge(ctx context.Context, in *ShardMergeArgs, opts ...grpc.CallOption) (*ShardReply, error)
+	// Nội bộ: router chuyển lệnh ghi / đọc tới Leader của nhóm
+	Submit(ctx context.Context, in *ShardSubmitArgs, opts ...grpc.CallOption) (*ShardSubmitReply, error)
+	Read(ctx context.Context, in *ShardReadArgs, opts ...grpc.CallOption) (*KVScanReply, error)
+}
+
+type shardServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShardServiceClient(cc grpc.ClientConnInterface) ShardServiceClient {
+	return &shardServiceClient{cc}
+}
+
+func (c *shardServiceClient) GetShardMap(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ShardMapReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardMapReply)
+	err := c.cc.Invoke(ctx, ShardService_GetShardMap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) Split(ctx context.Context, in *ShardSplitArgs, opts ...grpc.CallOption) (*ShardReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardReply)
+	err := c.cc.Invoke(ctx, ShardService_Split_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) Merge(ctx context.Context, in *ShardMergeArgs, opts ...grpc.CallOption) (*ShardReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardReply)
+	err := c.cc.Invoke(ctx, ShardService_Merge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) Submit(ctx context.Context, in *ShardSubmitArgs, opts ...grpc.CallOption) (*ShardSubmitReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShardSubmitReply)
+	err := c.cc.Invoke(ctx, ShardService_Submit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) Read(ctx context.Context, in *ShardReadArgs, opts ...grpc.CallOption) (*KVScanReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVScanReply)
+	err := c.cc.Invoke(ctx, ShardService_Read_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ShardServiceServer is the server API for ShardService service.
+// All implementations must embed UnimplementedShardServiceServer
+// for forward compatibility.
+//
+// --- RANGE SHARDING TRÊN MULTI-RAFT ---
+// Shard map (khoảng key -> nhóm Raft) nằm trong log của nhóm 0 (metadata).
+type ShardServiceServer interface {
+	GetShardMap(context.Context, *Empty) (*ShardMapReply, error)
+	// Split/Merge chạy trên Leader của nhóm metadata (Host khác tự chuyển tiếp).
+	// Tách range chứa `key` tại `key`, nửa [key, end) chuyển sang nhóm group_id
+	Split(context.Context, *ShardSplitArgs) (*ShardReply, error)
+	// Gộp range kết thúc tại `key` với range bắt đầu tại `key` vào nhóm của range bên trái
+	Merge(context.Context, *ShardMergeArgs) (*ShardReply, error)
+	// Nội bộ: router chuyển lệnh ghi / đọc tới Leader của nhóm
+	Submit(context.Context, *ShardSubmitArgs) (*ShardSubmitReply, error)
+	Read(context.Context, *ShardReadArgs) (*KVScanReply, error)
+	mustEmbedUnimplementedShardServiceServer()
+}
+
+// UnimplementedShardServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedShardServiceServer struct{}
+
+func (UnimplementedShardServiceServer) GetShardMap(context.Context, *Empty) (*ShardMapReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetShardMap not implemented")
+}
+func (UnimplementedShardServiceServer) Split(context.Context, *ShardSplitArgs) (*ShardReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Split not implemented")
+}
+func (UnimplementedShardServiceServer) Merge(context.Context, *ShardMergeArgs) (*ShardReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Merge not implemented")
+}
+func (UnimplementedShardServiceServer) Submit(context.Context, *ShardSubmitArgs) (*ShardSubmitReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Submit not implemented")
+}
+func (UnimplementedShardServiceServer) Read(context.Context, *ShardReadArgs) (*KVScanReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Read not implemented")
+}
+func (UnimplementedShardServiceServer) mustEmbedUnimplementedShardServiceServer() {}
+func (UnimplementedShardServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeShardServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShardServiceServer will
+// result in compilation errors.
+type UnsafeShardServiceServer interface {
+	mustEmbedUnimplementedShardServiceServer()
+}
+
+func RegisterShardServiceServer(s grpc.ServiceRegistrar, srv ShardServiceServer) {
+	// If the following call panics, it indicates UnimplementedShardServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ShardService_ServiceDesc, srv)
+}
+
+func _ShardService_GetShardMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).GetShardMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_GetShardMap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).GetShardMap(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_Split_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardSplitArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).Split(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_Split_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).Split(ctx, req.(*ShardSplitArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_Merge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardMergeArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).Merge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_Merge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).Merge(ctx, req.(*ShardMergeArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardSubmitArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_Submit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).Submit(ctx, req.(*ShardSubmitArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShardReadArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).Read(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_Read_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).Read(ctx, req.(*ShardReadArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ShardService_ServiceDesc is the grpc.ServiceDesc for ShardService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShardService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.ShardService",
+	HandlerType: (*ShardServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetShardMap",
+			Handler:    _ShardService_GetShardMap_Handler,
+		},
+		{
+			MethodName: "Split",
+			Handler:    _ShardService_Split_Handler,
+		},
+		{
+			MethodName: "Merge",
+			Handler:    _ShardService_Merge_Handler,
+		},
+		{
+			MethodName: "Submit",
+			Handler:    _ShardService_Submit_Handler,
+		},
+		{
+			MethodName: "Read",
+			Handler:    _ShardService_Read_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "common/proto/consensus.proto",
@@ -0,0 +1,256 @@
+// Package client là thư viện client pBFT: gửi <REQUEST, o, t, c> (ký bằng
+// khoá ed25519 của client) tới Primary
+// rồi chờ f+1 reply khớp nhau từ các replica khác nhau. Trong f+1 replica đó
+// có ít nhất một replica trung thực, nên kết quả đáng tin dù f replica có thể
+// nói dối. Hết RetryTimeout mà chưa đủ reply thì request được gửi lại cho mọi
+// replica (Backup chuyển tiếp cho Primary của view hiện tại).
+//
+// Replica đẩy reply qua stream WatchReplies ngay khi thực thi xong. Reply chỉ
+// được đếm khi kiểm tra được theo khoá của replica gửi: chữ ký ed25519, hoặc
+// HMAC bằng session key lập với replica đó (X25519) ở chế độ MAC.
+package client
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// Result là kết quả đã được f+1 replica xác nhận.
+type Result struct {
+	Sequence  int64
+	BlockHash string
+	View      int64
+}
+
+type Client struct {
+	ID           string
+	RetryTimeout time.Duration // Chờ reply bao lâu trước khi gửi lại cho mọi replica
+
+	key      ed25519.PrivateKey                   // Ký request; public key nằm trong cluster config
+	replicas map[string]pb.ConsensusServiceClient // NodeID ("node1"...) -> client
+	keys     map[string]ed25519.PublicKey         // Public key của replica theo NodeID
+	dh       *ecdh.PrivateKey                     // Khoá X25519 tạm thời để lập session key (chế độ MAC)
+	f        int
+
+	mu     sync.Mutex
+	view   int64 // View mới nhất thấy trong reply, để đoán Primary
+	lastTs int64
+}
+
+// New tạo client trên các kết nối có sẵn; N replica chịu được f = (N-1)/3 lỗi.
+// key là private key của client, keys là public key của các replica
+// (registry trong cluster config).
+func New(id string, key ed25519.PrivateKey, replicas map[string]pb.ConsensusServiceClient, keys map[string]ed25519.PublicKey) *Client {
+	dh, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	return &Client{
+		ID:           id,
+		RetryTimeout: time.Second,
+		key:          key,
+		replicas:     replicas,
+		keys:         keys,
+		dh:           dh,
+		f:            (len(replicas) - 1) / 3,
+		view:         1,
+	}
+}
+
+// Dial kết nối tới các replica theo địa chỉ gRPC.
+func Dial(id string, key ed25519.PrivateKey, addrs map[string]string, keys map[string]ed25519.PublicKey) (*Client, error) {
+	replicas := make(map[string]pb.ConsensusServiceClient)
+	for node, addr := range addrs {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		replicas[node] = pb.NewConsensusServiceClient(conn)
+	}
+	return New(id, key, replicas, keys), nil
+}
+
+// Submit gửi `op` và chờ tới khi f+1 replica trả cùng sequence và block hash.
+// Như trong bài báo, mỗi client chỉ có một request đang chờ tại một thời điểm:
+// replica bỏ qua request có timestamp cũ hơn request đã thực thi.
+func (c *Client) Submit(ctx context.Context, op []byte) (*Result, error) {
+	req := &pb.PbftRequest{ClientId: c.ID, Timestamp: c.nextTimestamp(), Operation: op}
+	req.Signature = ed25519.Sign(c.key, requestBytes(req))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	votes := make(chan *pb.PbftReply, len(c.replicas))
+	for _, rc := range c.replicas {
+		go c.watch(ctx, rc, votes)
+	}
+	go c.send(ctx, c.primary(), req)
+
+	retry := time.NewTimer(c.RetryTimeout)
+	defer retry.Stop()
+	type result struct {
+		seq  int64
+		hash string
+	}
+	matching := make(map[result]map[string]bool)
+	replies := 0
+	for {
+		select {
+		case r := <-votes:
+			// Reply đã được kiểm tra theo khoá của NodeId nên đếm theo NodeId
+			if r.ClientId != req.ClientId || r.Timestamp != req.Timestamp {
+				continue
+			}
+			replies++
+			key := result{r.Sequence, r.BlockHash}
+			if matching[key] == nil {
+				matching[key] = make(map[string]bool)
+			}
+			matching[key][r.NodeId] = true
+			if len(matching[key]) >= c.f+1 {
+				c.observeView(r.View)
+				return &Result{Sequence: r.Sequence, BlockHash: r.BlockHash, View: r.View}, nil
+			}
+		case <-retry.C:
+			for node := range c.replicas {
+				go c.send(ctx, node, req)
+			}
+			retry.Reset(c.RetryTimeout)
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request %d: %w (%d replies, no f+1 match)", req.Timestamp, ctx.Err(), replies)
+		}
+	}
+}
+
+// watch nhận reply từ một replica, mở lại stream nếu replica lỗi.
+func (c *Client) watch(ctx context.Context, rc pb.ConsensusServiceClient, votes chan<- *pb.PbftReply) {
+	for ctx.Err() == nil {
+		c.stream(ctx, rc, votes)
+		select {
+		case <-time.After(c.RetryTimeout / 4):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// stream chuyển các reply kiểm tra được vào votes tới khi stream đóng; reply
+// không kiểm tra được bị bỏ.
+func (c *Client) stream(ctx context.Context, rc pb.ConsensusServiceClient, votes chan<- *pb.PbftReply) {
+	st, err := rc.WatchReplies(ctx, &pb.ReplyWatchArgs{ClientId: c.ID, SessionKey: c.dh.PublicKey().Bytes()})
+	if err != nil {
+		return
+	}
+	var peer string // Replica đã lập session key trên stream này
+	var key []byte
+	for {
+		r, err := st.Recv()
+		if err != nil {
+			return
+		}
+		switch {
+		case r.SessionKey != nil:
+			// Mở đầu stream chế độ MAC: public key X25519 của replica, đã ký
+			if k, err := c.sessionKey(r); err == nil {
+				peer, key = r.NodeId, k
+			}
+			continue
+		case r.Authenticator != nil:
+			if key == nil || r.NodeId != peer || !hmac.Equal(r.Authenticator, mac(key, replyBytes(r))) {
+				continue
+			}
+		case !c.verify(r):
+			continue
+		}
+		select {
+		case votes <- r:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// verify kiểm tra chữ ký của reply theo public key của NodeId trong reply.
+func (c *Client) verify(r *pb.PbftReply) bool {
+	pub, ok := c.keys[r.NodeId]
+	return ok && ed25519.Verify(pub, replyBytes(r), r.Signature)
+}
+
+// sessionKey tính session key với replica từ reply mở đầu stream (cùng công
+// thức với replica).
+func (c *Client) sessionKey(hello *pb.PbftReply) ([]byte, error) {
+	if !c.verify(hello) {
+		return nil, fmt.Errorf("invalid signature from %s", hello.NodeId)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(hello.SessionKey)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := c.dh.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256(append([]byte("pbft-reply/"+hello.NodeId+"/"+c.ID+"/"), shared...))
+	return key[:], nil
+}
+
+// requestBytes là nội dung request được ký (trùng với replica).
+func requestBytes(r *pb.PbftRequest) []byte {
+	m := proto.Clone(r).(*pb.PbftRequest)
+	m.Signature = nil
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return data
+}
+
+// replyBytes là nội dung reply được xác thực (trùng với replica).
+func replyBytes(r *pb.PbftReply) []byte {
+	m := proto.Clone(r).(*pb.PbftReply)
+	m.Signature, m.Authenticator = nil, nil
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return data
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (c *Client) send(ctx context.Context, node string, req *pb.PbftRequest) {
+	rc, ok := c.replicas[node]
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.RetryTimeout)
+	defer cancel()
+	rc.SubmitRequest(ctx, req)
+}
+
+// nextTimestamp tăng dần kể cả khi gọi nhiều lần trong cùng nano giây.
+func (c *Client) nextTimestamp() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastTs = max(time.Now().UnixNano(), c.lastTs+1)
+	return c.lastTs
+}
+
+// primary đoán Primary theo view mới nhất đã thấy (cùng công thức với replica).
+func (c *Client) primary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("node%d", int(c.view-1)%len(c.replicas)+1)
+}
+
+func (c *Client) observeView(view int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.view = max(c.view, view)
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/grpc"
+)
+
+// fakeReplica đẩy reply do test quyết định qua WatchReplies; replica trung
+// thực chỉ trả lời sau khi cluster giả đã thực thi request. Reply được ký
+// bằng khoá của replica, hoặc mang HMAC nếu mac.
+type fakeReplica struct {
+	pb.ConsensusServiceClient // Các RPC client không dùng: gọi tới sẽ panic
+
+	node  string
+	key   ed25519.PrivateKey
+	net   *fakeNet
+	down  bool                                    // SubmitRequest thất bại (Primary crash)
+	mac   bool                                    // Xác thực reply bằng HMAC với session key
+	reply func(req *pb.PbftRequest) *pb.PbftReply // nil = trung thực
+
+	mu        sync.Mutex
+	submitted int
+}
+
+// fakeNet giữ trạng thái chung: request đã được một replica sống nhận và thực thi chưa.
+type fakeNet struct {
+	client   ed25519.PublicKey // Replica giả kiểm tra chữ ký request theo khoá này
+	once     sync.Once
+	executed chan struct{}
+	req      *pb.PbftRequest // Request đã thực thi, ghi trước khi đóng executed
+}
+
+func (r *fakeReplica) SubmitRequest(ctx context.Context, req *pb.PbftRequest, _ ...grpc.CallOption) (*pb.PbftResponse, error) {
+	r.mu.Lock()
+	r.submitted++
+	r.mu.Unlock()
+	if r.down {
+		return nil, fmt.Errorf("%s unavailable", r.node)
+	}
+	if !ed25519.Verify(r.net.client, requestBytes(req), req.Signature) {
+		return &pb.PbftResponse{Success: false, Message: "invalid client signature"}, nil
+	}
+	r.net.once.Do(func() {
+		r.net.req = req
+		close(r.net.executed)
+	})
+	return &pb.PbftResponse{Success: true}, nil
+}
+
+// fakeStream là stream WatchReplies phía client, nhận reply từ kênh.
+type fakeStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	replies chan *pb.PbftReply
+}
+
+func (s *fakeStream) Recv() (*pb.PbftReply, error) {
+	select {
+	case r := <-s.replies:
+		return r, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (r *fakeReplica) WatchReplies(ctx context.Context, args *pb.ReplyWatchArgs, _ ...grpc.CallOption) (grpc.ServerStreamingClient[pb.PbftReply], error) {
+	st := &fakeStream{ctx: ctx, replies: make(chan *pb.PbftReply, 2)}
+	var session []byte
+	if r.mac {
+		dh, _ := ecdh.X25519().GenerateKey(rand.Reader)
+		pub, _ := ecdh.X25519().NewPublicKey(args.SessionKey)
+		shared, _ := dh.ECDH(pub)
+		k := sha256.Sum256(append([]byte("pbft-reply/"+r.node+"/"+args.ClientId+"/"), shared...))
+		session = k[:]
+		hello := &pb.PbftReply{ClientId: args.ClientId, NodeId: r.node, SessionKey: dh.PublicKey().Bytes()}
+		hello.Signature = ed25519.Sign(r.key, replyBytes(hello))
+		st.replies <- hello
+	}
+	go func() {
+		if r.down {
+			return
+		}
+		select {
+		case <-r.net.executed:
+		case <-ctx.Done():
+			return
+		}
+		req := r.net.req
+		reply := &pb.PbftReply{View: 1, Timestamp: req.Timestamp, ClientId: req.ClientId, NodeId: r.node, Sequence: 7, BlockHash: "good"}
+		if r.reply != nil {
+			reply = r.reply(req)
+		}
+		if session != nil {
+			reply.Authenticator = mac(session, replyBytes(reply))
+		} else {
+			reply.Signature = ed25519.Sign(r.key, replyBytes(reply))
+		}
+		st.replies <- reply
+	}()
+	return st, nil
+}
+
+func (r *fakeReplica) submits() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.submitted
+}
+
+// newFakeClient tạo client trên 4 replica giả (f = 1); node1 là Primary của view 1.
+func newFakeClient(t *testing.T, setup func(map[string]*fakeReplica)) (*Client, map[string]*fakeReplica) {
+	t.Helper()
+	seed := sha256.Sum256([]byte("fake-client/alice"))
+	alice := ed25519.NewKeyFromSeed(seed[:])
+	net := &fakeNet{client: alice.Public().(ed25519.PublicKey), executed: make(chan struct{})}
+	fakes := make(map[string]*fakeReplica)
+	conns := make(map[string]pb.ConsensusServiceClient)
+	keys := make(map[string]ed25519.PublicKey)
+	for i := 1; i <= 4; i++ {
+		node := fmt.Sprintf("node%d", i)
+		seed := sha256.Sum256([]byte("fake-replica/" + node))
+		key := ed25519.NewKeyFromSeed(seed[:])
+		fakes[node] = &fakeReplica{node: node, key: key, net: net}
+		conns[node], keys[node] = fakes[node], key.Public().(ed25519.PublicKey)
+	}
+	if setup != nil {
+		setup(fakes)
+	}
+	c := New("alice", alice, conns, keys)
+	c.RetryTimeout = 100 * time.Millisecond
+	return c, fakes
+}
+
+func submit(t *testing.T, c *Client, d time.Duration) (*Result, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return c.Submit(ctx, []byte("op"))
+}
+
+func TestAcceptsFPlusOneMatchingReplies(t *testing.T) {
+	// Hai replica (node3, node4) không bao giờ trả lời: f+1 = 2 reply khớp là đủ
+	c, fakes := newFakeClient(t, func(fakes map[string]*fakeReplica) {
+		fakes["node3"].down, fakes["node4"].down = true, true
+	})
+	res, err := submit(t, c, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sequence != 7 || res.BlockHash != "good" {
+		t.Fatalf("result = %+v", res)
+	}
+	if n := fakes["node2"].submits(); n != 0 {
+		t.Fatalf("request retransmitted to node2 (%d times) although the primary accepted it", n)
+	}
+}
+
+func TestIgnoresForgedAndMismatchedReplies(t *testing.T) {
+	// node2 Byzantine trả reply sai, mạo danh node3 (ký bằng khoá của node2)
+	// để được đếm hai lần; node4 trả reply cho request khác. Chỉ node1 và node3
+	// trung thực.
+	c, _ := newFakeClient(t, func(fakes map[string]*fakeReplica) {
+		fakes["node2"].reply = func(req *pb.PbftRequest) *pb.PbftReply {
+			return &pb.PbftReply{View: 1, Timestamp: req.Timestamp, ClientId: req.ClientId, NodeId: "node3", Sequence: 7, BlockHash: "forged"}
+		}
+		fakes["node4"].reply = func(req *pb.PbftRequest) *pb.PbftReply {
+			return &pb.PbftReply{View: 1, Timestamp: req.Timestamp - 1, ClientId: req.ClientId, NodeId: "node4", Sequence: 7, BlockHash: "forged"}
+		}
+	})
+	res, err := submit(t, c, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BlockHash != "good" {
+		t.Fatalf("accepted %+v", res)
+	}
+
+	// Chỉ còn một replica trung thực trả lời: reply giả không được ghép thành f+1
+	c, _ = newFakeClient(t, func(fakes map[string]*fakeReplica) {
+		forged := func(req *pb.PbftRequest) *pb.PbftReply {
+			return &pb.PbftReply{View: 1, Timestamp: req.Timestamp, ClientId: req.ClientId, NodeId: "node1", Sequence: 7, BlockHash: "forged"}
+		}
+		fakes["node2"].reply = forged
+		fakes["node3"].reply = func(req *pb.PbftRequest) *pb.PbftReply {
+			r := forged(req)
+			r.ClientId = "mallory"
+			return r
+		}
+		fakes["node4"].down = true
+	})
+	if res, err := submit(t, c, 500*time.Millisecond); err == nil {
+		t.Fatalf("accepted %+v without f+1 matching replies", res)
+	}
+}
+
+func TestRetransmitsToAllReplicasOnTimeout(t *testing.T) {
+	// Primary (node1) không nhận request: sau RetryTimeout client gửi lại cho mọi replica
+	c, fakes := newFakeClient(t, func(fakes map[string]*fakeReplica) {
+		fakes["node1"].down = true
+	})
+	start := time.Now()
+	res, err := submit(t, c, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < c.RetryTimeout {
+		t.Fatalf("accepted after %v, before the retry timeout", elapsed)
+	}
+	if res.BlockHash != "good" {
+		t.Fatalf("result = %+v", res)
+	}
+	// Các lần gửi lại chạy song song, có thể chưa tới hết khi Submit trả về
+	deadline := time.Now().Add(time.Second)
+	for node, r := range fakes {
+		for r.submits() == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if r.submits() == 0 {
+			t.Errorf("%s never received the retransmitted request", node)
+		}
+	}
+}
+
+func TestMACReplies(t *testing.T) {
+	// Mọi replica xác thực reply bằng HMAC; node2 dùng session key của mình
+	// cho reply mạo danh node1 nên reply đó bị bỏ
+	setup := func(down ...string) func(map[string]*fakeReplica) {
+		return func(fakes map[string]*fakeReplica) {
+			for _, r := range fakes {
+				r.mac = true
+			}
+			fakes["node2"].reply = func(req *pb.PbftRequest) *pb.PbftReply {
+				return &pb.PbftReply{View: 1, Timestamp: req.Timestamp, ClientId: req.ClientId, NodeId: "node1", Sequence: 7, BlockHash: "forged"}
+			}
+			for _, node := range down {
+				fakes[node].down = true
+			}
+		}
+	}
+	c, _ := newFakeClient(t, setup("node3"))
+	if res, err := submit(t, c, 2*time.Second); err != nil || res.BlockHash != "good" {
+		t.Fatalf("result = %+v %v", res, err)
+	}
+
+	c, _ = newFakeClient(t, setup("node3", "node4"))
+	if res, err := submit(t, c, 500*time.Millisecond); err == nil {
+		t.Fatalf("accepted %+v with a single honest MAC reply", res)
+	}
+}
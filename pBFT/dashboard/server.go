@@ -210,7 +210,8 @@ func handleAskGemini(w http.ResponseWriter, r *http.Request) {
 	var req struct { Logs string `json:"logs"` }; json.NewDecoder(r.Body).Decode(&req)
 	prompt := "Phân tích log từ hệ thống mô phỏng thuật toán đồng thuận pBFT này (PrePrepare, Prepare, Commit). Giải thích bất kỳ hành vi độc hại nào:\n" + req.Logs
 	body, _ := json.Marshal(map[string]interface{}{"contents": []interface{}{map[string]interface{}{"parts": []interface{}{map[string]interface{}{"text": prompt}}}}})
-	resp, _ := http.Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-flash-latest:generateContent?key="+key, "application/json", bytes.NewBuffer(body))
+	resp, err := http.Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-flash-latest:generateContent?key="+key, "application/json", bytes.NewBuffer(body))
+	if err != nil { http.Error(w, err.Error(), 502); return }
 	defer resp.Body.Close(); io.Copy(w, resp.Body)
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+    "crypto/ed25519"
     "encoding/json"
     "flag"
     "fmt"
@@ -8,6 +9,7 @@ import (
     "net"
     "net/http"
     "strconv"
+    "time"
 
     "google.golang.org/grpc"
 
@@ -17,12 +19,18 @@ import (
 
     // 2. Import Proto chung từ folder common/proto
     pb "consensus/common/proto"
+    "consensus/common/netem"
 )
 
 func main() {
     // --- 1. Parsing Flags ---
     port := flag.String("port", "50051", "gRPC Port")
     id := flag.String("id", "node1", "Node ID")
+    topology := flag.String("netem", "", "WAN topology JSON (latency/jitter/bandwidth matrix)")
+    clusterFile := flag.String("cluster", "", "cluster config JSON (addresses, ed25519 public keys)")
+    keyFile := flag.String("key", "", "private key of this node (required with -cluster)")
+    genKeys := flag.String("genkeys", "", "generate keys + cluster.json for the 5 local nodes into this dir and exit")
+    auth := flag.String("auth", "", "message authentication: signature | mac (default: cluster config, else signature)")
     flag.Parse()
 
     // Tính toán port HTTP (Ví dụ: 50051 -> 60051)
@@ -38,12 +46,57 @@ func main() {
         "node4": "localhost:50054",
         "node5": "localhost:50055",
     }
+    if *genKeys != "" {
+        if err := node.GenerateCluster(*genKeys, peerMap); err != nil {
+            log.Fatalf("genkeys: %v", err)
+        }
+        return
+    }
     // Loại bỏ chính mình khỏi danh sách Peers
     delete(peerMap, *id)
 
+    // Cluster config thay danh sách mặc định và cung cấp public key để kiểm tra chữ ký
+    var cfg *node.ClusterConfig
+    if *clusterFile != "" {
+        var err error
+        if cfg, err = node.LoadClusterConfig(*clusterFile); err != nil {
+            log.Fatalf("cluster: %v", err)
+        }
+        peerMap = cfg.Peers(*id)
+    }
+
     // --- 3. Init pBFT Server ---
     // Khởi tạo Node với cấu hình mạng
     pbftServer := node.NewServer(*id, peerMap)
+    if cfg != nil {
+        key, err := node.LoadKey(*keyFile)
+        if err != nil {
+            log.Fatalf("key: %v", err)
+        }
+        registry := cfg.Registry()
+        if !key.Public().(ed25519.PublicKey).Equal(registry[*id]) {
+            log.Fatalf("key: %s does not match public key of %s in %s", *keyFile, *id, *clusterFile)
+        }
+        pbftServer.SetKeys(key, registry)
+        pbftServer.SetClients(cfg.ClientRegistry())
+        if *auth == "" {
+            *auth = cfg.Auth
+        }
+    }
+    if *auth != "" {
+        if err := pbftServer.SetAuth(*auth); err != nil {
+            log.Fatalf("auth: %v", err)
+        }
+    }
+
+    // Giả lập WAN: áp ma trận latency/bandwidth lên mọi link gửi đi
+    if *topology != "" {
+        t, err := netem.LoadTopology(*topology)
+        if err != nil {
+            log.Fatalf("netem: %v", err)
+        }
+        pbftServer.Faults.SetProfiles(t.Profiles(int32(pbftServer.NodeIndex)))
+    }
 
     // --- 4. Start gRPC Server ---
     lis, err := net.Listen("tcp", fmt.Sprintf(":%s", *port))
@@ -71,7 +124,34 @@ func main() {
     // Các API này dùng để Dashboard điều khiển Node
     
     // API: Kích hoạt Primary tạo Block mới
+    // Body {"client_id": ..., "operation": ...} thì gửi như request của client
+    // (node nào cũng nhận, Backup chuyển tiếp cho Primary). Request đã ký thì
+    // kèm "timestamp" và "signature" (base64); không kèm thì node ký bằng
+    // devKey, chỉ được khi cluster config không khai báo client.
     http.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+        var body struct {
+            ClientID  string `json:"client_id"`
+            Operation string `json:"operation"`
+            Timestamp int64  `json:"timestamp"`
+            Signature []byte `json:"signature"`
+        }
+        if json.NewDecoder(r.Body).Decode(&body) == nil && body.ClientID != "" {
+            req := &pb.PbftRequest{ClientId: body.ClientID, Timestamp: body.Timestamp, Operation: []byte(body.Operation), Signature: body.Signature}
+            if req.Signature == nil {
+                req.Timestamp = time.Now().UnixNano()
+                if err := pbftServer.SignDev(req); err != nil {
+                    http.Error(w, err.Error(), http.StatusForbidden)
+                    return
+                }
+            }
+            resp, _ := pbftServer.SubmitRequest(r.Context(), req)
+            if !resp.Success {
+                http.Error(w, resp.Message, http.StatusBadRequest)
+            } else {
+                w.Write([]byte(resp.Message))
+            }
+            return
+        }
         if err := pbftServer.StartConsensus(); err != nil {
             http.Error(w, err.Error(), http.StatusBadRequest)
         } else {
@@ -0,0 +1,417 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Mọi PbftMessage được xác thực theo NodeId của node gửi; node nhận bỏ tin
+// nhắn không kiểm tra được. Không xác thực, node Byzantine mạo danh được node
+// khác và tự đủ quorum Prepare/Commit. Hai chế độ:
+//   - AuthSignature: mọi tin nhắn ký ed25519, kiểm tra theo public key trong
+//     registry (nạp từ cluster config).
+//   - AuthMAC (authenticator của Castro–Liskov): Commit mang vector HMAC, mỗi
+//     phần tử tính bằng session key chung giữa node gửi và một node nhận.
+//     HMAC không chuyển giao được và mất hiệu lực khi session key đổi, nên
+//     mọi tin nhắn có thể nằm trong chứng chỉ chuyển cho bên thứ ba vẫn ký:
+//     PrePrepare/Prepare (chứng chỉ P của ViewChange, O của NewView),
+//     Checkpoint, ViewChange/NewView và NewKey.
+//
+// Session key lập bằng Diffie-Hellman X25519: mỗi node gửi NewKey (đã ký)
+// mang public key tạm thời; key của cặp (i, j) băm từ bí mật chung.
+const (
+	AuthSignature = "signature"
+	AuthMAC       = "mac"
+)
+
+// keyRetry giới hạn tần suất gửi lại NewKey cho một peer còn thiếu session key.
+const keyRetry = 500 * time.Millisecond
+
+// ClusterConfig liệt kê các node, public key của chúng, client được phép gửi
+// request và chế độ xác thực.
+//
+//	{"auth": "mac", "nodes": [{"id": "node1", "addr": "localhost:50051", "public_key": "<base64>"}, ...],
+//	 "clients": [{"id": "alice", "public_key": "<base64>"}, ...]}
+type ClusterConfig struct {
+	Auth    string         `json:"auth"` // AuthSignature (mặc định) hoặc AuthMAC
+	Nodes   []NodeConfig   `json:"nodes"`
+	Clients []ClientConfig `json:"clients"`
+}
+
+type NodeConfig struct {
+	ID        string `json:"id"`
+	Addr      string `json:"addr"`
+	PublicKey string `json:"public_key"` // ed25519, base64
+}
+
+type ClientConfig struct {
+	ID        string `json:"id"`
+	PublicKey string `json:"public_key"` // ed25519, base64
+}
+
+func LoadClusterConfig(path string) (*ClusterConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c ClusterConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cluster: parse %s: %w", path, err)
+	}
+	if len(c.Nodes) == 0 {
+		return nil, fmt.Errorf("cluster: %s has no nodes", path)
+	}
+	if c.Auth != "" && c.Auth != AuthSignature && c.Auth != AuthMAC {
+		return nil, fmt.Errorf("cluster: unknown auth mode %q", c.Auth)
+	}
+	for _, n := range c.Nodes {
+		if k, err := base64.StdEncoding.DecodeString(n.PublicKey); err != nil || len(k) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("cluster: %s has invalid public key", n.ID)
+		}
+	}
+	for _, cl := range c.Clients {
+		if k, err := base64.StdEncoding.DecodeString(cl.PublicKey); err != nil || len(k) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("cluster: client %s has invalid public key", cl.ID)
+		}
+	}
+	return &c, nil
+}
+
+// Peers trả về địa chỉ các node khác `self`.
+func (c *ClusterConfig) Peers(self string) map[string]string {
+	peers := make(map[string]string)
+	for _, n := range c.Nodes {
+		if n.ID != self {
+			peers[n.ID] = n.Addr
+		}
+	}
+	return peers
+}
+
+// Registry trả về public key theo NodeID.
+func (c *ClusterConfig) Registry() map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, n := range c.Nodes {
+		k, _ := base64.StdEncoding.DecodeString(n.PublicKey)
+		keys[n.ID] = k
+	}
+	return keys
+}
+
+// ClientRegistry trả về public key của client theo ClientId.
+func (c *ClusterConfig) ClientRegistry() map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, cl := range c.Clients {
+		k, _ := base64.StdEncoding.DecodeString(cl.PublicKey)
+		keys[cl.ID] = k
+	}
+	return keys
+}
+
+// LoadKey đọc private key của node (seed ed25519 dạng base64).
+func LoadKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("key: %s is not a base64 ed25519 seed", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GenerateCluster sinh cặp khoá cho mỗi node trong `addrs`, ghi
+// dir/cluster.json và dir/<id>.key.
+func GenerateCluster(dir string, addrs map[string]string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	c := &ClusterConfig{}
+	for i := 1; i <= len(addrs); i++ {
+		id := fmt.Sprintf("node%d", i)
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		seed := base64.StdEncoding.EncodeToString(priv.Seed())
+		if err := os.WriteFile(filepath.Join(dir, id+".key"), []byte(seed+"\n"), 0o600); err != nil {
+			return err
+		}
+		c.Nodes = append(c.Nodes, NodeConfig{ID: id, Addr: addrs[id], PublicKey: base64.StdEncoding.EncodeToString(pub)})
+	}
+	data, _ := json.MarshalIndent(c, "", "  ")
+	return os.WriteFile(filepath.Join(dir, "cluster.json"), append(data, '\n'), 0o644)
+}
+
+// devKey là khoá suy ra từ NodeID, dùng khi chạy không có cluster config
+// (demo, test). Ai cũng tính lại được nên không chống được mạo danh.
+func devKey(id string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte("pbft-dev-key/" + id))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+func devRegistry(ids []string) map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, id := range ids {
+		keys[id] = devKey(id).Public().(ed25519.PublicKey)
+	}
+	return keys
+}
+
+// SetKeys đặt private key của node và registry public key của cluster.
+func (s *Server) SetKeys(key ed25519.PrivateKey, registry map[string]ed25519.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signer, s.registry = key, registry
+}
+
+// SetClients đặt public key của các client được phép gửi request. Chưa gọi
+// thì client dùng devKey theo ClientId (demo, test).
+func (s *Server) SetClients(keys map[string]ed25519.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = keys
+}
+
+// SetAuth chọn chế độ xác thực tin nhắn gửi đi; gọi trước ConnectToPeers.
+func (s *Server) SetAuth(mode string) error {
+	if mode != AuthSignature && mode != AuthMAC {
+		return fmt.Errorf("unknown auth mode %q", mode)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Auth = mode
+	return nil
+}
+
+// signedBytes là nội dung được xác thực: message (kể cả request nhúng) trừ
+// chữ ký và authenticator.
+func signedBytes(msg *pb.PbftMessage) []byte {
+	m := proto.Clone(msg).(*pb.PbftMessage)
+	m.Signature, m.Authenticators = nil, nil
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return data
+}
+
+func (s *Server) sign(msg *pb.PbftMessage) {
+	msg.Signature = ed25519.Sign(s.signer, signedBytes(msg))
+}
+
+// authenticate gắn chữ ký hoặc vector HMAC vào msg theo chế độ xác thực
+// (gọi khi đang giữ mu).
+func (s *Server) authenticate(msg *pb.PbftMessage) {
+	if msg.Type == "Commit" && s.Auth == AuthMAC {
+		data := signedBytes(msg)
+		msg.Authenticators = make(map[string][]byte, len(s.sessionKeys))
+		for id, key := range s.sessionKeys {
+			msg.Authenticators[id] = mac(key, data)
+		}
+		return
+	}
+	s.sign(msg)
+}
+
+// verify kiểm tra chữ ký (nếu có) hoặc HMAC dành cho node này trong msg
+// (gọi khi đang giữ mu).
+func (s *Server) verify(msg *pb.PbftMessage) error {
+	if msg.Signature != nil || msg.Authenticators == nil {
+		key, ok := s.registry[msg.NodeId]
+		if !ok {
+			return fmt.Errorf("unknown sender %q", msg.NodeId)
+		}
+		if !ed25519.Verify(key, signedBytes(msg), msg.Signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	if msg.Type != "Commit" {
+		return fmt.Errorf("%s must be signed", msg.Type)
+	}
+	key, ok := s.sessionKeys[msg.NodeId]
+	tag, tagged := msg.Authenticators[s.NodeID]
+	if !ok || !tagged {
+		// Một trong hai bên chưa nhận NewKey của bên kia
+		s.requestKey(msg.NodeId)
+		return fmt.Errorf("no session key with %s", msg.NodeId)
+	}
+	if !hmac.Equal(tag, mac(key, signedBytes(msg))) {
+		return fmt.Errorf("invalid authenticator")
+	}
+	return nil
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// newKeyMsg là NewKey mang public key X25519 tạm thời của node, đã ký.
+func (s *Server) newKeyMsg() *pb.PbftMessage {
+	msg := &pb.PbftMessage{Type: "NewKey", NodeId: s.NodeID, SessionKey: s.dh.PublicKey().Bytes(), Timestamp: time.Now().UnixMilli()}
+	s.sign(msg)
+	return msg
+}
+
+// sendKey gửi NewKey tới `peer`, hoặc mọi peer nếu peer rỗng (gọi khi đang giữ mu).
+func (s *Server) sendKey(peer string) {
+	msg := s.newKeyMsg()
+	for id, c := range s.PeerClients {
+		if peer != "" && id != peer {
+			continue
+		}
+		go func(c pb.ConsensusServiceClient) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			c.HandlePbftMessage(ctx, msg)
+		}(c)
+	}
+}
+
+// requestKey gửi lại NewKey cho peer còn thiếu session key, tối đa mỗi keyRetry một lần.
+func (s *Server) requestKey(peer string) {
+	if _, known := s.registry[peer]; !known || time.Since(s.keySent[peer]) < keyRetry {
+		return
+	}
+	s.keySent[peer] = time.Now()
+	s.sendKey(peer)
+}
+
+// handleNewKey lập session key với node gửi. Public key tạm thời của peer
+// mới hoặc đã đổi (peer khởi động lại) thì gửi lại NewKey của mình để peer
+// tính cùng key.
+func (s *Server) handleNewKey(req *pb.PbftMessage) {
+	pub, err := ecdh.X25519().NewPublicKey(req.SessionKey)
+	if err != nil || req.NodeId == s.NodeID {
+		return
+	}
+	shared, err := s.dh.ECDH(pub)
+	if err != nil {
+		return
+	}
+	lo, hi := min(s.NodeID, req.NodeId), max(s.NodeID, req.NodeId)
+	key := sha256.Sum256(append([]byte("pbft-session/"+lo+"/"+hi+"/"), shared...))
+	changed := !hmac.Equal(s.peerDH[req.NodeId], req.SessionKey)
+	s.peerDH[req.NodeId], s.sessionKeys[req.NodeId] = req.SessionKey, key[:]
+	if changed {
+		s.report("SESSION-KEY", fmt.Sprintf("Session key with %s established", req.NodeId), "gray")
+		s.sendKey(req.NodeId)
+	}
+}
+
+// Reply gửi client được xác thực như tin nhắn giữa các replica: chế độ chữ
+// ký thì ký ed25519; chế độ MAC thì HMAC bằng session key giữa replica và
+// client, lập bằng X25519 từ public key client gửi khi mở WatchReplies. Reply
+// mở đầu stream mang public key X25519 của replica và được ký.
+
+// replyBytes là nội dung reply được xác thực (trừ chữ ký và authenticator);
+// client tính giống hệt.
+func replyBytes(r *pb.PbftReply) []byte {
+	m := proto.Clone(r).(*pb.PbftReply)
+	m.Signature, m.Authenticator = nil, nil
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return data
+}
+
+// replyKey lập session key với client từ public key X25519 trong args và trả
+// về reply mở đầu stream (đã ký) mang public key của replica.
+func (s *Server) replyKey(args *pb.ReplyWatchArgs) ([]byte, *pb.PbftReply, error) {
+	pub, err := ecdh.X25519().NewPublicKey(args.SessionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid client session key")
+	}
+	shared, err := s.dh.ECDH(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := sha256.Sum256(append([]byte("pbft-reply/"+s.NodeID+"/"+args.ClientId+"/"), shared...))
+	hello := &pb.PbftReply{View: s.View, ClientId: args.ClientId, NodeId: s.NodeID, SessionKey: s.dh.PublicKey().Bytes()}
+	hello.Signature = ed25519.Sign(s.signer, replyBytes(hello))
+	return key[:], hello, nil
+}
+
+// authenticateReply trả về bản sao của r mang HMAC bằng `key`, hoặc chữ ký
+// nếu không có session key.
+func (s *Server) authenticateReply(r *pb.PbftReply, key []byte) *pb.PbftReply {
+	out := proto.Clone(r).(*pb.PbftReply)
+	if key != nil {
+		out.Authenticator = mac(key, replyBytes(out))
+	} else {
+		out.Signature = ed25519.Sign(s.signer, replyBytes(out))
+	}
+	return out
+}
+
+// Request của client mang chữ ký ed25519 trên <REQUEST, o, t, c>, kiểm tra
+// theo public key của client trong cluster config (node cũng được gửi
+// request, ký bằng khoá node). Không có chữ ký, Primary Byzantine tự tạo được
+// request mạo danh client và Backup không phân biệt được.
+
+// requestBytes là nội dung request được ký (trừ chữ ký); client tính giống hệt.
+func requestBytes(r *pb.PbftRequest) []byte {
+	m := proto.Clone(r).(*pb.PbftRequest)
+	m.Signature = nil
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return data
+}
+
+// clientKey trả về public key kiểm tra request của client `id` (gọi khi đang giữ mu).
+func (s *Server) clientKey(id string) (ed25519.PublicKey, bool) {
+	if key, ok := s.clients[id]; ok {
+		return key, true
+	}
+	if key, ok := s.registry[id]; ok {
+		return key, true
+	}
+	if s.clients == nil {
+		return devKey(id).Public().(ed25519.PublicKey), true
+	}
+	return nil, false
+}
+
+// validRequest kiểm tra client_id, kích thước operation và chữ ký của client
+// (gọi khi đang giữ mu).
+func (s *Server) validRequest(r *pb.PbftRequest) error {
+	if r.ClientId == "" {
+		return fmt.Errorf("missing client_id")
+	}
+	if len(r.Operation) > MaxOperationSize {
+		return fmt.Errorf("operation of %d bytes exceeds %d", len(r.Operation), MaxOperationSize)
+	}
+	key, ok := s.clientKey(r.ClientId)
+	if !ok {
+		return fmt.Errorf("unknown client %q", r.ClientId)
+	}
+	if !ed25519.Verify(key, requestBytes(r), r.Signature) {
+		return fmt.Errorf("invalid client signature")
+	}
+	return nil
+}
+
+// SignDev ký req bằng devKey của client khi node chạy không có danh sách
+// client (demo); có danh sách thì client phải tự ký.
+func (s *Server) SignDev(req *pb.PbftRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients != nil {
+		return fmt.Errorf("client %s must sign its requests", req.ClientId)
+	}
+	req.Signature = ed25519.Sign(devKey(req.ClientId), requestBytes(req))
+	return nil
+}
@@ -0,0 +1,273 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+	"consensus/pBFT/client"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Node Byzantine (giữ khoá của node1) mạo danh node2, node3 để tự đủ quorum
+// Commit cho một block giả: chỉ tin nhắn ký đúng tên được đếm.
+func TestForgedMessagesAreDropped(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	attacker := &Server{signer: devKey("node1")}
+	victim := c.nodes[5]
+	view := c.view(all)
+	for _, from := range []string{"node1", "node2", "node3"} {
+		msg := &pb.PbftMessage{Type: "Commit", NodeId: from, View: view, Sequence: 1, BlockHash: "evil", PrevBlockHash: "Genesis-Hash"}
+		attacker.sign(msg)
+		r, _ := victim.HandlePbftMessage(context.Background(), msg)
+		if want := from == "node1"; r.Success != want {
+			t.Fatalf("commit signed by node1 claiming %s: success = %v", from, r.Success)
+		}
+	}
+	unsigned := &pb.PbftMessage{Type: "ViewChange", NodeId: "node2", View: view + 1}
+	if r, _ := victim.HandlePbftMessage(context.Background(), unsigned); r.Success {
+		t.Fatal("unsigned message accepted")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if st := c.status(5); st.Committed != 0 || st.Term != view {
+		t.Fatalf("forged messages changed state: %v", st)
+	}
+
+	// Tin nhắn thật vẫn được chấp nhận
+	c.commit(all, 1, 3*time.Second)
+	c.checkChains(all)
+	if h := c.ledger(5)[0].Hash; h == "evil" {
+		t.Fatal("forged block committed")
+	}
+}
+
+func TestGeneratedClusterKeys(t *testing.T) {
+	dir := t.TempDir()
+	addrs := make(map[string]string)
+	for i := 1; i <= TotalNodes; i++ {
+		addrs[fmt.Sprintf("node%d", i)] = fmt.Sprintf("localhost:%d", 50050+i)
+	}
+	if err := GenerateCluster(dir, addrs); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadClusterConfig(filepath.Join(dir, "cluster.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peers := cfg.Peers("node3"); len(peers) != TotalNodes-1 || peers["node1"] != "localhost:50051" {
+		t.Fatalf("peers = %v", peers)
+	}
+	registry := cfg.Registry()
+	key, err := LoadKey(filepath.Join(dir, "node3.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Public().(ed25519.PublicKey).Equal(registry["node3"]) {
+		t.Fatal("node3 key does not match registry")
+	}
+
+	// Chữ ký bằng khoá node3 chỉ hợp lệ dưới tên node3
+	s := &Server{signer: key, registry: registry}
+	msg := &pb.PbftMessage{Type: "Prepare", NodeId: "node3", Sequence: 7, BlockHash: "h"}
+	s.sign(msg)
+	if err := s.verify(msg); err != nil {
+		t.Fatal(err)
+	}
+	msg.BlockHash = "h2"
+	if s.verify(msg) == nil {
+		t.Fatal("tampered message verified")
+	}
+	msg.BlockHash, msg.NodeId = "h", "node4"
+	if s.verify(msg) == nil {
+		t.Fatal("message verified under another node's key")
+	}
+}
+
+func withAuth(mode string) func(*Server) {
+	return func(s *Server) { s.SetAuth(mode) }
+}
+
+// waitSessionKeys chờ mọi node có session key với mọi node khác.
+func (c *testCluster) waitSessionKeys(d time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for _, s := range c.nodes {
+		for {
+			s.mu.Lock()
+			n := len(s.sessionKeys)
+			s.mu.Unlock()
+			if n == TotalNodes {
+				break
+			}
+			if time.Now().After(deadline) {
+				c.t.Fatalf("%s has %d session keys", s.NodeID, n)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+func TestMACAuthenticators(t *testing.T) {
+	c := newTestCluster(t, withAuth(AuthMAC))
+	all := []int{1, 2, 3, 4, 5}
+	c.waitSessionKeys(3 * time.Second)
+
+	// Commit dùng vector HMAC; Prepare (nằm trong chứng chỉ) và View Change vẫn ký
+	s := c.nodes[1]
+	s.mu.Lock()
+	commit := &pb.PbftMessage{Type: "Commit", NodeId: "node1", View: 1, Sequence: 1, BlockHash: "h"}
+	s.authenticate(commit)
+	prepare := &pb.PbftMessage{Type: "Prepare", NodeId: "node1", View: 1, Sequence: 1, BlockHash: "h"}
+	s.authenticate(prepare)
+	vc := &pb.PbftMessage{Type: "ViewChange", NodeId: "node1", View: 2}
+	s.authenticate(vc)
+	keys := make(map[string][]byte)
+	for id, k := range s.sessionKeys {
+		keys[id] = k
+	}
+	s.mu.Unlock()
+	if commit.Signature != nil || len(commit.Authenticators) != TotalNodes {
+		t.Fatalf("commit authenticated as %v", commit)
+	}
+	for _, m := range []*pb.PbftMessage{prepare, vc} {
+		if m.Signature == nil || m.Authenticators != nil {
+			t.Fatalf("%s authenticated as %v", m.Type, m)
+		}
+	}
+
+	// node1 biết session key (node1, node5) nhưng không tạo được HMAC nhân danh node2
+	view := c.view(all)
+	for _, from := range []string{"node1", "node2", "node3"} {
+		msg := &pb.PbftMessage{Type: "Commit", NodeId: from, View: view, Sequence: 1, BlockHash: "evil", PrevBlockHash: "Genesis-Hash"}
+		msg.Authenticators = map[string][]byte{"node5": mac(keys["node5"], signedBytes(msg))}
+		r, _ := c.nodes[5].HandlePbftMessage(context.Background(), msg)
+		if want := from == "node1"; r.Success != want {
+			t.Fatalf("commit authenticated by node1 claiming %s: success = %v (%s)", from, r.Success, r.Message)
+		}
+	}
+	// ViewChange/Prepare chỉ mang HMAC bị từ chối: phải chuyển tiếp được làm bằng chứng
+	for _, typ := range []string{"ViewChange", "Prepare"} {
+		m := &pb.PbftMessage{Type: typ, NodeId: "node1", View: view + 1}
+		m.Authenticators = map[string][]byte{"node5": mac(keys["node5"], signedBytes(m))}
+		if r, _ := c.nodes[5].HandlePbftMessage(context.Background(), m); r.Success {
+			t.Fatalf("%s authenticated only by MAC accepted", typ)
+		}
+	}
+
+	// Cluster chạy đủ normal case và View Change ở chế độ MAC
+	c.commit(all, 1, 3*time.Second)
+	primary := primaryOf(c.view(all))
+	c.stop(primary)
+	var rest []int
+	for _, i := range all {
+		if i != primary {
+			rest = append(rest, i)
+		}
+	}
+	c.commit(rest, 2, 10*time.Second)
+	c.checkChains(rest)
+	if h := c.ledger(rest[0])[0].Hash; h == "evil" {
+		t.Fatal("forged block committed")
+	}
+}
+
+// Chứng chỉ prepared ở chế độ MAC gồm PrePrepare/Prepare đã ký: node thứ ba
+// kiểm tra được, kể cả sau khi session key đổi; bản chỉ mang HMAC bị từ chối.
+func TestMACCertificatesAreSigned(t *testing.T) {
+	c := newTestCluster(t, withAuth(AuthMAC))
+	all := []int{1, 2, 3, 4, 5}
+	c.waitSessionKeys(3 * time.Second)
+	c.commit(all, 1, 3*time.Second)
+
+	s := c.nodes[2]
+	s.mu.Lock()
+	certs := s.preparedCerts(s.Stable.Sequence)
+	view := s.View
+	s.mu.Unlock()
+	if len(certs) == 0 {
+		t.Fatal("no prepared certificate after commit")
+	}
+
+	checker := c.nodes[3]
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+	checker.sessionKeys = make(map[string][]byte) // Giả lập đổi session key
+	for _, cert := range certs {
+		if err := checker.validCert(cert, view+1, 0); err != nil {
+			t.Fatalf("signed certificate rejected after key rotation: %v", err)
+		}
+		forged := proto.Clone(cert).(*pb.PreparedCert)
+		for _, p := range forged.Prepares {
+			p.Authenticators = map[string][]byte{"node3": p.Signature}
+			p.Signature = nil
+		}
+		if checker.validCert(forged, view+1, 0) == nil {
+			t.Fatal("certificate of MAC-only Prepares accepted")
+		}
+	}
+}
+
+// BenchmarkAuthenticate: chi phí xác thực một Commit ở node gửi và 4 node nhận.
+func BenchmarkAuthenticate(b *testing.B) {
+	for _, mode := range []string{AuthSignature, AuthMAC} {
+		b.Run(mode, func(b *testing.B) {
+			nodes := make(map[string]*Server)
+			for i := 1; i <= TotalNodes; i++ {
+				id := fmt.Sprintf("node%d", i)
+				s := &Server{NodeID: id, Auth: mode, signer: devKey(id), registry: devRegistry([]string{"node1", "node2", "node3", "node4", "node5"})}
+				s.sessionKeys = map[string][]byte{}
+				nodes[id] = s
+			}
+			for x := range nodes {
+				for y := range nodes {
+					k := sha256.Sum256([]byte(min(x, y) + max(x, y)))
+					nodes[x].sessionKeys[y] = k[:]
+				}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				msg := &pb.PbftMessage{Type: "Commit", NodeId: "node1", View: 1, Sequence: int64(i), BlockHash: "h"}
+				nodes["node1"].authenticate(msg)
+				for id, s := range nodes {
+					if id != "node1" {
+						if err := s.verify(msg); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCommit: thông lượng một client gửi tuần tự trên cluster 5 node thật.
+func BenchmarkCommit(b *testing.B) {
+	for _, mode := range []string{AuthSignature, AuthMAC} {
+		b.Run(mode, func(b *testing.B) {
+			c := newTestCluster(b, withAuth(mode))
+			if mode == AuthMAC {
+				c.waitSessionKeys(3 * time.Second)
+			}
+			cl, err := client.Dial("bench", devKey("bench"), c.addrs, c.keys())
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				_, err := cl.Submit(ctx, []byte("op"))
+				cancel()
+				if err != nil {
+					b.Fatalf("submit %d: %v", i, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+// Batching: Primary gom request trong hàng đợi thành một block, đề xuất khi
+// đủ BatchSize request hoặc batch đang gom đã mở quá BatchTimeout. Một lượt
+// PrePrepare/Prepare/Commit (O(n²) tin nhắn) nhờ đó dùng chung cho cả batch.
+// Digest của block băm gốc Merkle của các request theo thứ tự trong batch;
+// Backup kiểm tra từng request trước khi chấp nhận PrePrepare.
+
+// nextBatch trả về batch kế tiếp ở đầu hàng đợi, hoặc nil nếu chưa tới lúc
+// đề xuất (khi đó hẹn timer gọi lại orderNext).
+func (s *Server) nextBatch() []*pb.PbftRequest {
+	// Bỏ request đã thực thi hoặc đang chạy (VD: được phát lại trong NewView)
+	pending := s.Queue[:0]
+	for _, r := range s.Queue {
+		if r.Timestamp > s.LastExecuted[r.ClientId] && !s.inFlight(r) {
+			pending = append(pending, r)
+		}
+	}
+	s.Queue = pending
+	if len(s.Queue) == 0 {
+		return nil
+	}
+	wait := BatchTimeout - time.Since(s.batchStart)
+	if len(s.Queue) >= BatchSize || wait <= 0 {
+		return slices.Clone(s.Queue[:min(BatchSize, len(s.Queue))])
+	}
+	if s.batchTimer == nil {
+		s.batchTimer = time.AfterFunc(wait, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.batchTimer = nil
+			if !s.Stopped {
+				s.orderNext()
+			}
+		})
+	}
+	return nil
+}
+
+// validBatch kiểm tra từng request Primary đưa vào block: hợp lệ theo
+// validRequest (chữ ký client, kích thước operation) và không lặp trong batch;
+// batch không rỗng (null request chỉ có trong NewView) và không vượt
+// BatchSize. Primary Byzantine nhờ đó không chèn được request mạo danh client. Request đã thực thi không làm hỏng cả block: các
+// node có LastExecuted khác nhau lúc nhận PrePrepare, còn execute bỏ qua
+// request đó như nhau trên mọi node.
+func (s *Server) validBatch(batch []*pb.PbftRequest) error {
+	if len(batch) == 0 {
+		return fmt.Errorf("empty batch")
+	}
+	if len(batch) > BatchSize {
+		return fmt.Errorf("batch of %d requests exceeds %d", len(batch), BatchSize)
+	}
+	seen := make(map[string]bool)
+	for i, r := range batch {
+		if err := s.validRequest(r); err != nil {
+			return fmt.Errorf("request %d: %w", i, err)
+		}
+		key := fmt.Sprintf("%s|%d", r.ClientId, r.Timestamp)
+		if seen[key] {
+			return fmt.Errorf("request %d: duplicate of %s in batch", i, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// merkleRoot là gốc cây Merkle trên digest các request theo thứ tự trong
+// batch: lá băm digest request, nút trong băm hai con, nút lẻ cuối mỗi tầng
+// được đẩy lên nguyên. Tiền tố 0/1 tách hash lá với hash nút trong. Batch rỗng
+// (null request) có gốc là hash của chuỗi rỗng.
+func merkleRoot(batch []*pb.PbftRequest) string {
+	if len(batch) == 0 {
+		h := sha256.Sum256(nil)
+		return hex.EncodeToString(h[:])
+	}
+	level := make([][]byte, len(batch))
+	for i, r := range batch {
+		h := sha256.Sum256(append([]byte{0}, digest(r)...))
+		level[i] = h[:]
+	}
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i+1 < len(level); i += 2 {
+			h := sha256.Sum256(append(append([]byte{1}, level[i]...), level[i+1]...))
+			next = append(next, h[:])
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		level = next
+	}
+	return hex.EncodeToString(level[0])
+}
+
+// batchData là nội dung block hiển thị trên ledger/dashboard: payload các
+// request theo thứ tự, cách nhau bởi "; ".
+func batchData(batch []*pb.PbftRequest) string {
+	ops := make([]string, len(batch))
+	for i, r := range batch {
+		ops[i] = display(r.Operation)
+	}
+	return strings.Join(ops, "; ")
+}
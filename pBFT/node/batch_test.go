@@ -0,0 +1,154 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+func requests(client string, n int) []*pb.PbftRequest {
+	batch := make([]*pb.PbftRequest, n)
+	for i := range batch {
+		batch[i] = signRequest(&pb.PbftRequest{ClientId: client, Timestamp: int64(i + 1), Operation: []byte(fmt.Sprintf("op %d", i+1))})
+	}
+	return batch
+}
+
+// Gốc Merkle phụ thuộc từng request và thứ tự của chúng trong batch.
+func TestMerkleRoot(t *testing.T) {
+	for n := 1; n <= 5; n++ {
+		batch := requests("alice", n)
+		root := merkleRoot(batch)
+		if root != merkleRoot(requests("alice", n)) {
+			t.Fatalf("%d requests: root not deterministic", n)
+		}
+		for i := range batch {
+			changed := requests("alice", n)
+			changed[i].Operation = []byte("tampered")
+			if merkleRoot(changed) == root {
+				t.Fatalf("%d requests: changing request %d keeps root", n, i)
+			}
+		}
+		if n > 1 {
+			swapped := requests("alice", n)
+			swapped[0], swapped[n-1] = swapped[n-1], swapped[0]
+			if merkleRoot(swapped) == root {
+				t.Fatalf("%d requests: reordering keeps root", n)
+			}
+		}
+		if merkleRoot(batch[:n-1]) == root {
+			t.Fatalf("%d requests: dropping the last request keeps root", n)
+		}
+	}
+}
+
+// Primary đề xuất ngay khi gom đủ BatchSize request, phần còn lại đi trong
+// block riêng khi hết BatchTimeout; mỗi request nhận reply với sequence block của nó.
+func TestBatchingBySizeAndTime(t *testing.T) {
+	override(t, &BatchSize, 4)
+	override(t, &BatchTimeout, 500*time.Millisecond)
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	primary := c.nodes[primaryOf(c.view(all))]
+
+	start := time.Now()
+	for _, req := range requests("alice", 9) {
+		if r, err := primary.SubmitRequest(context.Background(), req); err != nil || !r.Success {
+			t.Fatalf("submit %d = %v %v", req.Timestamp, r, err)
+		}
+	}
+	if !c.waitCommitted(all, 2, 400*time.Millisecond) {
+		t.Fatalf("full batches not committed: %v", c.status(1))
+	}
+	if n := c.status(1).Committed; n != 2 && time.Since(start) < BatchTimeout {
+		t.Fatalf("committed %d blocks before the batch timeout", n)
+	}
+	if !c.waitCommitted(all, 3, 2*time.Second) {
+		t.Fatalf("partial batch not committed after timeout: %v", c.status(1))
+	}
+	c.checkChains(all)
+	want := []string{"op 1; op 2; op 3; op 4", "op 5; op 6; op 7; op 8", "op 9"}
+	for i, e := range c.ledger(1) {
+		if e.Data != want[i] || e.Hash != blockHash(e.Index, e.PrevHash, e.Requests) {
+			t.Fatalf("block %d = %q %.8s, want %q", e.Index, e.Data, e.Hash, want[i])
+		}
+	}
+
+	// Replica đẩy reply gần nhất của client (request 9, block 3), đã ký
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := c.replies(ctx, 3, &pb.ReplyWatchArgs{ClientId: "alice"}).Recv()
+	if err != nil || reply.Timestamp != 9 || reply.Sequence != 3 {
+		t.Fatalf("reply = %v %v, want request 9 in block 3", reply, err)
+	}
+	if !ed25519.Verify(c.keys()["node3"], replyBytes(reply), reply.Signature) {
+		t.Fatal("reply is not signed by node3")
+	}
+}
+
+// Backup kiểm tra từng request trong batch trước khi chấp nhận PrePrepare;
+// request đã thực thi vẫn được chấp nhận.
+func TestBatchValidation(t *testing.T) {
+	override(t, &BaseTimeout, time.Minute)
+	override(t, &BatchSize, 4)
+	c := newTestCluster(t)
+	victim := c.nodes[3]
+	victim.mu.Lock()
+	victim.LastExecuted["bob"] = 7
+	victim.mu.Unlock()
+	batchPP := func(batch []*pb.PbftRequest) *pb.PbftMessage {
+		return signAs("node1", &pb.PbftMessage{
+			Type:          "PrePrepare",
+			NodeId:        "node1",
+			View:          1,
+			Sequence:      1,
+			PrevBlockHash: "Genesis-Hash",
+			BlockHash:     blockHash(1, "Genesis-Hash", batch),
+			Data:          batchData(batch),
+			Requests:      batch,
+		})
+	}
+	accepted := func() *pb.PbftMessage {
+		victim.mu.Lock()
+		defer victim.mu.Unlock()
+		if e := victim.Log[slot{1, 1}]; e != nil {
+			return e.prePrepare
+		}
+		return nil
+	}
+
+	override(t, &MaxOperationSize, 8)
+	alice := requests("alice", 3)
+	unsigned := &pb.PbftRequest{ClientId: "carol", Timestamp: 1, Operation: []byte("unsigned")}
+	// Primary tự ký request mạo danh carol bằng khoá của mình
+	forged := &pb.PbftRequest{ClientId: "carol", Timestamp: 2, Operation: []byte("forged")}
+	forged.Signature = ed25519.Sign(devKey("node1"), requestBytes(forged))
+	tampered := signRequest(&pb.PbftRequest{ClientId: "carol", Timestamp: 3, Operation: []byte("pay 1")})
+	tampered.Operation = []byte("pay 999")
+	for name, batch := range map[string][]*pb.PbftRequest{
+		"empty batch":         nil,
+		"oversized batch":     requests("alice", 5),
+		"missing client_id":   {alice[0], {Timestamp: 1, Operation: []byte("anonymous")}},
+		"duplicate request":   {alice[0], alice[1], alice[0]},
+		"unsigned request":    {alice[0], unsigned},
+		"forged signature":    {alice[0], forged},
+		"tampered operation":  {alice[0], tampered},
+		"oversized operation": {alice[0], signRequest(&pb.PbftRequest{ClientId: "carol", Timestamp: 4, Operation: []byte("too large")})},
+	} {
+		victim.HandlePbftMessage(context.Background(), batchPP(batch))
+		if pp := accepted(); pp != nil {
+			t.Fatalf("%s accepted: %v", name, pp)
+		}
+	}
+
+	// Request bob đã thực thi không làm hỏng cả block: execute sẽ bỏ qua nó
+	valid := batchPP(append(alice, signRequest(&pb.PbftRequest{ClientId: "bob", Timestamp: 7, Operation: []byte("replay")})))
+	victim.HandlePbftMessage(context.Background(), valid)
+	if pp := accepted(); pp == nil || pp.BlockHash != valid.BlockHash {
+		t.Fatalf("valid batch not accepted: %v", pp)
+	}
+}
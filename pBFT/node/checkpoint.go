@@ -0,0 +1,193 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+// Checkpoint theo Castro–Liskov: thực thi xong sequence n chia hết cho
+// CheckpointInterval, node gửi <CHECKPOINT, n, d, i> đã ký với d là digest
+// state. 2f+1 Checkpoint cùng (n, d) tạo checkpoint ổn định; message log
+// (PrePrepare/Prepare/Commit) và Checkpoint cũ có sequence ≤ n bị xoá. Hash
+// block n băm nối cả chain phía trước nên dùng làm digest state (ledger) tới n.
+//
+// Node tụt lại sau checkpoint ổn định không còn nhận được PrePrepare cho các
+// sequence đã bị xoá khỏi log, nên tải các block còn thiếu từ peer (chuyển
+// state) và chỉ nối khi hash tính lại từ request khớp tới digest đã được 2f+1
+// node xác nhận.
+
+// Checkpoint là checkpoint ổn định kèm bằng chứng: Checkpoint của 2f+1 node khác nhau.
+type Checkpoint struct {
+	Sequence int64
+	Digest   string
+	Proof    []*pb.PbftMessage
+}
+
+// stableCheckpoint trả về checkpoint ổn định gần nhất (n, digest) và bằng chứng C.
+func (s *Server) stableCheckpoint() (int64, string, []*pb.PbftMessage) {
+	return s.Stable.Sequence, s.Stable.Digest, s.Stable.Proof
+}
+
+// sendCheckpoint gửi Checkpoint cho block `b` vừa thực thi nếu tới chu kỳ.
+func (s *Server) sendCheckpoint(b Block) {
+	if b.Sequence%CheckpointInterval != 0 {
+		return
+	}
+	go s.Broadcast(&pb.PbftMessage{
+		Type:      "Checkpoint",
+		NodeId:    s.NodeID,
+		Sequence:  b.Sequence,
+		BlockHash: b.Hash,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+func (s *Server) handleCheckpoint(req *pb.PbftMessage) {
+	n := req.Sequence
+	if n <= s.Stable.Sequence {
+		return
+	}
+	if _, ok := s.Checkpoints[n]; !ok {
+		s.Checkpoints[n] = make(map[string]*pb.PbftMessage)
+	}
+	if _, ok := s.Checkpoints[n][req.NodeId]; ok {
+		return
+	}
+	s.Checkpoints[n][req.NodeId] = req
+
+	var proof []*pb.PbftMessage
+	for _, m := range s.Checkpoints[n] {
+		if m.BlockHash == req.BlockHash {
+			proof = append(proof, m)
+		}
+	}
+	if len(proof) < Quorum {
+		return
+	}
+	if n <= s.Sequence && s.Blockchain[n].Hash != req.BlockHash {
+		s.report("DIVERGED", fmt.Sprintf("Stable checkpoint #%d digest %.8s differs from local block %.8s", n, req.BlockHash, s.Blockchain[n].Hash), "red")
+	}
+	s.setStable(Checkpoint{Sequence: n, Digest: req.BlockHash, Proof: proof})
+	// Cửa sổ watermark dịch lên
+	s.replayFuture()
+	s.orderNext()
+}
+
+// setStable ghi nhận checkpoint ổn định mới và xoá message log tới sequence của nó.
+func (s *Server) setStable(c Checkpoint) {
+	if c.Sequence <= s.Stable.Sequence {
+		return
+	}
+	s.Stable = c
+	for sl := range s.Log {
+		if sl.seq <= c.Sequence {
+			delete(s.Log, sl)
+		}
+	}
+	for seq := range s.Committed {
+		if seq <= c.Sequence {
+			delete(s.Committed, seq)
+		}
+	}
+	for seq := range s.Checkpoints {
+		if seq <= c.Sequence {
+			delete(s.Checkpoints, seq)
+		}
+	}
+	for sl := range s.Future {
+		if sl.seq <= c.Sequence {
+			delete(s.Future, sl)
+		}
+	}
+	s.report("CHECKPOINT", fmt.Sprintf("Stable checkpoint #%d (%d proofs), log below discarded", c.Sequence, len(c.Proof)), "gray")
+	if c.Sequence > s.Sequence && !s.fetching {
+		s.fetching = true
+		go s.fetchState()
+	}
+}
+
+// fetchState tải block tới checkpoint ổn định từ các node đã ký bằng chứng của
+// nó, lặp lại nếu trong lúc tải có checkpoint ổn định mới hơn.
+func (s *Server) fetchState() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() { s.fetching = false }()
+	for progress := true; progress && s.Stable.Sequence > s.Sequence; {
+		progress = false
+		c := s.Stable
+		for _, m := range c.Proof {
+			peer, ok := s.PeerClients[m.NodeId]
+			if !ok {
+				continue
+			}
+			from := s.Sequence + 1
+			s.mu.Unlock()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			reply, err := peer.GetLedger(ctx, &pb.LedgerArgs{FromIndex: from})
+			cancel()
+			s.mu.Lock()
+			if err == nil && s.applyState(c, reply.Entries) {
+				progress = true
+				break
+			}
+		}
+	}
+}
+
+// applyState nối các block tới checkpoint `c` nếu chúng tạo thành chain hợp lệ
+// từ đỉnh chain hiện tại tới digest của checkpoint (gọi khi đang giữ mu).
+func (s *Server) applyState(c Checkpoint, entries []*pb.LedgerEntry) bool {
+	if s.Stopped || s.Sequence >= c.Sequence {
+		return true
+	}
+	tip := s.Blockchain[len(s.Blockchain)-1]
+	var blocks []*pb.PbftMessage
+	prev := tip.Hash
+	for _, e := range entries {
+		if e.Index <= tip.Sequence || e.Index > c.Sequence {
+			continue
+		}
+		if e.Index != tip.Sequence+int64(len(blocks))+1 || e.PrevHash != prev || e.Hash != blockHash(e.Index, prev, e.Requests) {
+			return false
+		}
+		blocks = append(blocks, &pb.PbftMessage{Sequence: e.Index, PrevBlockHash: e.PrevHash, BlockHash: e.Hash, Requests: e.Requests})
+		prev = e.Hash
+	}
+	if len(blocks) == 0 || blocks[len(blocks)-1].Sequence != c.Sequence || prev != c.Digest {
+		return false
+	}
+	for _, b := range blocks {
+		s.execute(b)
+	}
+	s.report("STATE-TRANSFER", fmt.Sprintf("Fetched blocks #%d-#%d up to stable checkpoint", tip.Sequence+1, c.Sequence), "purple")
+	// Instance sau checkpoint có thể đã committed-local trong lúc chờ
+	s.advance(s.View, s.Sequence+1)
+	return true
+}
+
+// validCheckpoint kiểm tra checkpoint (n, digest) kèm bằng chứng C: Genesis,
+// hoặc Checkpoint đã ký cùng (n, digest) từ 2f+1 node khác nhau.
+func (s *Server) validCheckpoint(n int64, digest string, proof []*pb.PbftMessage) error {
+	if n == 0 {
+		if digest != s.Blockchain[0].Hash {
+			return fmt.Errorf("genesis checkpoint with digest %.8s", digest)
+		}
+		return nil
+	}
+	from := make(map[string]bool)
+	for _, m := range proof {
+		if m.Type != "Checkpoint" || m.Sequence != n || m.BlockHash != digest {
+			continue
+		}
+		if s.verify(m) == nil {
+			from[m.NodeId] = true
+		}
+	}
+	if len(from) < Quorum {
+		return fmt.Errorf("checkpoint %d has %d valid proofs", n, len(from))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+// Sau mỗi CheckpointInterval block, checkpoint ổn định trên mọi node và log
+// tới đó bị xoá; view change sau checkpoint bắt đầu O từ checkpoint.
+func TestCheckpointGarbageCollection(t *testing.T) {
+	override(t, &CheckpointInterval, 2)
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	for n := int64(1); n <= 5; n++ {
+		c.commit(all, n, 3*time.Second)
+	}
+	stable := func(i int) Checkpoint {
+		s := c.nodes[i]
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Stable
+	}
+	deadline := time.Now().Add(time.Second)
+	for _, i := range all {
+		for stable(i).Sequence < 4 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	digest := c.ledger(1)[3].Hash
+	check := func(s *Server) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.Stable.Sequence != 4 || s.Stable.Digest != digest || len(s.Stable.Proof) < Quorum {
+			return fmt.Errorf("stable checkpoint = #%d %.8s with %d proofs", s.Stable.Sequence, s.Stable.Digest, len(s.Stable.Proof))
+		}
+		for sl := range s.Log {
+			if sl.seq <= 4 {
+				return fmt.Errorf("still logs view %d seq %d", sl.view, sl.seq)
+			}
+		}
+		for seq := range s.Checkpoints {
+			if seq <= 4 {
+				return fmt.Errorf("still keeps checkpoint %d", seq)
+			}
+		}
+		if err := s.validCheckpoint(4, digest, s.Stable.Proof); err != nil {
+			return fmt.Errorf("rejects its own proof: %v", err)
+		}
+		if s.validCheckpoint(4, "forged", s.Stable.Proof) == nil || s.validCheckpoint(4, digest, s.Stable.Proof[:Quorum-1]) == nil {
+			return fmt.Errorf("accepts checkpoint without 2f+1 matching proofs")
+		}
+		return nil
+	}
+	for _, i := range all {
+		if err := check(c.nodes[i]); err != nil {
+			t.Fatalf("node%d: %v", i, err)
+		}
+	}
+
+	// Primary crash: ViewChange mang checkpoint #4 thay vì toàn bộ chứng chỉ từ Genesis
+	var primary int
+	for _, i := range all {
+		if c.status(i).State == "Primary" {
+			primary = i
+		}
+	}
+	c.stop(primary)
+	var rest []int
+	for _, i := range all {
+		if i != primary {
+			rest = append(rest, i)
+		}
+	}
+	c.commit(rest, 6, 10*time.Second)
+	c.checkChains(rest)
+	for _, i := range rest {
+		s := c.nodes[i]
+		s.mu.Lock()
+		nv := s.LastNewView
+		s.mu.Unlock()
+		if nv == nil {
+			t.Fatalf("node%d has no NewView", i)
+		}
+		for _, vc := range nv.ViewChanges {
+			if vc.Sequence < 4 {
+				t.Fatalf("ViewChange from %s carries checkpoint %d", vc.NodeId, vc.Sequence)
+			}
+			for _, cert := range vc.Prepared {
+				if cert.PrePrepare.Sequence <= vc.Sequence {
+					t.Fatalf("ViewChange from %s carries certificate for seq %d below checkpoint", vc.NodeId, cert.PrePrepare.Sequence)
+				}
+			}
+		}
+	}
+}
+
+// Node bị cô lập lỡ các block đã bị xoá khỏi log: khi có checkpoint ổn định
+// mới, nó tải block từ peer rồi tham gia commit tiếp.
+func TestStateTransferToLaggingReplica(t *testing.T) {
+	override(t, &CheckpointInterval, 2)
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	isolate := func(ids ...int32) {
+		c.nodes[5].SetNetworkPartition(context.Background(), &pb.PartitionArgs{IsolatedNodeIds: ids})
+	}
+	isolate(1, 2, 3, 4)
+	for n := int64(1); n <= 4; n++ {
+		c.commit(all[:4], n, 3*time.Second)
+	}
+	if n := c.status(5).Committed; n != 0 {
+		t.Fatalf("isolated node5 committed %d blocks", n)
+	}
+
+	// Block 7 chạy trong lúc node5 còn tải state: PrePrepare của nó được giữ
+	// trong log và thực thi sau block 6
+	isolate()
+	for n := int64(5); n <= 7; n++ {
+		c.commit(all[:4], n, 3*time.Second)
+	}
+	if !c.waitCommitted(all, 7, 3*time.Second) {
+		t.Fatalf("node5 did not catch up: %v", c.status(5))
+	}
+	c.checkChains(all)
+}
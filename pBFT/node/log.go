@@ -0,0 +1,149 @@
+package node
+
+import (
+	"fmt"
+
+	pb "consensus/common/proto"
+)
+
+// logEntry là phần message log của một instance (view, sequence): PrePrepare
+// đã chấp nhận cùng các Prepare và Commit nhận được (mỗi node một tin).
+// Prepare/Commit tới trước PrePrepare vẫn được giữ, chỉ được đếm khi khớp
+// digest của PrePrepare.
+type logEntry struct {
+	prePrepare *pb.PbftMessage
+	prepares   map[string]*pb.PbftMessage
+	commits    map[string]*pb.PbftMessage
+	sentCommit bool
+}
+
+// Watermark: node chỉ nhận tin nhắn 3 pha có sequence trong (h, H], h là
+// checkpoint ổn định và H = h + 2K. Cửa sổ chặn Primary Byzantine dùng cạn
+// không gian sequence và giới hạn kích thước log; H đủ xa để checkpoint kịp ổn
+// định trước khi cửa sổ đầy.
+func (s *Server) highWatermark() int64 {
+	return s.Stable.Sequence + 2*CheckpointInterval
+}
+
+func (s *Server) inWindow(seq int64) bool {
+	return seq > s.Stable.Sequence && seq <= s.highWatermark()
+}
+
+// ahead: sequence thuộc cửa sổ kế tiếp (H, H + 2K]. Primary có thể ổn định
+// checkpoint và đề xuất tiếp trước Backup: tin nhắn 3 pha đó được giữ lại
+// (Prepare/Commit vào log, PrePrepare chờ trong Future) thay vì mất hẳn, nhưng
+// PrePrepare chỉ được chấp nhận khi cửa sổ của node dịch tới.
+func (s *Server) ahead(seq int64) bool {
+	high := s.highWatermark()
+	return seq > high && seq <= high+2*CheckpointInterval
+}
+
+// buffered: tin nhắn 3 pha của view cao hơn view hiện tại chỉ được giữ khi
+// view đó là v+1 hoặc đang có ViewChange hợp lệ cho nó (NewView sắp tới). Node
+// Byzantine không thể lấp log/Future bằng tin nhắn cho view tuỳ ý.
+func (s *Server) buffered(view int64) bool {
+	return view <= s.View+1 || len(s.ViewChangeMsgs[view]) > 0
+}
+
+// replayFuture xử lý các PrePrepare đang chờ đã thuộc view hiện tại và cửa sổ
+// watermark, và đã biết block trước đó; PrePrepare của view cũ bị bỏ.
+func (s *Server) replayFuture() {
+	if s.InViewChange {
+		return
+	}
+	for sl, pp := range s.Future {
+		switch {
+		case sl.view < s.View:
+			delete(s.Future, sl)
+		case sl.view == s.View && s.inWindow(sl.seq):
+			if _, ok := s.prevHash(sl.view, sl.seq); ok {
+				delete(s.Future, sl)
+				s.handlePrePrepare(pp)
+			}
+		}
+	}
+}
+
+// prevHash trả về hash block seq-1 nếu đã biết: block đã thực thi, checkpoint
+// ổn định, hoặc PrePrepare đã chấp nhận cho seq-1 trong `view`.
+func (s *Server) prevHash(view, seq int64) (string, bool) {
+	if seq-1 <= s.Sequence {
+		return s.Blockchain[seq-1].Hash, true
+	}
+	if seq-1 == s.Stable.Sequence {
+		return s.Stable.Digest, true
+	}
+	if e := s.Log[slot{view, seq - 1}]; e != nil && e.prePrepare != nil {
+		return e.prePrepare.BlockHash, true
+	}
+	return "", false
+}
+
+func (s *Server) entry(view, seq int64) *logEntry {
+	e, ok := s.Log[slot{view, seq}]
+	if !ok {
+		e = &logEntry{prepares: make(map[string]*pb.PbftMessage), commits: make(map[string]*pb.PbftMessage)}
+		s.Log[slot{view, seq}] = e
+	}
+	return e
+}
+
+// matching đếm tin nhắn cùng digest với PrePrepare, bỏ qua node `except`.
+func (e *logEntry) matching(msgs map[string]*pb.PbftMessage, except string) int {
+	n := 0
+	for id, m := range msgs {
+		if id != except && m.BlockHash == e.prePrepare.BlockHash {
+			n++
+		}
+	}
+	return n
+}
+
+// prepared(m, v, n, i): log có request m, PrePrepare cho m ở (v, n) và 2f
+// Prepare khớp PrePrepare từ các Backup khác nhau (Primary không gửi Prepare).
+func (e *logEntry) prepared(view int64) bool {
+	if e.prePrepare == nil {
+		return false
+	}
+	return e.matching(e.prepares, fmt.Sprintf("node%d", primaryOf(view))) >= 2*Faults
+}
+
+// committedLocal(m, v, n, i): prepared(m, v, n, i) và 2f+1 Commit khớp (kể
+// cả của chính node) từ các replica khác nhau.
+func (e *logEntry) committedLocal(view int64) bool {
+	return e.prepared(view) && e.matching(e.commits, "") >= Quorum
+}
+
+// advance gửi Commit khi instance vừa prepared và thực thi khi committed-local
+// (theo thứ tự sequence). Chỉ chạy cho view hiện tại đã vào qua NewView.
+func (s *Server) advance(view, seq int64) {
+	e := s.Log[slot{view, seq}]
+	if e == nil || view != s.View || s.InViewChange {
+		return
+	}
+	if !e.sentCommit && e.prepared(view) {
+		e.sentCommit = true
+		go s.Broadcast(s.vote("Commit", e.prePrepare))
+	}
+	// Instance có thể committed-local không theo thứ tự (tin nhắn đảo thứ tự,
+	// O của NewView): chỉ thực thi khi mọi sequence nhỏ hơn đã thực thi
+	for e := s.Log[slot{view, s.Sequence + 1}]; e != nil && e.committedLocal(view); e = s.Log[slot{view, s.Sequence + 1}] {
+		if tip := s.Blockchain[len(s.Blockchain)-1]; e.prePrepare.PrevBlockHash != tip.Hash {
+			s.report("REJECTED", fmt.Sprintf("Committed Block #%d does not extend chain tip %.8s", tip.Sequence+1, tip.Hash), "red")
+			return
+		}
+		s.execute(e.prePrepare)
+	}
+}
+
+// vote tạo Prepare hoặc Commit của node cho PrePrepare `pp`.
+func (s *Server) vote(typ string, pp *pb.PbftMessage) *pb.PbftMessage {
+	return &pb.PbftMessage{
+		Type:          typ,
+		NodeId:        s.NodeID,
+		View:          pp.View,
+		Sequence:      pp.Sequence,
+		BlockHash:     pp.BlockHash,
+		PrevBlockHash: pp.PrevBlockHash,
+	}
+}
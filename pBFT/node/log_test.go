@@ -0,0 +1,246 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+// Đưa từng tin nhắn tới một Backup và kiểm tra prepared / committed-local
+// đúng như bài báo.
+func TestPreparedAndCommittedLocal(t *testing.T) {
+	override(t, &BaseTimeout, time.Minute)
+	c := newTestCluster(t)
+	node3 := c.nodes[3]
+	deliver := func(msg *pb.PbftMessage) {
+		node3.HandlePbftMessage(context.Background(), msg)
+	}
+	pp := signAs("node1", prePrepare("node1", 1, "pay bob"))
+	vote := func(typ, from, hash string) *pb.PbftMessage {
+		return signAs(from, &pb.PbftMessage{Type: typ, NodeId: from, View: 1, Sequence: 1, BlockHash: hash, PrevBlockHash: "Genesis-Hash"})
+	}
+	state := func() (prepared, committed bool, sentCommit bool) {
+		node3.mu.Lock()
+		defer node3.mu.Unlock()
+		e := node3.Log[slot{1, 1}]
+		if e == nil {
+			return false, false, false
+		}
+		return e.prepared(1), e.committedLocal(1), e.sentCommit
+	}
+
+	// 2f+1 Commit khớp nhau nhưng chưa có PrePrepare: không thực thi
+	for _, from := range []string{"node1", "node2", "node4"} {
+		deliver(vote("Commit", from, pp.BlockHash))
+	}
+	// Prepare của Primary và Prepare lệch digest không được đếm
+	deliver(vote("Prepare", "node1", pp.BlockHash))
+	deliver(vote("Prepare", "node2", "other"))
+	deliver(vote("Prepare", "node4", pp.BlockHash))
+	if p, cl, _ := state(); p || cl {
+		t.Fatalf("without PrePrepare: prepared=%v committed-local=%v", p, cl)
+	}
+
+	// Có PrePrepare: node3 gửi Prepare của mình; cùng Prepare của node4 là đủ 2f
+	deliver(pp)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if p, cl, sent := state(); p && cl && sent {
+			break
+		}
+		if time.Now().After(deadline) {
+			p, cl, sent := state()
+			t.Fatalf("after PrePrepare: prepared=%v committed-local=%v sent commit=%v", p, cl, sent)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if e := c.ledger(3); len(e) != 1 || e[0].Hash != pp.BlockHash || e[0].Data != "pay bob" {
+		t.Fatalf("node3 ledger = %v", e)
+	}
+}
+
+// Chỉ Prepare của Primary và một Backup: chưa đủ 2f Prepare từ Backup.
+func TestPrimaryPrepareDoesNotCount(t *testing.T) {
+	e := &logEntry{
+		prePrepare: prePrepare("node1", 1, "op"),
+		prepares:   make(map[string]*pb.PbftMessage),
+		commits:    make(map[string]*pb.PbftMessage),
+	}
+	hash := e.prePrepare.BlockHash
+	for _, from := range []string{"node1", "node2"} {
+		e.prepares[from] = &pb.PbftMessage{NodeId: from, BlockHash: hash}
+	}
+	for _, from := range []string{"node1", "node2", "node3"} {
+		e.commits[from] = &pb.PbftMessage{NodeId: from, BlockHash: hash}
+	}
+	if e.prepared(1) || e.committedLocal(1) {
+		t.Fatal("prepared with primary's Prepare counted")
+	}
+	e.prepares["node3"] = &pb.PbftMessage{NodeId: "node3", BlockHash: hash}
+	if !e.prepared(1) || !e.committedLocal(1) {
+		t.Fatal("not committed-local with 2f backup Prepares and 2f+1 Commits")
+	}
+	// Ở view 2 Primary là node2: Prepare của node1 được đếm, của node2 thì không
+	if !e.prepared(2) {
+		t.Fatal("view 2: node1 and node3 Prepares should count")
+	}
+}
+
+// PrePrepare và phiếu của sequence 2 tới trước sequence 1 (tin nhắn đảo thứ
+// tự): PrePrepare 2 chờ tới khi biết block 1 để kiểm tra PrevBlockHash trước
+// khi gửi Prepare; block chỉ thực thi đúng thứ tự. PrePrepare 2 không nối vào
+// block 1 bị từ chối ngay khi PrePrepare 1 tới.
+func TestOutOfOrderPrePreparesWaitForPrevious(t *testing.T) {
+	override(t, &BaseTimeout, time.Minute)
+	c := newTestCluster(t)
+	node3 := c.nodes[3]
+	deliver := func(msg *pb.PbftMessage) {
+		node3.HandlePbftMessage(context.Background(), msg)
+	}
+	pp1 := prePrepare("node1", 1, "first")
+	req2 := signRequest(&pb.PbftRequest{ClientId: "mallory", Timestamp: 2, Operation: []byte("second")})
+	next := func(prev string) *pb.PbftMessage {
+		return &pb.PbftMessage{Type: "PrePrepare", NodeId: "node1", View: 1, Sequence: 2, PrevBlockHash: prev, BlockHash: blockHash(2, prev, []*pb.PbftRequest{req2}), Data: "second", Requests: []*pb.PbftRequest{req2}}
+	}
+	votes := func(pp *pb.PbftMessage) {
+		for _, from := range []string{"node2", "node4"} {
+			deliver(signAs(from, &pb.PbftMessage{Type: "Prepare", NodeId: from, View: 1, Sequence: pp.Sequence, BlockHash: pp.BlockHash, PrevBlockHash: pp.PrevBlockHash}))
+		}
+		for _, from := range []string{"node1", "node2", "node4"} {
+			deliver(signAs(from, &pb.PbftMessage{Type: "Commit", NodeId: from, View: 1, Sequence: pp.Sequence, BlockHash: pp.BlockHash, PrevBlockHash: pp.PrevBlockHash}))
+		}
+	}
+	state := func(seq int64) (accepted, buffered bool) {
+		node3.mu.Lock()
+		defer node3.mu.Unlock()
+		e := node3.Log[slot{1, seq}]
+		_, buffered = node3.Future[slot{1, seq}]
+		return e != nil && e.prePrepare != nil, buffered
+	}
+
+	// Primary Byzantine: block 2 không nối vào block 1
+	fork := next("other")
+	deliver(signAs("node1", fork))
+	votes(fork)
+	if accepted, buffered := state(2); accepted || !buffered {
+		t.Fatalf("seq 2 before seq 1: accepted=%v buffered=%v", accepted, buffered)
+	}
+	deliver(signAs("node1", pp1))
+	votes(pp1)
+	if !c.waitCommitted([]int{3}, 1, time.Second) {
+		t.Fatalf("node3 = %v", c.status(3))
+	}
+	if accepted, buffered := state(2); accepted || buffered {
+		t.Fatalf("seq 2 not extending block 1: accepted=%v buffered=%v", accepted, buffered)
+	}
+
+	// Cluster mới: PrePrepare 2 hợp lệ tới trước, được chấp nhận khi PrePrepare 1 tới
+	c2 := newTestCluster(t)
+	node3 = c2.nodes[3]
+	pp2 := next(pp1.BlockHash)
+	deliver(signAs("node1", pp2))
+	votes(pp2)
+	time.Sleep(100 * time.Millisecond)
+	if n := c2.status(3).Committed; n != 0 {
+		t.Fatalf("executed %d blocks before seq 1", n)
+	}
+	deliver(signAs("node1", pp1))
+	votes(pp1)
+	if !c2.waitCommitted([]int{3}, 2, time.Second) {
+		t.Fatalf("node3 = %v", c2.status(3))
+	}
+	if e := c2.ledger(3); e[0].Data != "first" || e[1].Data != "second" || e[1].PrevHash != e[0].Hash {
+		t.Fatalf("node3 ledger = %v", e)
+	}
+}
+
+// Tin nhắn ngoài cửa sổ (h, H] bị bỏ; PrePrepare của Primary view kế tiếp
+// được giữ tới khi node vào view đó, view xa hơn bị bỏ.
+func TestWatermarksAndFutureViews(t *testing.T) {
+	override(t, &BaseTimeout, time.Minute)
+	c := newTestCluster(t)
+	node3 := c.nodes[3]
+	logged := func(view, seq int64) (entry, future bool) {
+		node3.mu.Lock()
+		defer node3.mu.Unlock()
+		_, entry = node3.Log[slot{view, seq}]
+		_, future = node3.Future[slot{view, seq}]
+		return entry, future
+	}
+
+	high := 2 * CheckpointInterval
+	prepare := func(seq int64) {
+		node3.HandlePbftMessage(context.Background(), signAs("node2", &pb.PbftMessage{Type: "Prepare", NodeId: "node2", View: 1, Sequence: seq, BlockHash: "h"}))
+	}
+	for _, seq := range []int64{0, 2*high + 1} {
+		prepare(seq)
+		if entry, _ := logged(1, seq); entry {
+			t.Fatalf("Prepare for seq %d outside (0, %d] logged", seq, 2*high)
+		}
+	}
+	// Cửa sổ kế tiếp: Prepare được giữ, PrePrepare chờ checkpoint chứ chưa được chấp nhận
+	for _, seq := range []int64{high, high + 1} {
+		prepare(seq)
+		if entry, _ := logged(1, seq); !entry {
+			t.Fatalf("Prepare for seq %d dropped", seq)
+		}
+	}
+	next := prePrepare("node1", 1, "next window")
+	next.Sequence, next.BlockHash = high+1, blockHash(high+1, next.PrevBlockHash, next.Requests)
+	node3.HandlePbftMessage(context.Background(), signAs("node1", next))
+	node3.mu.Lock()
+	_, future := node3.Future[slot{1, high + 1}]
+	accepted := node3.Log[slot{1, high + 1}].prePrepare != nil
+	node3.mu.Unlock()
+	if accepted || !future {
+		t.Fatalf("PrePrepare above high watermark: accepted=%v buffered=%v", accepted, future)
+	}
+
+	// PrePrepare của Primary view 2 tới khi node3 còn ở view 1
+	pp := signAs("node2", prePrepare("node2", 2, "early"))
+	node3.HandlePbftMessage(context.Background(), pp)
+	if entry, future := logged(2, 1); entry || !future {
+		t.Fatalf("view 2 PrePrepare: logged=%v buffered=%v", entry, future)
+	}
+	// Chỉ giữ view v+1 (hoặc view đang có ViewChange) và PrePrepare của đúng Primary
+	node3.HandlePbftMessage(context.Background(), signAs("node4", prePrepare("node4", 4, "far")))
+	node3.HandlePbftMessage(context.Background(), signAs("node4", &pb.PbftMessage{Type: "Prepare", NodeId: "node4", View: 4, Sequence: 1, BlockHash: "h"}))
+	if entry, future := logged(4, 1); entry || future {
+		t.Fatalf("view 4 messages in view 1: logged=%v buffered=%v", entry, future)
+	}
+	node3.mu.Lock()
+	node3.ViewChangeMsgs[4] = map[string]*pb.PbftMessage{"node4": {Type: "ViewChange", NodeId: "node4", View: 4}}
+	node3.mu.Unlock()
+	node3.HandlePbftMessage(context.Background(), signAs("node4", prePrepare("node4", 4, "far")))
+	if _, future := logged(4, 1); !future {
+		t.Fatal("view 4 PrePrepare dropped while a view change to 4 is pending")
+	}
+	node3.mu.Lock()
+	delete(node3.ViewChangeMsgs, 4)
+	delete(node3.Future, slot{2, 1})
+	node3.mu.Unlock()
+	node3.HandlePbftMessage(context.Background(), signAs("node5", prePrepare("node5", 2, "forged")))
+	if _, future := logged(2, 1); future {
+		t.Fatal("view 2 PrePrepare from non-primary node5 buffered")
+	}
+	node3.HandlePbftMessage(context.Background(), pp)
+	for i := 2; i <= TotalNodes; i++ {
+		s := c.nodes[i]
+		s.mu.Lock()
+		s.startViewChange(2)
+		s.mu.Unlock()
+	}
+	inView := func() bool {
+		node3.mu.Lock()
+		defer node3.mu.Unlock()
+		e := node3.Log[slot{2, 1}]
+		return node3.View == 2 && !node3.InViewChange && e != nil && e.prePrepare != nil && e.prePrepare.BlockHash == pp.BlockHash
+	}
+	for deadline := time.Now().Add(2 * time.Second); !inView(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("buffered PrePrepare not processed after NewView")
+		}
+	}
+}
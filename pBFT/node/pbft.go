@@ -3,8 +3,9 @@ package node
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	// Import từ module chung
+	"consensus/common/netem"
 	pb "consensus/common/proto"
 )
 
@@ -24,18 +26,46 @@ const (
 	DashboardURL      = "http://localhost:8080/api/report"
 	TotalNodes        = 5
 	Faults            = 1
-	BaseTimeout       = 5 * time.Second 
 	
 	// Quorum chuẩn pBFT: 2f + 1
 	Quorum = 2*Faults + 1
 )
 
+// Timeout chờ Primary trước khi ViewChange (var để test có thể rút ngắn)
+var BaseTimeout = 5 * time.Second
+
+// Số sequence giữa hai Checkpoint (K trong bài báo)
+var CheckpointInterval int64 = 16
+
+// Primary đề xuất block khi gom đủ BatchSize request hoặc batch đã mở quá BatchTimeout
+var (
+	BatchSize    = 64
+	BatchTimeout = 10 * time.Millisecond
+)
+
+// Request có operation lớn hơn MaxOperationSize byte bị từ chối
+var MaxOperationSize = 64 << 10
+
 // --- STRUCTURES ---
 type Block struct {
 	Sequence int64
 	PrevHash string
 	Hash     string
 	Data     string
+	Requests []*pb.PbftRequest // Batch request của client (rỗng với Genesis và null request)
+}
+
+// slot là một instance đồng thuận: sequence được gán trong một view.
+type slot struct {
+	view, seq int64
+}
+
+// Equivocation là bằng chứng Primary gửi hai PrePrepare khác digest cho cùng
+// (view, sequence). Ở chế độ chữ ký, hai tin nhắn đã ký chuyển cho node khác
+// kiểm tra được.
+type Equivocation struct {
+	View, Sequence int64
+	First, Second  *pb.PbftMessage
 }
 
 type Server struct {
@@ -46,22 +76,52 @@ type Server struct {
 	NodeIndex   int 
 	Peers       map[string]string
 	PeerClients map[string]pb.ConsensusServiceClient
+	Faults      *netem.Injector
+
+	Auth        string                       // AuthSignature hoặc AuthMAC
+	signer      ed25519.PrivateKey           // Ký tin nhắn gửi đi
+	registry    map[string]ed25519.PublicKey // Public key của các node theo NodeID
+	clients     map[string]ed25519.PublicKey // Public key của client theo ClientId; nil thì dùng devKey
+	dh          *ecdh.PrivateKey             // Khoá X25519 tạm thời để lập session key
+	sessionKeys map[string][]byte            // Session key chung với từng node (cả chính mình)
+	peerDH      map[string][]byte            // Public key tạm thời gần nhất của từng peer
+	keySent     map[string]time.Time         // Lần gần nhất gửi NewKey cho peer thiếu key
 
 	View           int64
 	Sequence       int64
 	Blockchain     []Block
 	IsMalicious    bool
+	Blacklist      map[string]bool // Partition phía nhận (giống Raft)
 
 	// Message Logs
-	PrepareMsgs map[int64]map[string]*pb.PbftMessage
-	CommitMsgs  map[int64]map[string]*pb.PbftMessage
-	Committed   map[int64]bool
+	Log       map[slot]*logEntry // PrePrepare/Prepare/Commit theo (view, sequence)
+	Future    map[slot]*pb.PbftMessage // PrePrepare của view chưa vào (tối đa v+1 hoặc view đang ViewChange), xử lý khi nhận NewView
+	Committed map[int64]bool     // Sequence đã thực thi
+	Evidence  []Equivocation     // PrePrepare mâu thuẫn đã phát hiện
+
+	// Checkpoint
+	Checkpoints map[int64]map[string]*pb.PbftMessage // Checkpoint chưa ổn định theo sequence và node gửi
+	Stable      Checkpoint                           // Checkpoint ổn định gần nhất, log tới đây đã bị xoá
+	fetching    bool                                 // Đang tải block tới checkpoint ổn định từ peer
+
+	// Client requests
+	Queue        []*pb.PbftRequest           // Primary: request chờ gán sequence
+	InFlight     map[int64][]*pb.PbftRequest // Primary: batch đã gán sequence, chưa thực thi
+	batchStart   time.Time                   // Primary: lúc batch đang gom nhận request đầu tiên
+	batchTimer   *time.Timer                 // Primary: đề xuất batch chưa đầy khi hết BatchTimeout
+	Assigned     int64                       // Primary: sequence lớn nhất đã gán trong view (kể cả O của NewView)
+	LastExecuted map[string]int64            // Timestamp request thực thi gần nhất của mỗi client
+	LastReply    map[string]*pb.PbftReply    // Reply gần nhất đã gửi cho mỗi client
+	executed     chan struct{}               // Đóng mỗi khi có request thực thi xong (đánh thức WatchReplies)
 
 	// View Change State
-	ViewChangeMsgs map[int64]map[string]bool 
+	ViewChangeMsgs map[int64]map[string]*pb.PbftMessage // ViewChange hợp lệ theo view mới và node gửi
+	InViewChange   bool                                 // Đang chờ NewView của View (không nhận PrePrepare)
+	LastNewView    *pb.PbftMessage                      // NewView đã vào, gửi lại cho node bị tụt view
 	LastActive     time.Time                 
 	Timer          *time.Timer
 	CurrentTimeout time.Duration // [FIX] Để xử lý Backoff
+	Stopped        bool
 }
 
 func NewServer(id string, peers map[string]string) *Server {
@@ -72,20 +132,40 @@ func NewServer(id string, peers map[string]string) *Server {
 		NodeIndex:   idx, 
 		Peers:       peers,
 		PeerClients: make(map[string]pb.ConsensusServiceClient),
+		Faults:      netem.New(),
 		View:        1, 
 		Sequence:    0,
-		Blockchain:  []Block{{0, "0000", "Genesis-Hash", "Genesis"}},
+		Blockchain:  []Block{{Sequence: 0, PrevHash: "0000", Hash: "Genesis-Hash", Data: "Genesis"}},
 		IsMalicious: false,
+		Blacklist:   make(map[string]bool),
 
-		PrepareMsgs:    make(map[int64]map[string]*pb.PbftMessage),
-		CommitMsgs:     make(map[int64]map[string]*pb.PbftMessage),
+		Log:            make(map[slot]*logEntry),
+		Future:         make(map[slot]*pb.PbftMessage),
 		Committed:      make(map[int64]bool),
-		ViewChangeMsgs: make(map[int64]map[string]bool),
+		Checkpoints:    make(map[int64]map[string]*pb.PbftMessage),
+		Stable:         Checkpoint{Digest: "Genesis-Hash"},
+		InFlight:       make(map[int64][]*pb.PbftRequest),
+		LastExecuted:   make(map[string]int64),
+		LastReply:      make(map[string]*pb.PbftReply),
+		executed:       make(chan struct{}),
+		ViewChangeMsgs: make(map[int64]map[string]*pb.PbftMessage),
 		LastActive:     time.Now(),
 		CurrentTimeout: BaseTimeout, // Khởi tạo timeout
 	}
 
-	s.startTimer()
+	// Mặc định dùng khoá suy ra từ NodeID; main.go thay bằng khoá trong cluster config
+	ids := []string{id}
+	for p := range peers {
+		ids = append(ids, p)
+	}
+	s.signer, s.registry = devKey(id), devRegistry(ids)
+	s.Auth = AuthSignature
+	s.dh, _ = ecdh.X25519().GenerateKey(rand.Reader)
+	self := make([]byte, 32)
+	rand.Read(self)
+	s.sessionKeys = map[string][]byte{id: self}
+	s.peerDH, s.keySent = make(map[string][]byte), make(map[string]time.Time)
+
 	s.report("INIT", "Node started (Honest)", "gray")
 	return s
 }
@@ -96,11 +176,18 @@ func (s *Server) startTimer() {
     if s.Timer != nil {
         s.Timer.Stop()
     }
+    if s.Stopped {
+        return
+    }
     
     s.Timer = time.AfterFunc(s.CurrentTimeout, func() {
         s.mu.Lock()
         defer s.mu.Unlock()
 
+        if s.Stopped {
+            return
+        }
+
         if s.IsMalicious {
             s.resetTimer() 
             return
@@ -117,7 +204,7 @@ func (s *Server) startTimer() {
             }
             
             // 1. Kích hoạt bầu cử View mới
-            s.startViewChange()
+            s.startViewChange(s.View + 1)
 
             // [FIX QUAN TRỌNG]
             // Dù đã start ViewChange, vẫn phải restart timer!
@@ -143,12 +230,15 @@ func (s *Server) resetTimer() {
 }
 
 // --- PHASE 1: PRE-PREPARE ---
+// StartConsensus đề xuất một block mẫu (nút Start trên dashboard, chaos
+// runner), node tự làm client và ký bằng khoá của mình; request thật đi qua
+// SubmitRequest.
 func (s *Server) StartConsensus() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// [FIX] Chặn chặt chẽ hơn: Phải đúng View mới được làm Primary
-	expectedPrimaryIdx := int(s.View-1)%TotalNodes + 1
+	expectedPrimaryIdx := primaryOf(s.View)
 	if s.NodeIndex != expectedPrimaryIdx {
 		return fmt.Errorf("node%d is NOT Primary for View %d (Primary is node%d). Cannot start.", s.NodeIndex, s.View, expectedPrimaryIdx)
 	}
@@ -157,28 +247,17 @@ func (s *Server) StartConsensus() error {
 		s.report("MALICIOUS", "Primary blocked consensus start", "red")
 		return fmt.Errorf("malicious node blocked")
 	}
+	if s.InViewChange {
+		return fmt.Errorf("view change to %d in progress", s.View)
+	}
 
-	newSeq := s.Sequence + 1
-	prevBlock := s.Blockchain[len(s.Blockchain)-1]
-	
-	data := fmt.Sprintf("Block #%d Data", newSeq)
-	hashInput := fmt.Sprintf("%d%s%s%d", newSeq, prevBlock.Hash, data, time.Now().UnixNano())
-	hash := sha256.Sum256([]byte(hashInput))
-	blockHash := hex.EncodeToString(hash[:])
-
-	msg := &pb.PbftMessage{
-		Type:          "PrePrepare",
-		NodeId:        s.NodeID,
-		View:          s.View,
-		Sequence:      newSeq,
-		BlockHash:     blockHash,
-		PrevBlockHash: prevBlock.Hash,
-		Data:          data,
-		Timestamp:     time.Now().UnixMilli(),
-	}
-
-	s.report("START", fmt.Sprintf("Primary proposed Block #%d", newSeq), "blue")
-	go s.Broadcast(msg)
+	req := &pb.PbftRequest{
+		ClientId:  s.NodeID,
+		Timestamp: time.Now().UnixNano(),
+		Operation: []byte(fmt.Sprintf("Block #%d Data", max(s.Assigned, s.Sequence)+1+int64(len(s.Queue)))),
+	}
+	req.Signature = ed25519.Sign(s.signer, requestBytes(req))
+	s.enqueue(req)
 	return nil
 }
 
@@ -187,217 +266,271 @@ func (s *Server) HandlePbftMessage(ctx context.Context, req *pb.PbftMessage) (*p
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.IsMalicious {
+	if s.IsMalicious || s.Stopped {
 		return &pb.PbftResponse{Success: false}, nil
 	}
-
-	// [FIX] Nếu nhận được tin nhắn từ View cao hơn hẳn -> Cập nhật ngay (Fast Catchup)
-	// Điều này giúp node bị lag (do malicious cũ) bắt kịp mạng lưới
-	if req.View > s.View {
-		s.View = req.View
-		s.CurrentTimeout = BaseTimeout // Reset backoff khi bắt kịp
-		s.report("SYNC", fmt.Sprintf("Caught up to View %d via message from %s", s.View, req.NodeId), "purple")
+	if s.Blacklist[req.NodeId] {
+		return nil, fmt.Errorf("Partition")
+	}
+	if err := s.verify(req); err != nil {
+		s.report("FORGED", fmt.Sprintf("Dropped %s claiming to be from %s: %v", req.Type, req.NodeId, err), "red")
+		return &pb.PbftResponse{Success: false, Message: err.Error()}, nil
+	}
+	if req.Type == "NewKey" {
+		s.handleNewKey(req)
+		return &pb.PbftResponse{Success: true}, nil
 	}
 
 	// Logic xử lý tin nhắn
 	s.LastActive = time.Now() // Reset activity
 
+	// View chỉ đổi qua ViewChange/NewView hợp lệ. PrePrepare của view mới tới
+	// trước NewView hoặc thuộc cửa sổ kế tiếp được giữ lại và xử lý khi vào
+	// view/cửa sổ đó; sequence ngoài (h, H + 2K] và view quá xa bị bỏ.
 	switch req.Type {
-	case "PrePrepare":
-		if req.View == s.View { s.handlePrePrepare(req) }
-	case "Prepare":
-		if req.View == s.View { s.handlePrepare(req) }
-	case "Commit":
-		if req.View == s.View { s.handleCommit(req) }
+	case "PrePrepare", "Prepare", "Commit":
+		if req.View < s.View || !s.buffered(req.View) || !(s.inWindow(req.Sequence) || s.ahead(req.Sequence)) {
+			break
+		}
+		switch {
+		case req.Type == "Prepare":
+			s.handlePrepare(req)
+		case req.Type == "Commit":
+			s.handleCommit(req)
+		case req.View > s.View || s.InViewChange || s.ahead(req.Sequence):
+			if primary := fmt.Sprintf("node%d", primaryOf(req.View)); req.NodeId != primary {
+				s.report("REJECTED", fmt.Sprintf("PrePrepare #%d from %s: sender is not primary %s of view %d", req.Sequence, req.NodeId, primary, req.View), "red")
+				break
+			}
+			if _, ok := s.Future[slot{req.View, req.Sequence}]; !ok {
+				s.Future[slot{req.View, req.Sequence}] = req
+			}
+		default:
+			s.handlePrePrepare(req)
+		}
 	case "ViewChange":
 		s.handleViewChange(req)
 	case "NewView":
 		s.handleNewView(req)
+	case "Checkpoint":
+		s.handleCheckpoint(req)
 	}
 
 	return &pb.PbftResponse{Success: true}, nil
 }
 
-// --- LOGIC VIEW CHANGE (Improved) ---
+// --- LOGIC 3 PHA (Giữ nguyên) ---
 
-func (s *Server) startViewChange() {
-	nextView := s.View + 1
-	
-	msg := &pb.PbftMessage{
-		Type:      "ViewChange",
-		NodeId:    s.NodeID,
-		View:      nextView, 
-		Timestamp: time.Now().UnixMilli(),
+func (s *Server) handlePrePrepare(req *pb.PbftMessage) {
+	if req.Sequence <= s.Sequence { return }
+	sl := slot{req.View, req.Sequence}
+	if e := s.Log[sl]; e != nil && e.prePrepare != nil && e.prePrepare.BlockHash == req.BlockHash {
+		return // Bản lặp, hoặc PrePrepare của chính Primary (đã ghi log khi đề xuất)
 	}
-	
-	if _, ok := s.ViewChangeMsgs[nextView]; !ok {
-		s.ViewChangeMsgs[nextView] = make(map[string]bool)
+	// PrePrepare tới trước PrePrepare của sequence liền trước: chờ trong Future
+	// để kiểm tra PrevBlockHash trước khi gửi Prepare
+	if _, ok := s.prevHash(req.View, req.Sequence); !ok && req.NodeId == fmt.Sprintf("node%d", primaryOf(req.View)) {
+		if _, ok := s.Future[sl]; !ok {
+			s.Future[sl] = req
+		}
+		return
 	}
-	s.ViewChangeMsgs[nextView][s.NodeID] = true
-	
-	go s.Broadcast(msg)
-}
-
-func (s *Server) handleViewChange(req *pb.PbftMessage) {
-	newView := req.View
-	if newView <= s.View { return } // Bỏ qua view cũ
-
-	if _, ok := s.ViewChangeMsgs[newView]; !ok {
-		s.ViewChangeMsgs[newView] = make(map[string]bool)
+	if err := s.validatePrePrepare(req); err != nil {
+		s.report("REJECTED", fmt.Sprintf("PrePrepare #%d from %s: %v", req.Sequence, req.NodeId, err), "red")
+		return
 	}
-	s.ViewChangeMsgs[newView][req.NodeId] = true
 
-	count := len(s.ViewChangeMsgs[newView])
-	
-	// Tính Primary cho View mới
-	expectedPrimaryIdx := int(newView-1)%TotalNodes + 1
-	
-	if s.NodeIndex == expectedPrimaryIdx {
-		if count >= Quorum {
-			s.report("LEADER-ELECTION", fmt.Sprintf("I am new Primary for View %d! (Votes: %d)", newView, count), "purple")
-			
-			newViewMsg := &pb.PbftMessage{
-				Type:      "NewView",
-				NodeId:    s.NodeID,
-				View:      newView,
-				Timestamp: time.Now().UnixMilli(),
-			}
-			// Tự xử lý NewView cho chính mình trước để reset state
-			s.processNewView(newView)
-			go s.Broadcast(newViewMsg)
-		}
+	s.report("PRE-PREPARE", fmt.Sprintf("Accepted Block #%d from %s", req.Sequence, req.NodeId), "cyan")
+	s.entry(req.View, req.Sequence).prePrepare = req
+	go s.Broadcast(s.vote("Prepare", req))
+	s.advance(req.View, req.Sequence) // Prepare/Commit có thể đã tới trước
+
+	// PrePrepare của sequence kế tiếp đang chờ block này
+	next := slot{req.View, req.Sequence + 1}
+	if pp, ok := s.Future[next]; ok && req.View == s.View && !s.InViewChange && s.inWindow(next.seq) {
+		delete(s.Future, next)
+		s.handlePrePrepare(pp)
 	}
 }
 
-func (s *Server) handleNewView(req *pb.PbftMessage) {
-	if req.View > s.View {
-		s.processNewView(req.View)
-		s.report("NEW-VIEW", fmt.Sprintf("Followed new Primary %s to View %d", req.NodeId, s.View), "purple")
+// validatePrePrepare áp điều kiện chấp nhận <PRE-PREPARE, v, n, d> của bài
+// báo: node gửi là Primary của view v, từng request trong batch hợp lệ, d
+// khớp batch đi kèm, block nối vào block n-1 và chưa chấp nhận PrePrepare khác
+// digest cho (v, n).
+func (s *Server) validatePrePrepare(req *pb.PbftMessage) error {
+	if primary := fmt.Sprintf("node%d", primaryOf(req.View)); req.NodeId != primary {
+		return fmt.Errorf("sender is not primary %s of view %d", primary, req.View)
 	}
+	if e := s.Log[slot{req.View, req.Sequence}]; e != nil && e.prePrepare != nil {
+		first := e.prePrepare
+		s.Evidence = append(s.Evidence, Equivocation{View: req.View, Sequence: req.Sequence, First: first, Second: req})
+		s.report("EQUIVOCATION", fmt.Sprintf("%s sent conflicting PrePrepares for view %d seq %d", req.NodeId, req.View, req.Sequence), "red")
+		return fmt.Errorf("conflicts with accepted digest %.8s", first.BlockHash)
+	}
+	if prev, ok := s.prevHash(req.View, req.Sequence); !ok || req.PrevBlockHash != prev {
+		return fmt.Errorf("does not extend block #%d", req.Sequence-1)
+	}
+	if err := s.validBatch(req.Requests); err != nil {
+		return err
+	}
+	if req.BlockHash != blockHash(req.Sequence, req.PrevBlockHash, req.Requests) || req.Data != batchData(req.Requests) {
+		return fmt.Errorf("digest does not match requests")
+	}
+	return nil
 }
 
-// [FIX] Hàm logic chung để chuyển View an toàn
-func (s *Server) processNewView(newView int64) {
-	s.View = newView
-	s.CurrentTimeout = BaseTimeout // Reset backoff về mặc định
-	
-	// [QUAN TRỌNG] Clear state cũ để tránh "rác"
-	// Chỉ giữ lại blockchain, xóa phiếu bầu của các view cũ
-	s.ViewChangeMsgs = make(map[int64]map[string]bool)
-	
-	// Restart timer để chờ Block mới
-	s.resetTimer()
+func (s *Server) handlePrepare(req *pb.PbftMessage) {
+	if req.Sequence <= s.Sequence { return }
+	e := s.entry(req.View, req.Sequence)
+	if _, ok := e.prepares[req.NodeId]; !ok {
+		e.prepares[req.NodeId] = req
+	}
+	s.advance(req.View, req.Sequence)
 }
 
-// --- LOGIC 3 PHA (Giữ nguyên) ---
-
-func (s *Server) handlePrePrepare(req *pb.PbftMessage) {
+func (s *Server) handleCommit(req *pb.PbftMessage) {
 	if req.Sequence <= s.Sequence { return }
-
-	s.report("PRE-PREPARE", fmt.Sprintf("Accepted Block #%d from %s", req.Sequence, req.NodeId), "cyan")
-
-	prepareMsg := &pb.PbftMessage{
-		Type:          "Prepare",
-		NodeId:        s.NodeID,
-		View:          req.View,
-		Sequence:      req.Sequence,
-		BlockHash:     req.BlockHash,
-		PrevBlockHash: req.PrevBlockHash,
+	e := s.entry(req.View, req.Sequence)
+	if _, ok := e.commits[req.NodeId]; !ok {
+		e.commits[req.NodeId] = req
 	}
-	go s.Broadcast(prepareMsg)
+	s.advance(req.View, req.Sequence)
 }
 
-func (s *Server) handlePrepare(req *pb.PbftMessage) {
-	seq := req.Sequence
-	if _, ok := s.PrepareMsgs[seq]; !ok {
-		s.PrepareMsgs[seq] = make(map[string]*pb.PbftMessage)
+// execute nối block của PrePrepare đã committed-local vào chain và trả lời client.
+func (s *Server) execute(pp *pb.PbftMessage) {
+	seq := pp.Sequence
+	s.Committed[seq] = true
+	s.Sequence = seq
+
+	newBlock := Block{
+		Sequence: seq,
+		PrevHash: pp.PrevBlockHash,
+		Hash:     pp.BlockHash,
+		Data:     batchData(pp.Requests),
+		Requests: pp.Requests,
 	}
-
-	s.PrepareMsgs[seq][req.NodeId] = req
-
-	count := 0
-	for _, msg := range s.PrepareMsgs[seq] {
-		if msg.BlockHash == req.BlockHash {
-			count++
+	s.Blockchain = append(s.Blockchain, newBlock)
+	for _, r := range pp.Requests {
+		// Request đã thực thi ở block trước là no-op (mọi node bỏ qua như nhau)
+		if r.Timestamp <= s.LastExecuted[r.ClientId] {
+			continue
 		}
+		s.LastExecuted[r.ClientId] = r.Timestamp
+		s.recordReply(newBlock, r)
 	}
 
-	if count >= Quorum {
-		commitMsg := &pb.PbftMessage{
-			Type:          "Commit",
-			NodeId:        s.NodeID,
-			View:          req.View,
-			Sequence:      req.Sequence,
-			BlockHash:     req.BlockHash,
-			PrevBlockHash: req.PrevBlockHash,
-			Data:          req.Data,
-		}
+	s.report("COMMITTED", fmt.Sprintf("+++ BLOCK #%d COMMITTED +++", seq), "green")
+	s.sendCommitToDB(newBlock)
+	s.sendCheckpoint(newBlock)
 
-		if !s.Committed[seq] {
-			go s.Broadcast(commitMsg)
-		}
-	}
+	// [FIX] Reset timer sau khi commit thành công để tránh timeout oan
+	s.resetTimer()
+
+	// Primary: batch của sequence vừa thực thi không còn đang chạy
+	delete(s.InFlight, seq)
+	s.orderNext()
 }
 
-func (s *Server) handleCommit(req *pb.PbftMessage) {
-	seq := req.Sequence
-	if s.Committed[seq] { return }
+// --- QUERY RPCs (Chaos runner / Test) ---
 
-	if _, ok := s.CommitMsgs[seq]; !ok {
-		s.CommitMsgs[seq] = make(map[string]*pb.PbftMessage)
+func (s *Server) GetStatus(ctx context.Context, _ *pb.Empty) (*pb.StatusReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := "Backup"
+	if s.NodeIndex == primaryOf(s.View) {
+		state = "Primary"
 	}
-
-	s.CommitMsgs[seq][req.NodeId] = req
-
-	count := 0
-	for _, msg := range s.CommitMsgs[seq] {
-		if msg.BlockHash == req.BlockHash {
-			count++
-		}
+	if s.IsMalicious {
+		state += " (Malicious)"
 	}
+	return &pb.StatusReply{Id: int32(s.NodeIndex), State: state, Term: s.View, Committed: int64(len(s.Blockchain) - 1)}, nil
+}
 
-	if count >= Quorum {
-		s.Committed[seq] = true
-		s.Sequence = seq
-
-		newBlock := Block{
-			Sequence: seq,
-			PrevHash: req.PrevBlockHash,
-			Hash:     req.BlockHash,
-			Data:     fmt.Sprintf("Block #%d", seq),
+func (s *Server) GetLedger(ctx context.Context, req *pb.LedgerArgs) (*pb.LedgerReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := &pb.LedgerReply{}
+	for _, b := range s.Blockchain[1:] {
+		if b.Sequence < req.FromIndex {
+			continue
 		}
-		s.Blockchain = append(s.Blockchain, newBlock)
-
-		s.report("COMMITTED", fmt.Sprintf("+++ BLOCK #%d COMMITTED +++", seq), "green")
-		s.sendCommitToDB(newBlock)
-		
-		// [FIX] Reset timer sau khi commit thành công để tránh timeout oan
-		s.resetTimer()
+		reply.Entries = append(reply.Entries, &pb.LedgerEntry{Index: b.Sequence, Hash: b.Hash, PrevHash: b.PrevHash, Data: b.Data, Requests: b.Requests})
 	}
+	return reply, nil
 }
 
 // --- UTILS ---
 
 func (s *Server) Broadcast(msg *pb.PbftMessage) {
+	s.mu.Lock()
+	s.authenticate(msg)
+	s.mu.Unlock()
+	s.send(msg)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		s.HandlePbftMessage(context.Background(), msg)
+	}()
+}
+
+// send gửi tin nhắn đã xác thực cho mọi peer (không giao lại cho chính node).
+func (s *Server) send(msg *pb.PbftMessage) {
+	s.mu.Lock()
+	clients := make([]pb.ConsensusServiceClient, 0, len(s.PeerClients))
 	for _, client := range s.PeerClients {
+		clients = append(clients, client)
+	}
+	s.mu.Unlock()
+	for _, client := range clients {
 		go func(c pb.ConsensusServiceClient) {
 			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 			defer cancel()
 			c.HandlePbftMessage(ctx, msg)
 		}(client)
 	}
-	go func() {
-		time.Sleep(5 * time.Millisecond)
-		s.HandlePbftMessage(context.Background(), msg)
-	}()
 }
 
 func (s *Server) ConnectToPeers() {
 	time.Sleep(1 * time.Second)
 	for peerID, addr := range s.Peers {
-		conn, _ := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		peerIdx, _ := strconv.Atoi(peerID[len(peerID)-1:])
+		conn, _ := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), s.Faults.DialOption(int32(peerIdx)))
+		s.mu.Lock()
 		s.PeerClients[peerID] = pb.NewConsensusServiceClient(conn)
+		s.mu.Unlock()
+	}
+	// Chế độ MAC: lập session key với mọi peer (peer chưa chạy sẽ gửi NewKey khi lên)
+	s.mu.Lock()
+	if s.Auth == AuthMAC {
+		s.sendKey("")
+	}
+	// Timer ViewChange chạy từ lúc gửi được tin nhắn: ViewChange gửi trước đó sẽ mất
+	if !s.Stopped {
+		s.resetTimer()
+	}
+	s.mu.Unlock()
+}
+
+// SetNetworkPartition: chặn mọi tin nhắn đến từ các node trong danh sách (node1 -> 1)
+func (s *Server) SetNetworkPartition(ctx context.Context, req *pb.PartitionArgs) (*pb.PartitionReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Blacklist = make(map[string]bool)
+	for _, id := range req.IsolatedNodeIds {
+		s.Blacklist[fmt.Sprintf("node%d", id)] = true
+	}
+	return &pb.PartitionReply{Success: true}, nil
+}
+
+// SetLinkFaults: link được đánh số theo NodeIndex (node1 -> 1)
+func (s *Server) SetLinkFaults(ctx context.Context, req *pb.LinkFaultArgs) (*pb.LinkFaultReply, error) {
+	s.Faults.SetFaults(req.Links)
+	if len(req.Links) > 0 {
+		s.report("FAULTS", fmt.Sprintf("Applied faults on %d outgoing links", len(req.Links)), "orange")
+	} else {
+		s.report("FAULTS", "Outgoing links healed", "blue")
 	}
+	return &pb.LinkFaultReply{Success: true}, nil
 }
 
 func (s *Server) SetMalicious(malicious bool) {
@@ -417,16 +550,36 @@ func (s *Server) SetMalicious(malicious bool) {
 	}
 }
 
+// Stop tắt node: dừng timer ViewChange và bỏ qua mọi tin nhắn tới sau đó.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Stopped = true
+	if s.Timer != nil {
+		s.Timer.Stop()
+	}
+	close(s.executed) // WatchReplies đang chờ trả lỗi
+	s.executed = make(chan struct{})
+}
+
 func (s *Server) Reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.View = 1
 	s.Sequence = 0
-	s.Blockchain = []Block{{0, "0000", "Genesis-Hash", "Genesis"}}
-	s.PrepareMsgs = make(map[int64]map[string]*pb.PbftMessage)
-	s.CommitMsgs = make(map[int64]map[string]*pb.PbftMessage)
-	s.ViewChangeMsgs = make(map[int64]map[string]bool)
+	s.Blockchain = []Block{{Sequence: 0, PrevHash: "0000", Hash: "Genesis-Hash", Data: "Genesis"}}
+	s.Log = make(map[slot]*logEntry)
+	s.Future = make(map[slot]*pb.PbftMessage)
+	s.ViewChangeMsgs = make(map[int64]map[string]*pb.PbftMessage)
+	s.InViewChange, s.LastNewView = false, nil
 	s.Committed = make(map[int64]bool)
+	s.Checkpoints = make(map[int64]map[string]*pb.PbftMessage)
+	s.Stable = Checkpoint{Digest: "Genesis-Hash"}
+	s.Evidence = nil
+	s.Queue, s.InFlight, s.Assigned = nil, make(map[int64][]*pb.PbftRequest), 0
+	s.LastExecuted = make(map[string]int64)
+	s.LastReply = make(map[string]*pb.PbftReply)
+	s.Blacklist = make(map[string]bool)
 	s.IsMalicious = false
 	s.CurrentTimeout = BaseTimeout
 	s.resetTimer()
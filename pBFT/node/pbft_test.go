@@ -0,0 +1,334 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestMain(m *testing.M) {
+	// Rút ngắn timeout ViewChange để test không phải chờ 5s mỗi lần đổi Primary
+	BaseTimeout = time.Second
+	os.Exit(m.Run())
+}
+
+// override đổi biến cấu hình trong một test. Gọi trước newTestCluster: giá trị
+// cũ được khôi phục sau khi các node của test đã dừng.
+func override[T any](t testing.TB, p *T, v T) {
+	old := *p
+	*p = v
+	t.Cleanup(func() { *p = old })
+}
+
+// testCluster chạy 5 node pBFT trong cùng process qua gRPC thật trên 127.0.0.1.
+type testCluster struct {
+	t     testing.TB
+	nodes map[int]*Server
+	srvs  map[int]*grpc.Server
+	addrs map[string]string // NodeID -> địa chỉ gRPC
+}
+
+// opts cấu hình từng node trước khi kết nối peer (VD: chế độ xác thực).
+func newTestCluster(t testing.TB, opts ...func(*Server)) *testCluster {
+	t.Helper()
+	c := &testCluster{t: t, nodes: make(map[int]*Server), srvs: make(map[int]*grpc.Server)}
+	addrs := make(map[string]string)
+	listeners := make(map[int]net.Listener)
+	for i := 1; i <= TotalNodes; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners[i] = lis
+		addrs[fmt.Sprintf("node%d", i)] = lis.Addr().String()
+	}
+
+	var wg sync.WaitGroup
+	for i, lis := range listeners {
+		id := fmt.Sprintf("node%d", i)
+		peers := make(map[string]string)
+		for p, addr := range addrs {
+			if p != id {
+				peers[p] = addr
+			}
+		}
+		s := NewServer(id, peers)
+		for _, opt := range opts {
+			opt(s)
+		}
+		g := grpc.NewServer()
+		pb.RegisterConsensusServiceServer(g, s)
+		go g.Serve(lis)
+		c.nodes[i], c.srvs[i] = s, g
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.ConnectToPeers()
+		}()
+	}
+	wg.Wait()
+	t.Cleanup(func() {
+		for i := range c.srvs {
+			c.stop(i)
+		}
+	})
+	c.addrs = addrs
+	return c
+}
+
+// keys là public key của các replica, để client kiểm tra reply.
+func (c *testCluster) keys() map[string]ed25519.PublicKey {
+	s := c.nodes[1]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registry
+}
+
+// replies mở stream WatchReplies của `client` tới node i.
+func (c *testCluster) replies(ctx context.Context, i int, args *pb.ReplyWatchArgs) grpc.ServerStreamingClient[pb.PbftReply] {
+	c.t.Helper()
+	conn, err := grpc.NewClient(c.addrs[fmt.Sprintf("node%d", i)], grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { conn.Close() })
+	stream, err := pb.NewConsensusServiceClient(conn).WatchReplies(ctx, args)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return stream
+}
+
+// stop giả lập crash: node ngừng timer, ngừng xử lý tin nhắn và đóng gRPC server.
+func (c *testCluster) stop(i int) {
+	if g, ok := c.srvs[i]; ok {
+		c.nodes[i].Stop()
+		g.Stop()
+		delete(c.srvs, i)
+	}
+}
+
+func (c *testCluster) status(i int) *pb.StatusReply {
+	st, _ := c.nodes[i].GetStatus(context.Background(), &pb.Empty{})
+	return st
+}
+
+// commit tìm Primary của View hiện hành trong số `honest`, yêu cầu nó đề xuất
+// block và chờ mọi node trong `honest` commit đủ `want` block.
+func (c *testCluster) commit(honest []int, want int64, d time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		for _, i := range honest {
+			if c.status(i).State == "Primary" && c.nodes[i].StartConsensus() == nil {
+				if c.waitCommitted(honest, want, time.Second) {
+					return
+				}
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for _, i := range honest {
+		c.t.Logf("node%d: %v", i, c.status(i))
+	}
+	c.t.Fatalf("block %d not committed by %v within %v", want, honest, d)
+}
+
+func (c *testCluster) waitCommitted(ids []int, want int64, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		done := true
+		for _, i := range ids {
+			done = done && c.status(i).Committed >= want
+		}
+		if done {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+// checkChains: các chain phải giống hệt nhau ở mọi sequence chung.
+func (c *testCluster) checkChains(ids []int) {
+	c.t.Helper()
+	ref := make(map[int64]*pb.LedgerEntry)
+	for _, i := range ids {
+		reply, _ := c.nodes[i].GetLedger(context.Background(), &pb.LedgerArgs{})
+		for _, e := range reply.Entries {
+			if r, ok := ref[e.Index]; ok && (r.Hash != e.Hash || r.PrevHash != e.PrevHash) {
+				c.t.Fatalf("block %d diverged on node%d: %s vs %s", e.Index, i, e.Hash, r.Hash)
+			}
+			ref[e.Index] = e
+		}
+	}
+}
+
+func (c *testCluster) view(ids []int) int64 {
+	var v int64
+	for _, i := range ids {
+		v = max(v, c.status(i).Term)
+	}
+	return v
+}
+
+func TestNormalCase(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	for n := int64(1); n <= 3; n++ {
+		c.commit(all, n, 3*time.Second)
+	}
+	c.checkChains(all)
+}
+
+func TestMaliciousPrimary(t *testing.T) {
+	c := newTestCluster(t)
+	c.nodes[1].SetMalicious(true)
+	if err := c.nodes[1].StartConsensus(); err == nil {
+		t.Fatal("malicious primary started consensus")
+	}
+
+	// Backup hết timeout -> ViewChange sang Primary trung thực, hệ thống vẫn commit được
+	honest := []int{2, 3, 4, 5}
+	c.commit(honest, 1, 10*time.Second)
+	if v := c.view(honest); v < 2 {
+		t.Fatalf("honest nodes still in view %d", v)
+	}
+	if n := c.status(1).Committed; n != 0 {
+		t.Fatalf("malicious node committed %d blocks", n)
+	}
+	c.checkChains(honest)
+}
+
+func TestViewChangeAfterPrimaryCrash(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	c.commit(all, 1, 3*time.Second)
+
+	// Primary hiện hành crash: phần còn lại phải chuyển View và commit tiếp trên chain cũ
+	var primary int
+	for _, i := range all {
+		if c.status(i).State == "Primary" {
+			primary = i
+		}
+	}
+	before := c.view(all)
+	c.stop(primary)
+	var rest []int
+	for _, i := range all {
+		if i != primary {
+			rest = append(rest, i)
+		}
+	}
+	c.commit(rest, 2, 10*time.Second)
+	if v := c.view(rest); v <= before {
+		t.Fatalf("view did not advance past %d", before)
+	}
+	c.checkChains(rest)
+}
+
+// ledger trả về các block đã commit của node i (bỏ Genesis).
+func (c *testCluster) ledger(i int) []*pb.LedgerEntry {
+	reply, _ := c.nodes[i].GetLedger(context.Background(), &pb.LedgerArgs{})
+	return reply.Entries
+}
+
+func TestClientRequestForwardedToPrimary(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	ctx := context.Background()
+
+	// Gửi tới Backup: được chuyển tiếp cho Primary
+	backup := primaryOf(c.view(all))%TotalNodes + 1
+	bin := []byte{0xff, 0x00, 0x01}
+	for i, op := range [][]byte{[]byte("transfer a->b 10"), bin} {
+		r, err := c.nodes[backup].SubmitRequest(ctx, signRequest(&pb.PbftRequest{ClientId: "client-a", Timestamp: int64(i + 1), Operation: op}))
+		if err != nil || !r.Success || r.Message != fmt.Sprintf("forwarded to node%d", primaryOf(c.view(all))) {
+			t.Fatalf("submit to backup = %v %v", r, err)
+		}
+		// Client chờ request trước thực thi rồi mới gửi request sau
+		if !c.waitCommitted(all, int64(i+1), 3*time.Second) {
+			t.Fatalf("request %d not committed: %v", i+1, c.status(backup))
+		}
+	}
+	c.checkChains(all)
+	for _, i := range all {
+		entries := c.ledger(i)
+		if entries[0].Data != "transfer a->b 10" || entries[1].Data != "0xff0001" {
+			t.Fatalf("node%d ledger = %v", i, entries)
+		}
+	}
+	blk := c.nodes[2].Blockchain[2]
+	if len(blk.Requests) != 1 || string(blk.Requests[0].Operation) != string(bin) {
+		t.Fatalf("block 2 requests = %v", blk.Requests)
+	}
+
+	// Request đã thực thi (timestamp không mới hơn) không tạo block mới
+	if r, _ := c.nodes[backup].SubmitRequest(ctx, signRequest(&pb.PbftRequest{ClientId: "client-a", Timestamp: 2, Operation: bin})); !r.Success || r.Message != "already executed" {
+		t.Fatalf("duplicate submit = %v", r)
+	}
+	if r, _ := c.nodes[backup].SubmitRequest(ctx, &pb.PbftRequest{Operation: bin}); r.Success {
+		t.Fatal("request without client_id accepted")
+	}
+	if r, _ := c.nodes[backup].SubmitRequest(ctx, &pb.PbftRequest{ClientId: "client-a", Timestamp: 3, Operation: bin}); r.Success {
+		t.Fatal("unsigned request accepted")
+	}
+	large := signRequest(&pb.PbftRequest{ClientId: "client-a", Timestamp: 3, Operation: make([]byte, MaxOperationSize+1)})
+	if r, _ := c.nodes[backup].SubmitRequest(ctx, large); r.Success {
+		t.Fatal("oversized operation accepted")
+	}
+	time.Sleep(300 * time.Millisecond)
+	if n := c.status(backup).Committed; n != 2 {
+		t.Fatalf("committed %d blocks after duplicate", n)
+	}
+}
+
+// Primary gán sequence cho mọi request trong hàng đợi mà không chờ instance
+// trước commit, nhưng không vượt high watermark; block thực thi đúng thứ tự.
+func TestConcurrentInstancesWithinWindow(t *testing.T) {
+	override(t, &CheckpointInterval, 2)
+	override(t, &BatchSize, 1) // Mỗi request một block
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	primary := c.nodes[primaryOf(c.view(all))]
+	window := func() (assigned, executed, high int64, inFlight int) {
+		primary.mu.Lock()
+		defer primary.mu.Unlock()
+		return primary.Assigned, primary.Sequence, primary.highWatermark(), len(primary.InFlight)
+	}
+
+	ctx := context.Background()
+	for i := 1; i <= 10; i++ {
+		req := signRequest(&pb.PbftRequest{ClientId: fmt.Sprintf("client-%d", i), Timestamp: 1, Operation: []byte(fmt.Sprintf("op %d", i))})
+		if r, err := primary.SubmitRequest(ctx, req); err != nil || !r.Success {
+			t.Fatalf("submit %d = %v %v", i, r, err)
+		}
+	}
+	// Cửa sổ (h, h+4]: nhiều instance chạy song song, request còn lại chờ checkpoint
+	if n, done, high, _ := window(); n != high || n-done < 2 {
+		t.Fatalf("assigned up to %d (executed %d), want several instances up to H=%d", n, done, high)
+	}
+	if !c.waitCommitted(all, 10, 5*time.Second) {
+		t.Fatalf("requests not committed: %v", c.status(1))
+	}
+	c.checkChains(all)
+	for i, e := range c.ledger(1) {
+		if e.Data != fmt.Sprintf("op %d", i+1) {
+			t.Fatalf("block %d = %q, want requests in submit order", i+1, e.Data)
+		}
+	}
+	if _, _, _, in := window(); in != 0 {
+		t.Fatalf("%d requests still in flight", in)
+	}
+}
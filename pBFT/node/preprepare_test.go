@@ -0,0 +1,102 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+)
+
+// prePrepare tạo PrePrepare hợp lệ cho block kế tiếp Genesis, ký bằng khoá của `from`.
+func prePrepare(from string, view int64, op string) *pb.PbftMessage {
+	req := signRequest(&pb.PbftRequest{ClientId: "mallory", Timestamp: 1, Operation: []byte(op)})
+	msg := &pb.PbftMessage{
+		Type:          "PrePrepare",
+		NodeId:        from,
+		View:          view,
+		Sequence:      1,
+		PrevBlockHash: "Genesis-Hash",
+		BlockHash:     blockHash(1, "Genesis-Hash", []*pb.PbftRequest{req}),
+		Data:          op,
+		Requests:      []*pb.PbftRequest{req},
+	}
+	return msg
+}
+
+func signAs(from string, msg *pb.PbftMessage) *pb.PbftMessage {
+	(&Server{signer: devKey(from)}).sign(msg)
+	return msg
+}
+
+// signRequest ký r bằng devKey của client.
+func signRequest(r *pb.PbftRequest) *pb.PbftRequest {
+	r.Signature = ed25519.Sign(devKey(r.ClientId), requestBytes(r))
+	return r
+}
+
+func TestPrePrepareValidation(t *testing.T) {
+	// Giữ nguyên View 1 (Primary node1) trong suốt test
+	override(t, &BaseTimeout, time.Minute)
+	c := newTestCluster(t)
+	victim := c.nodes[3]
+	accepted := func() *pb.PbftMessage {
+		victim.mu.Lock()
+		defer victim.mu.Unlock()
+		if e := victim.Log[slot{1, 1}]; e != nil {
+			return e.prePrepare
+		}
+		return nil
+	}
+	deliver := func(msg *pb.PbftMessage) {
+		victim.HandlePbftMessage(context.Background(), msg)
+	}
+
+	// Backup Byzantine tự đề xuất block
+	deliver(signAs("node2", prePrepare("node2", 1, "inject")))
+	// Digest không khớp request
+	bad := prePrepare("node1", 1, "pay 10")
+	bad.Requests[0].Operation = []byte("pay 1000")
+	bad.Data = "pay 1000"
+	deliver(signAs("node1", bad))
+	// Không nối vào đỉnh chain
+	fork := prePrepare("node1", 1, "fork")
+	fork.PrevBlockHash = "other"
+	fork.BlockHash = blockHash(1, "other", fork.Requests)
+	deliver(signAs("node1", fork))
+	if pp := accepted(); pp != nil {
+		t.Fatalf("invalid PrePrepare accepted: %v", pp)
+	}
+
+	// Primary Byzantine gửi hai digest cho cùng (view, sequence): chỉ cái đầu được nhận
+	first := signAs("node1", prePrepare("node1", 1, "pay bob"))
+	second := signAs("node1", prePrepare("node1", 1, "pay carol"))
+	deliver(first)
+	deliver(second)
+	deliver(first) // Bản lặp không phải bằng chứng
+	if pp := accepted(); pp == nil || pp.BlockHash != first.BlockHash {
+		t.Fatalf("accepted PrePrepare = %v", pp)
+	}
+	victim.mu.Lock()
+	evidence := victim.Evidence
+	victim.mu.Unlock()
+	if len(evidence) != 1 || evidence[0].First.BlockHash != first.BlockHash || evidence[0].Second.BlockHash != second.BlockHash {
+		t.Fatalf("evidence = %+v", evidence)
+	}
+
+	// Bằng chứng chuyển được: node khác kiểm tra cả hai chữ ký của node1
+	for _, m := range []*pb.PbftMessage{evidence[0].First, evidence[0].Second} {
+		if err := c.nodes[5].verify(m); err != nil {
+			t.Fatalf("evidence does not verify: %v", err)
+		}
+	}
+
+	// Không block nào lọt vào chain
+	time.Sleep(300 * time.Millisecond)
+	for i := 1; i <= TotalNodes; i++ {
+		if n := c.status(i).Committed; n != 0 {
+			t.Fatalf("node%d committed %d blocks from invalid PrePrepares", i, n)
+		}
+	}
+}
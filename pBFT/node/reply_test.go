@@ -0,0 +1,110 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+	"consensus/pBFT/client"
+)
+
+func TestClientAcceptsFPlusOneMatchingReplies(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	// Một Backup Byzantine trả reply giả ngay lập tức
+	bad := primaryOf(c.view(all))%TotalNodes + 1
+	c.nodes[bad].SetMalicious(true)
+
+	cl, err := client.Dial("alice", devKey("alice"), c.addrs, c.keys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.RetryTimeout = 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for n := int64(1); n <= 3; n++ {
+		res, err := cl.Submit(ctx, []byte("transfer a->b 10"))
+		if err != nil {
+			t.Fatalf("submit %d: %v", n, err)
+		}
+		honest := bad%TotalNodes + 1
+		if blk := c.ledger(honest)[res.Sequence-1]; res.Sequence != n || res.BlockHash != blk.Hash {
+			t.Fatalf("result = %+v, node%d has block %v", res, honest, blk)
+		}
+	}
+}
+
+func TestClientRetransmitsAfterPrimaryCrash(t *testing.T) {
+	c := newTestCluster(t)
+	all := []int{1, 2, 3, 4, 5}
+	before := c.view(all)
+	primary := primaryOf(before)
+	c.stop(primary)
+
+	// Request gửi tới Primary đã crash bị mất; client gửi lại cho mọi replica,
+	// Backup chuyển tiếp cho Primary mới sau View Change
+	cl, err := client.Dial("bob", devKey("bob"), c.addrs, c.keys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.RetryTimeout = 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	res, err := cl.Submit(ctx, []byte("after crash"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.View <= before {
+		t.Fatalf("reply from view %d, crash happened in view %d", res.View, before)
+	}
+	var rest []int
+	for _, i := range all {
+		if i != primary {
+			rest = append(rest, i)
+		}
+	}
+	c.checkChains(rest)
+	for _, i := range rest {
+		if e := c.ledger(i); len(e) < int(res.Sequence) || e[res.Sequence-1].Hash != res.BlockHash || e[res.Sequence-1].Data != "after crash" {
+			t.Fatalf("node%d ledger = %v, result %+v", i, e, res)
+		}
+	}
+}
+
+// Chế độ MAC: replica mở stream bằng reply đã ký mang public key X25519 rồi
+// đẩy reply có HMAC bằng session key với client thay vì chữ ký.
+func TestClientAcceptsMACReplies(t *testing.T) {
+	c := newTestCluster(t, withAuth(AuthMAC))
+	c.waitSessionKeys(3 * time.Second)
+	cl, err := client.Dial("carol", devKey("carol"), c.addrs, c.keys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.RetryTimeout = 300 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := cl.Submit(ctx, []byte("mac reply"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dh, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	stream := c.replies(ctx, 2, &pb.ReplyWatchArgs{ClientId: "carol", SessionKey: dh.PublicKey().Bytes()})
+	hello, err := stream.Recv()
+	if err != nil || hello.SessionKey == nil || !ed25519.Verify(c.keys()["node2"], replyBytes(hello), hello.Signature) {
+		t.Fatalf("stream opened with %v %v", hello, err)
+	}
+	pub, _ := ecdh.X25519().NewPublicKey(hello.SessionKey)
+	shared, _ := dh.ECDH(pub)
+	key := sha256.Sum256(append([]byte("pbft-reply/node2/carol/"), shared...))
+	reply, err := stream.Recv()
+	if err != nil || reply.Signature != nil || reply.Sequence != res.Sequence || !hmac.Equal(reply.Authenticator, mac(key[:], replyBytes(reply))) {
+		t.Fatalf("MAC reply = %v %v, result %+v", reply, err, res)
+	}
+}
@@ -0,0 +1,235 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client gửi <REQUEST, o, t, c> qua SubmitRequest tới node bất kỳ: Primary
+// xếp request vào hàng đợi và gán sequence, Backup chuyển tiếp cho Primary
+// của View hiện tại. Primary gom request trong hàng đợi thành batch và gán
+// sequence liên tiếp cho các batch mà không chờ instance trước commit: nhiều
+// instance chạy song song trong cửa sổ watermark, block vẫn được thực thi
+// theo thứ tự sequence.
+
+// primaryOf trả về NodeIndex của Primary trong `view`.
+func primaryOf(view int64) int {
+	return int(view-1)%TotalNodes + 1
+}
+
+func (s *Server) SubmitRequest(ctx context.Context, req *pb.PbftRequest) (*pb.PbftResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.IsMalicious || s.Stopped {
+		return &pb.PbftResponse{Success: false}, nil
+	}
+	if err := s.validRequest(req); err != nil {
+		return &pb.PbftResponse{Success: false, Message: err.Error()}, nil
+	}
+	if req.Timestamp <= s.LastExecuted[req.ClientId] {
+		return &pb.PbftResponse{Success: true, Message: "already executed"}, nil
+	}
+	if primary := primaryOf(s.View); s.NodeIndex != primary {
+		id := fmt.Sprintf("node%d", primary)
+		go s.forward(id, req)
+		return &pb.PbftResponse{Success: true, Message: "forwarded to " + id}, nil
+	}
+	s.enqueue(req)
+	return &pb.PbftResponse{Success: true, Message: "queued at primary " + s.NodeID}, nil
+}
+
+// forward chuyển request cho Primary; mất thì client tự gửi lại.
+func (s *Server) forward(primary string, req *pb.PbftRequest) {
+	s.mu.Lock()
+	c, ok := s.PeerClients[primary]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c.SubmitRequest(ctx, req)
+}
+
+// enqueue bỏ qua request đã có trong hàng đợi hoặc đang chạy.
+func (s *Server) enqueue(req *pb.PbftRequest) {
+	if s.inFlight(req) {
+		return
+	}
+	for _, r := range s.Queue {
+		if sameRequest(r, req) {
+			return
+		}
+	}
+	if len(s.Queue) == 0 {
+		s.batchStart = time.Now()
+	}
+	s.Queue = append(s.Queue, req)
+	s.orderNext()
+}
+
+func sameRequest(a, b *pb.PbftRequest) bool {
+	return a.ClientId == b.ClientId && a.Timestamp == b.Timestamp
+}
+
+// inFlight: request đã được gán sequence trong view hiện tại mà chưa thực thi.
+func (s *Server) inFlight(req *pb.PbftRequest) bool {
+	for _, batch := range s.InFlight {
+		for _, r := range batch {
+			if sameRequest(r, req) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orderNext gán sequence kế tiếp cho các batch request trong hàng đợi tới khi
+// hết hàng đợi hoặc chạm high watermark. PrePrepare mới nối vào PrePrepare vừa
+// gán trước nó (kể cả O của NewView) nên không cần chờ instance trước commit.
+func (s *Server) orderNext() {
+	if s.InViewChange || s.IsMalicious || s.NodeIndex != primaryOf(s.View) {
+		return
+	}
+	for {
+		newSeq := max(s.Assigned, s.Sequence) + 1
+		if newSeq > s.highWatermark() {
+			return // Chờ checkpoint ổn định mới
+		}
+		prev, ok := s.prevHash(s.View, newSeq)
+		if !ok {
+			return
+		}
+		batch := s.nextBatch()
+		if batch == nil {
+			return
+		}
+		s.Queue, s.batchStart = s.Queue[len(batch):], time.Now()
+		s.propose(newSeq, prev, batch)
+	}
+}
+
+// propose gửi PrePrepare gán sequence `seq` cho batch.
+func (s *Server) propose(seq int64, prev string, batch []*pb.PbftRequest) {
+	s.Assigned = seq
+	s.InFlight[seq] = batch
+	msg := &pb.PbftMessage{
+		Type:          "PrePrepare",
+		NodeId:        s.NodeID,
+		View:          s.View,
+		Sequence:      seq,
+		BlockHash:     blockHash(seq, prev, batch),
+		PrevBlockHash: prev,
+		Data:          batchData(batch),
+		Timestamp:     time.Now().UnixMilli(),
+		Requests:      batch,
+	}
+	// Primary ghi PrePrepare (đã xác thực, để làm chứng chỉ khi đổi view) vào
+	// log ngay khi đề xuất và không gửi Prepare
+	s.authenticate(msg)
+	s.entry(s.View, seq).prePrepare = msg
+	s.report("START", fmt.Sprintf("Primary proposed Block #%d with %d requests", seq, len(batch)), "blue")
+	go s.send(msg)
+}
+
+// digest là mã băm d của request trong <PRE-PREPARE, v, n, d>.
+func digest(req *pb.PbftRequest) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|", req.ClientId, req.Timestamp)
+	h.Write(req.Operation)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// blockHash nối block vào chain: băm sequence, hash block trước và gốc Merkle
+// của batch request.
+func blockHash(seq int64, prevHash string, batch []*pb.PbftRequest) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d%s%s", seq, prevHash, merkleRoot(batch))))
+	return hex.EncodeToString(hash[:])
+}
+
+// display: payload UTF-8 giữ nguyên trên ledger/dashboard, payload nhị phân ghi dạng hex.
+func display(op []byte) string {
+	if utf8.Valid(op) {
+		return string(op)
+	}
+	return "0x" + hex.EncodeToString(op)
+}
+
+// WatchReplies đẩy <REPLY, v, t, c, i, r> cho client args.ClientId ngay
+// khi một request của client được thực thi, mở đầu bằng reply gần nhất (nếu
+// có). Client mở stream tới mọi replica và chờ f+1 reply khớp nhau. Chỉ giữ
+// reply gần nhất của mỗi client (như bài báo).
+func (s *Server) WatchReplies(args *pb.ReplyWatchArgs, stream grpc.ServerStreamingServer[pb.PbftReply]) error {
+	s.mu.Lock()
+	var key []byte
+	if s.Auth == AuthMAC {
+		k, hello, err := s.replyKey(args)
+		s.mu.Unlock()
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := stream.Send(hello); err != nil {
+			return err
+		}
+		key = k
+		s.mu.Lock()
+	}
+	var sent int64 // Timestamp của reply đã đẩy gần nhất
+	for {
+		if s.Stopped {
+			s.mu.Unlock()
+			return status.Error(codes.Unavailable, "node stopped")
+		}
+		var out *pb.PbftReply
+		if r := s.LastReply[args.ClientId]; r != nil && r.Timestamp > sent {
+			sent = r.Timestamp
+			if s.IsMalicious {
+				// Replica Byzantine đẩy kết quả giả (ký bằng khoá của chính nó)
+				r = &pb.PbftReply{View: r.View, Timestamp: r.Timestamp, ClientId: r.ClientId, NodeId: s.NodeID, Sequence: r.Sequence + 1, BlockHash: "forged"}
+			}
+			out = s.authenticateReply(r, key)
+		}
+		executed := s.executed
+		s.mu.Unlock()
+		if out != nil {
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		} else {
+			select {
+			case <-executed:
+			case <-stream.Context().Done():
+				return nil
+			}
+		}
+		s.mu.Lock()
+	}
+}
+
+// recordReply lưu reply cho request `r` vừa thực thi ở block `b` và đánh thức
+// các WatchReplies đang chờ.
+func (s *Server) recordReply(b Block, r *pb.PbftRequest) {
+	if last := s.LastReply[r.ClientId]; last != nil && last.Timestamp >= r.Timestamp {
+		return
+	}
+	s.LastReply[r.ClientId] = &pb.PbftReply{
+		View:      s.View,
+		Timestamp: r.Timestamp,
+		ClientId:  r.ClientId,
+		NodeId:    s.NodeID,
+		Sequence:  b.Sequence,
+		BlockHash: b.Hash,
+	}
+	close(s.executed)
+	s.executed = make(chan struct{})
+}
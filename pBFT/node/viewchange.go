@@ -0,0 +1,377 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// View change theo Castro–Liskov. Hết timeout ở view v, replica chuyển sang
+// v+1, ngừng nhận PrePrepare và gửi <VIEW-CHANGE, v+1, n, C, P, i> mang
+// checkpoint ổn định n (kèm bằng chứng C) và tập P các chứng chỉ prepared có
+// sequence > n. Primary của v+1 gom 2f+1 ViewChange thành V, tính O (PrePrepare
+// phát lại cho mỗi sequence trong (min-s, max-s], null request cho sequence
+// không có chứng chỉ) và gửi <NEW-VIEW, v+1, V, O>. Backup tự tính lại O từ V
+// và chỉ vào view mới khi khớp. Request đã prepared ở view cũ nhờ đó giữ
+// nguyên sequence.
+
+// Null request (batch rỗng) lấp sequence không có chứng chỉ prepared nào
+// trong V; thực thi là no-op (block rỗng, không trả lời client).
+func isNull(batch []*pb.PbftRequest) bool {
+	return len(batch) == 0
+}
+
+// startViewChange chuyển sang `view` và gửi ViewChange; ViewChange của chính
+// node được ghi nhận khi Broadcast giao lại cho node.
+func (s *Server) startViewChange(view int64) {
+	s.View = view
+	s.InViewChange = true
+	n, d, proof := s.stableCheckpoint()
+	go s.Broadcast(&pb.PbftMessage{
+		Type:            "ViewChange",
+		NodeId:          s.NodeID,
+		View:            view,
+		Sequence:        n,
+		BlockHash:       d,
+		CheckpointProof: proof,
+		Prepared:        s.preparedCerts(n),
+		Timestamp:       time.Now().UnixMilli(),
+	})
+}
+
+// preparedCerts là tập P: với mỗi sequence > low đã prepared, chứng chỉ ở view
+// cao nhất (PrePrepare và các Prepare khớp từ Backup).
+func (s *Server) preparedCerts(low int64) []*pb.PreparedCert {
+	best := make(map[int64]slot)
+	for sl, e := range s.Log {
+		if sl.seq <= low || !e.prepared(sl.view) {
+			continue
+		}
+		if b, ok := best[sl.seq]; !ok || sl.view > b.view {
+			best[sl.seq] = sl
+		}
+	}
+	var certs []*pb.PreparedCert
+	for _, sl := range best {
+		e := s.Log[sl]
+		cert := &pb.PreparedCert{PrePrepare: e.prePrepare}
+		primary := fmt.Sprintf("node%d", primaryOf(sl.view))
+		for id, p := range e.prepares {
+			if id != primary && p.BlockHash == e.prePrepare.BlockHash {
+				cert.Prepares = append(cert.Prepares, p)
+			}
+		}
+		certs = append(certs, cert)
+	}
+	slices.SortFunc(certs, func(a, b *pb.PreparedCert) int {
+		return int(a.PrePrepare.Sequence - b.PrePrepare.Sequence)
+	})
+	return certs
+}
+
+// validCert kiểm tra chứng chỉ prepared trong ViewChange cho `view` với
+// checkpoint `low`: PrePrepare của đúng Primary ở view cũ hơn, digest khớp
+// batch request, và 2f Prepare khớp đã xác thực từ các Backup khác nhau.
+func (s *Server) validCert(cert *pb.PreparedCert, view, low int64) error {
+	pp := cert.PrePrepare
+	if pp == nil || pp.Type != "PrePrepare" {
+		return fmt.Errorf("certificate without PrePrepare")
+	}
+	primary := fmt.Sprintf("node%d", primaryOf(pp.View))
+	switch {
+	case pp.View >= view:
+		return fmt.Errorf("certificate from view %d", pp.View)
+	case pp.Sequence <= low:
+		return fmt.Errorf("certificate for seq %d below checkpoint %d", pp.Sequence, low)
+	case pp.NodeId != primary:
+		return fmt.Errorf("seq %d: PrePrepare not from primary %s", pp.Sequence, primary)
+	case pp.BlockHash != blockHash(pp.Sequence, pp.PrevBlockHash, pp.Requests):
+		return fmt.Errorf("seq %d: digest does not match requests", pp.Sequence)
+	}
+	if err := s.verify(pp); err != nil {
+		return fmt.Errorf("seq %d: PrePrepare: %w", pp.Sequence, err)
+	}
+	from := make(map[string]bool)
+	for _, p := range cert.Prepares {
+		if p.Type != "Prepare" || p.NodeId == primary || p.View != pp.View || p.Sequence != pp.Sequence || p.BlockHash != pp.BlockHash {
+			continue
+		}
+		if s.verify(p) == nil {
+			from[p.NodeId] = true
+		}
+	}
+	if len(from) < 2*Faults {
+		return fmt.Errorf("seq %d: %d valid Prepares", pp.Sequence, len(from))
+	}
+	return nil
+}
+
+// validViewChange kiểm tra checkpoint và mọi chứng chỉ trong P (mỗi sequence một chứng chỉ).
+func (s *Server) validViewChange(vc *pb.PbftMessage) error {
+	if err := s.validCheckpoint(vc.Sequence, vc.BlockHash, vc.CheckpointProof); err != nil {
+		return err
+	}
+	seen := make(map[int64]bool)
+	for _, cert := range vc.Prepared {
+		if err := s.validCert(cert, vc.View, vc.Sequence); err != nil {
+			return err
+		}
+		if seen[cert.PrePrepare.Sequence] {
+			return fmt.Errorf("two certificates for seq %d", cert.PrePrepare.Sequence)
+		}
+		seen[cert.PrePrepare.Sequence] = true
+	}
+	return nil
+}
+
+func (s *Server) handleViewChange(vc *pb.PbftMessage) {
+	if vc.View < s.View || (vc.View == s.View && !s.InViewChange) {
+		// Node gửi bị tụt lại và đã lỡ NewView: gửi lại NewView của view hiện tại
+		if s.LastNewView != nil && vc.NodeId != s.NodeID {
+			s.sendTo(vc.NodeId, s.LastNewView)
+		}
+		return
+	}
+	if _, ok := s.ViewChangeMsgs[vc.View][vc.NodeId]; ok {
+		return
+	}
+	if err := s.validViewChange(vc); err != nil {
+		s.report("REJECTED", fmt.Sprintf("ViewChange to %d from %s: %v", vc.View, vc.NodeId, err), "red")
+		return
+	}
+	if _, ok := s.ViewChangeMsgs[vc.View]; !ok {
+		s.ViewChangeMsgs[vc.View] = make(map[string]*pb.PbftMessage)
+	}
+	s.ViewChangeMsgs[vc.View][vc.NodeId] = vc
+
+	// f+1 node đã rời view hiện tại (ít nhất một node trung thực): theo sang
+	// view nhỏ nhất trong số đó thay vì chờ timeout
+	if vc.View > s.View {
+		ahead := make(map[string]int64)
+		for v, msgs := range s.ViewChangeMsgs {
+			for id := range msgs {
+				if v > s.View && (ahead[id] == 0 || v < ahead[id]) {
+					ahead[id] = v
+				}
+			}
+		}
+		if len(ahead) >= Faults+1 {
+			target := vc.View
+			for _, v := range ahead {
+				target = min(target, v)
+			}
+			s.report("VIEW-CHANGE", fmt.Sprintf("%d nodes moved past view %d, joining view %d", len(ahead), s.View, target), "orange")
+			s.startViewChange(target)
+		}
+	}
+	s.sendNewView(vc.View)
+}
+
+// sendNewView: Primary của `view` có 2f+1 ViewChange thì tạo NewView, vào view
+// mới và gửi cho các Backup.
+func (s *Server) sendNewView(view int64) {
+	vcs := s.ViewChangeMsgs[view]
+	if view != s.View || !s.InViewChange || s.NodeIndex != primaryOf(view) || len(vcs) < Quorum {
+		return
+	}
+	var v []*pb.PbftMessage
+	for _, vc := range vcs {
+		v = append(v, vc)
+	}
+	slices.SortFunc(v, func(a, b *pb.PbftMessage) int {
+		return strings.Compare(a.NodeId, b.NodeId)
+	})
+	o := newViewPrePrepares(view, v)
+	for _, pp := range o {
+		// Mỗi PrePrepare trong O được xác thực riêng để sau này làm chứng chỉ prepared
+		s.authenticate(pp)
+	}
+	nv := &pb.PbftMessage{
+		Type:        "NewView",
+		NodeId:      s.NodeID,
+		View:        view,
+		ViewChanges: v,
+		PrePrepares: o,
+		Timestamp:   time.Now().UnixMilli(),
+	}
+	s.authenticate(nv)
+	s.report("LEADER-ELECTION", fmt.Sprintf("I am new Primary for View %d! (Votes: %d, re-issued %d)", view, len(v), len(o)), "purple")
+	s.installNewView(nv)
+	go s.send(nv)
+}
+
+// newViewPrePrepares tính O từ V: min-s là checkpoint ổn định mới nhất trong V,
+// max-s là sequence prepared lớn nhất. Mỗi sequence trong (min-s, max-s] nhận
+// request của chứng chỉ ở view cao nhất, không có thì null request. Mọi
+// PrePrepare trong O (kể cả null request) được nối lại vào block trước nó để
+// chain liền mạch: sau một null request, block của chứng chỉ không còn nối
+// vào PrevBlockHash cũ. PrePrepare trong O chưa được xác thực.
+func newViewPrePrepares(view int64, vcs []*pb.PbftMessage) []*pb.PbftMessage {
+	minS, prev := int64(-1), ""
+	for _, vc := range vcs {
+		if vc.Sequence > minS {
+			minS, prev = vc.Sequence, vc.BlockHash
+		}
+	}
+	best := make(map[int64]*pb.PbftMessage)
+	maxS := minS
+	for _, vc := range vcs {
+		for _, cert := range vc.Prepared {
+			pp := cert.PrePrepare
+			if pp.Sequence <= minS {
+				continue
+			}
+			if b := best[pp.Sequence]; b == nil || pp.View > b.View {
+				best[pp.Sequence] = pp
+			}
+			maxS = max(maxS, pp.Sequence)
+		}
+	}
+	var o []*pb.PbftMessage
+	for n := minS + 1; n <= maxS; n++ {
+		pp := &pb.PbftMessage{
+			Type:          "PrePrepare",
+			NodeId:        fmt.Sprintf("node%d", primaryOf(view)),
+			View:          view,
+			Sequence:      n,
+			PrevBlockHash: prev,
+		}
+		if b := best[n]; b != nil {
+			pp.Requests = b.Requests
+		}
+		pp.BlockHash = blockHash(n, pp.PrevBlockHash, pp.Requests)
+		pp.Data = batchData(pp.Requests)
+		o = append(o, pp)
+		prev = pp.BlockHash
+	}
+	return o
+}
+
+// validNewView: NewView của đúng Primary, V gồm 2f+1 ViewChange hợp lệ cho view
+// mới từ các node khác nhau, O khớp với O tự tính từ V và mỗi PrePrepare trong
+// O nối vào block trước nó.
+func (s *Server) validNewView(nv *pb.PbftMessage) error {
+	if primary := fmt.Sprintf("node%d", primaryOf(nv.View)); nv.NodeId != primary {
+		return fmt.Errorf("sender is not primary %s of view %d", primary, nv.View)
+	}
+	from := make(map[string]bool)
+	for _, vc := range nv.ViewChanges {
+		if vc.Type != "ViewChange" || vc.View != nv.View || from[vc.NodeId] {
+			return fmt.Errorf("unexpected %s from %s for view %d", vc.Type, vc.NodeId, vc.View)
+		}
+		if err := s.verify(vc); err != nil {
+			return fmt.Errorf("ViewChange from %s: %w", vc.NodeId, err)
+		}
+		if err := s.validViewChange(vc); err != nil {
+			return fmt.Errorf("ViewChange from %s: %w", vc.NodeId, err)
+		}
+		from[vc.NodeId] = true
+	}
+	if len(from) < Quorum {
+		return fmt.Errorf("only %d ViewChanges", len(from))
+	}
+	want := newViewPrePrepares(nv.View, nv.ViewChanges)
+	if len(nv.PrePrepares) != len(want) {
+		return fmt.Errorf("O has %d PrePrepares, expected %d", len(nv.PrePrepares), len(want))
+	}
+	for i, pp := range nv.PrePrepares {
+		if i > 0 && pp.PrevBlockHash != nv.PrePrepares[i-1].BlockHash {
+			return fmt.Errorf("O breaks the chain at seq %d", pp.Sequence)
+		}
+		got := proto.Clone(pp).(*pb.PbftMessage)
+		got.Signature, got.Authenticators = nil, nil
+		if !proto.Equal(got, want[i]) {
+			return fmt.Errorf("O does not match V at seq %d", want[i].Sequence)
+		}
+		if err := s.verify(pp); err != nil {
+			return fmt.Errorf("PrePrepare #%d: %w", pp.Sequence, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleNewView(nv *pb.PbftMessage) {
+	if nv.View < s.View || (nv.View == s.View && !s.InViewChange) {
+		return
+	}
+	if err := s.validNewView(nv); err != nil {
+		s.report("REJECTED", fmt.Sprintf("NewView %d from %s: %v", nv.View, nv.NodeId, err), "red")
+		return
+	}
+	s.installNewView(nv)
+	s.report("NEW-VIEW", fmt.Sprintf("Followed new Primary %s to View %d (%d re-issued)", nv.NodeId, s.View, len(nv.PrePrepares)), "purple")
+}
+
+// installNewView vào view của NewView hợp lệ: ghi các PrePrepare trong O vào
+// log và chạy lại 3 pha cho chúng như PrePrepare thường. Sequence đã thực thi
+// thì gửi Prepare kèm luôn Commit (block đã committed) để node còn tụt lại đủ quorum.
+func (s *Server) installNewView(nv *pb.PbftMessage) {
+	view := nv.View
+	s.View, s.InViewChange, s.LastNewView = view, false, nv
+	s.CurrentTimeout = BaseTimeout // Reset backoff về mặc định
+	for v := range s.ViewChangeMsgs {
+		if v <= view {
+			delete(s.ViewChangeMsgs, v)
+		}
+	}
+	// Checkpoint ổn định trong V mới hơn của node: nhận luôn (đã kèm bằng chứng)
+	for _, vc := range nv.ViewChanges {
+		if vc.Sequence > s.Stable.Sequence {
+			s.setStable(Checkpoint{Sequence: vc.Sequence, Digest: vc.BlockHash, Proof: vc.CheckpointProof})
+		}
+	}
+	// Request chưa commit ở View cũ: client gửi lại tới Primary mới
+	s.InFlight = make(map[int64][]*pb.PbftRequest)
+	isPrimary := s.NodeIndex == primaryOf(view)
+	if !isPrimary {
+		s.Queue = nil
+	}
+	s.Assigned = s.Sequence
+
+	for _, pp := range nv.PrePrepares {
+		s.Assigned = max(s.Assigned, pp.Sequence)
+		e := s.entry(view, pp.Sequence)
+		e.prePrepare = pp
+		executed := pp.Sequence <= s.Sequence
+		if executed && s.Blockchain[pp.Sequence].Hash != pp.BlockHash {
+			continue
+		}
+		if isPrimary && !executed && !isNull(pp.Requests) {
+			s.InFlight[pp.Sequence] = pp.Requests // Không gán sequence mới cho request đã có trong O
+		}
+		if !isPrimary {
+			go s.Broadcast(s.vote("Prepare", pp))
+		}
+		if executed {
+			e.sentCommit = true
+			go s.Broadcast(s.vote("Commit", pp))
+		}
+	}
+	// Prepare/Commit của view mới tới trước NewView đã nằm sẵn trong log
+	for _, pp := range nv.PrePrepares {
+		s.advance(view, pp.Sequence)
+	}
+	// PrePrepare của view mới tới trước NewView
+	s.replayFuture()
+	s.resetTimer()
+	s.orderNext()
+}
+
+// sendTo gửi tin nhắn đã xác thực cho một peer.
+func (s *Server) sendTo(peer string, msg *pb.PbftMessage) {
+	c, ok := s.PeerClients[peer]
+	if !ok {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		c.HandlePbftMessage(ctx, msg)
+	}()
+}
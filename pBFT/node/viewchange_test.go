@@ -0,0 +1,131 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "consensus/common/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Request chỉ prepared ở node3 trong view 1 (chưa node nào commit): ViewChange
+// của node3 nằm trong V nên Primary mới phải phát lại request trong NewView và
+// nó được commit ở đúng sequence cũ.
+func TestPreparedRequestSurvivesViewChange(t *testing.T) {
+	override(t, &BaseTimeout, time.Minute)
+	c := newTestCluster(t)
+	c.stop(1)
+	rest := []int{2, 3, 4, 5}
+
+	pp := signAs("node1", prePrepare("node1", 1, "pay bob"))
+	node3 := c.nodes[3]
+	node3.HandlePbftMessage(context.Background(), pp)
+	for _, from := range []string{"node4", "node5"} {
+		node3.HandlePbftMessage(context.Background(), signAs(from, &pb.PbftMessage{Type: "Prepare", NodeId: from, View: 1, Sequence: 1, BlockHash: pp.BlockHash, PrevBlockHash: "Genesis-Hash"}))
+	}
+	prepared := func() bool {
+		node3.mu.Lock()
+		defer node3.mu.Unlock()
+		e := node3.Log[slot{1, 1}]
+		return e != nil && e.prepared(1)
+	}
+	for deadline := time.Now().Add(time.Second); !prepared(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("node3 did not prepare the request")
+		}
+	}
+	for _, i := range rest {
+		if n := c.status(i).Committed; n != 0 {
+			t.Fatalf("node%d committed %d blocks before view change", i, n)
+		}
+	}
+
+	// Primary node1 im lặng: mọi Backup đổi sang view 2 (Primary node2),
+	// node3 trước để ViewChange của nó có trong 2f+1 ViewChange đầu tiên
+	viewChange := func(i int) {
+		s := c.nodes[i]
+		s.mu.Lock()
+		s.startViewChange(2)
+		s.mu.Unlock()
+	}
+	received := func() bool {
+		c.nodes[2].mu.Lock()
+		defer c.nodes[2].mu.Unlock()
+		return c.nodes[2].ViewChangeMsgs[2]["node3"] != nil
+	}
+	viewChange(3)
+	for deadline := time.Now().Add(time.Second); !received(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("node2 did not receive node3's ViewChange")
+		}
+	}
+	for _, i := range []int{2, 4, 5} {
+		viewChange(i)
+	}
+	if !c.waitCommitted(rest, 1, 3*time.Second) {
+		t.Fatalf("re-issued request not committed: %v", c.status(2))
+	}
+	for _, i := range rest {
+		if v := c.status(i).Term; v != 2 {
+			t.Fatalf("node%d in view %d", i, v)
+		}
+		if e := c.ledger(i); e[0].Hash != pp.BlockHash || e[0].Data != "pay bob" {
+			t.Fatalf("node%d block 1 = %v, want the request prepared in view 1", i, e[0])
+		}
+	}
+
+	// NewView bỏ request đã prepared (thay bằng null request) bị Backup từ chối
+	node3.mu.Lock()
+	defer node3.mu.Unlock()
+	forged := proto.Clone(node3.LastNewView).(*pb.PbftMessage)
+	null := &pb.PbftMessage{Type: "PrePrepare", NodeId: "node2", View: 2, Sequence: 1, PrevBlockHash: "Genesis-Hash", BlockHash: blockHash(1, "Genesis-Hash", nil)}
+	forged.PrePrepares = []*pb.PbftMessage{signAs("node2", null)}
+	forged.Signature = nil
+	if err := node3.validNewView(signAs("node2", forged)); err == nil {
+		t.Fatal("NewView dropping a prepared request was accepted")
+	}
+	// V thiếu quorum
+	short := proto.Clone(node3.LastNewView).(*pb.PbftMessage)
+	short.ViewChanges = short.ViewChanges[:Quorum-1]
+	short.Signature = nil
+	if err := node3.validNewView(signAs("node2", short)); err == nil {
+		t.Fatal("NewView with 2f ViewChanges was accepted")
+	}
+}
+
+// O lấy chứng chỉ ở view cao nhất cho mỗi sequence, lấp chỗ trống bằng null
+// request và nối lại mọi PrePrepare thành một chain liền mạch.
+func TestNewViewPrePrepares(t *testing.T) {
+	cert := func(view, seq int64, op string) *pb.PreparedCert {
+		batch := []*pb.PbftRequest{{ClientId: "c", Timestamp: view, Operation: []byte(op)}}
+		return &pb.PreparedCert{PrePrepare: &pb.PbftMessage{Type: "PrePrepare", View: view, Sequence: seq, PrevBlockHash: "h1", BlockHash: blockHash(seq, "h1", batch), Requests: batch}}
+	}
+	vc := func(from string, certs ...*pb.PreparedCert) *pb.PbftMessage {
+		return &pb.PbftMessage{Type: "ViewChange", NodeId: from, View: 4, Sequence: 0, BlockHash: "Genesis-Hash", Prepared: certs}
+	}
+	o := newViewPrePrepares(4, []*pb.PbftMessage{
+		vc("node2", cert(1, 2, "old")),
+		vc("node3", cert(3, 2, "new")),
+		vc("node5"),
+	})
+	if len(o) != 2 {
+		t.Fatalf("O has %d PrePrepares, want seq 1 and 2", len(o))
+	}
+	if o[0].Sequence != 1 || !isNull(o[0].Requests) || o[0].PrevBlockHash != "Genesis-Hash" {
+		t.Fatalf("seq 1 = %v, want null request on Genesis", o[0])
+	}
+	if o[1].Sequence != 2 || string(o[1].Requests[0].Operation) != "new" {
+		t.Fatalf("seq 2 = %v, want request prepared in view 3", o[1])
+	}
+	// Block của chứng chỉ nối vào null request thay vì PrevBlockHash cũ "h1"
+	if o[1].PrevBlockHash != o[0].BlockHash || o[1].BlockHash != blockHash(2, o[0].BlockHash, o[1].Requests) {
+		t.Fatalf("seq 2 = %v does not extend seq 1 %.8s", o[1], o[0].BlockHash)
+	}
+	for _, pp := range o {
+		if pp.View != 4 || pp.NodeId != "node4" {
+			t.Fatalf("PrePrepare %d issued as %s in view %d", pp.Sequence, pp.NodeId, pp.View)
+		}
+	}
+}
@@ -30,6 +30,9 @@ IF %ERRORLEVEL% NEQ 0 (
     exit /b
 )
 
+:: Sinh khoá ed25519 cho 5 node (một lần, giữ lại giữa các lần chạy)
+IF NOT EXIST keys\cluster.json node-app.exe -genkeys keys
+
 :: --- 3. START DASHBOARD ---
 echo [3/4] Starting Dashboard...
 start /B dashboard-app.exe > dashboard.log 2>&1
@@ -38,11 +41,11 @@ start /B dashboard-app.exe > dashboard.log 2>&1
 echo [4/4] Starting 5 pBFT Nodes...
 
 :: Dùng start /B để chạy ngầm (Background)
-start /B node-app.exe -id=node1 -port=50051
-start /B node-app.exe -id=node2 -port=50052
-start /B node-app.exe -id=node3 -port=50053
-start /B node-app.exe -id=node4 -port=50054
-start /B node-app.exe -id=node5 -port=50055
+start /B node-app.exe -id=node1 -port=50051 -cluster=keys\cluster.json -key=keys\node1.key
+start /B node-app.exe -id=node2 -port=50052 -cluster=keys\cluster.json -key=keys\node2.key
+start /B node-app.exe -id=node3 -port=50053 -cluster=keys\cluster.json -key=keys\node3.key
+start /B node-app.exe -id=node4 -port=50054 -cluster=keys\cluster.json -key=keys\node4.key
+start /B node-app.exe -id=node5 -port=50055 -cluster=keys\cluster.json -key=keys\node5.key
 echo.
 echo ✅ SYSTEM STARTED SUCCESSFULLY!
 echo 👉 Dashboard: http://localhost:8080
@@ -35,16 +35,22 @@ if [ $? -ne 0 ]; then echo "❌ Build Node Failed"; exit 1; fi
 go build -o dashboard-app dashboard/server.go
 if [ $? -ne 0 ]; then echo "❌ Build Dashboard Failed"; exit 1; fi
 
+# 3. Sinh khoá ed25519 cho 5 node (một lần, giữ lại giữa các lần chạy)
+if [ ! -f keys/cluster.json ]; then
+    echo "🔑 Generating node keys -> keys/"
+    ./node-app -genkeys keys
+fi
+
 echo "🚀 Starting Dashboard (Logs -> dashboard.log)..."
 ./dashboard-app > dashboard.log 2>&1 &
 DASH_PID=$!
 
 echo "🚀 Starting 5 pBFT Nodes..."
-./node-app -id=node1 -port=50051 &
-./node-app -id=node2 -port=50052 &
-./node-app -id=node3 -port=50053 &
-./node-app -id=node4 -port=50054 &
-./node-app -id=node5 -port=50055 &
+./node-app -id=node1 -port=50051 -cluster=keys/cluster.json -key=keys/node1.key &
+./node-app -id=node2 -port=50052 -cluster=keys/cluster.json -key=keys/node2.key &
+./node-app -id=node3 -port=50053 -cluster=keys/cluster.json -key=keys/node3.key &
+./node-app -id=node4 -port=50054 -cluster=keys/cluster.json -key=keys/node4.key &
+./node-app -id=node5 -port=50055 -cluster=keys/cluster.json -key=keys/node5.key &
 
 echo "✅ SYSTEM STARTED!"
 echo "👉 Dashboard Log: tail -f dashboard.log"